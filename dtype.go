@@ -318,7 +318,7 @@ var dtype2GoType map[DType]reflect.Type = map[DType]reflect.Type{
 	Uint8:  reflect.TypeOf(uint8(0)),
 	Int8:   reflect.TypeOf(int8(0)),
 	Int16:  reflect.TypeOf(int16(0)),
-	Int:    reflect.TypeOf(int(0)),
+	Int:    reflect.TypeOf(int32(0)),
 	Int64:  reflect.TypeOf(int64(0)),
 	Half:   reflect.TypeOf(uint16(0)), // <- just uint16
 	Float:  reflect.TypeOf(float32(0)),
@@ -344,7 +344,7 @@ var dtype2GoType map[DType]reflect.Type = map[DType]reflect.Type{
 func (dt DType) GoType() (reflect.Type, error) {
 	typ, ok := dtype2GoType[dt]
 	if !ok {
-		err := fmt.Errorf("DType.GoType() failed: no corresponding Go type to given DType %v\n", typ.String())
+		err := fmt.Errorf("DType.GoType() failed: no corresponding Go type to given DType %v\n", dt)
 		return nil, err
 	}
 
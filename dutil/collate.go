@@ -0,0 +1,269 @@
+package dutil
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+// Collator merges a list of samples to form a mini-batch.
+type Collator interface {
+	Collate(batch []interface{}) (interface{}, error)
+}
+
+// CollateFunc is a function that implements Collator interface.
+type CollateFunc func(batch []interface{}) (interface{}, error)
+
+// Collate implements Collator interface.
+func (f CollateFunc) Collate(batch []interface{}) (interface{}, error) {
+	return f(batch)
+}
+
+// DefaultCollator collates samples similar to Pytorch `default_collate`:
+//
+//   - `*ts.Tensor` samples are stacked to a tensor with batch as first dimension.
+//   - Numeric or bool samples are converted to a tensor of shape [batch].
+//   - Numeric or bool slice samples of same length are converted to a tensor of shape [batch, length].
+//   - String samples are collected to a `[]string`.
+//   - Struct (or pointer to struct) samples are collated field by field to a `map[string]interface{}`
+//     keyed by exported field names.
+//   - Map samples with string keys are collated key by key to a `map[string]interface{}`.
+type DefaultCollator struct{}
+
+var _ Collator = &DefaultCollator{}
+
+// NewDefaultCollator creates a new DefaultCollator.
+func NewDefaultCollator() *DefaultCollator {
+	return &DefaultCollator{}
+}
+
+// Collate implements Collator interface.
+func (c *DefaultCollator) Collate(batch []interface{}) (interface{}, error) {
+	return collate(batch, nil)
+}
+
+// PaddedBatch is a batch of variable-length sequences padded to the same length.
+type PaddedBatch struct {
+	Data    *ts.Tensor // padded sequences of shape [batch, maxLen, ...]
+	Lengths *ts.Tensor // int64 tensor of shape [batch], lengths of sequences before padding
+	Mask    *ts.Tensor // bool tensor of shape [batch, maxLen], true at non-padded positions
+}
+
+// PaddingCollator collates samples as DefaultCollator does except that sequences
+// (i.e. `*ts.Tensor` or numeric slices) are padded to the longest one in the batch
+// and returned as `*PaddedBatch`. Sequence length is the size of the first dimension.
+type PaddingCollator struct {
+	PaddingValue float64
+}
+
+var _ Collator = &PaddingCollator{}
+
+// NewPaddingCollator creates a new PaddingCollator.
+func NewPaddingCollator(paddingValue float64) *PaddingCollator {
+	return &PaddingCollator{
+		PaddingValue: paddingValue,
+	}
+}
+
+// Collate implements Collator interface.
+func (c *PaddingCollator) Collate(batch []interface{}) (interface{}, error) {
+	return collate(batch, c)
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	_, err := gotch.GoKind2DType(kind)
+	return err == nil
+}
+
+// collate collates batch recursively. If pad is not nil, sequences are padded.
+func collate(batch []interface{}, pad *PaddingCollator) (interface{}, error) {
+	if len(batch) == 0 {
+		err := fmt.Errorf("collate: empty batch")
+		return nil, err
+	}
+
+	elem := batch[0]
+	if elem == nil {
+		err := fmt.Errorf("collate: nil sample")
+		return nil, err
+	}
+
+	if _, ok := elem.(*ts.Tensor); ok {
+		tensors := make([]*ts.Tensor, len(batch))
+		for i, item := range batch {
+			x, ok := item.(*ts.Tensor)
+			if !ok {
+				err := fmt.Errorf("collate: expected '*ts.Tensor' sample, got %T", item)
+				return nil, err
+			}
+			tensors[i] = x
+		}
+		if pad != nil {
+			return pad.padTensors(tensors)
+		}
+		return ts.Stack(tensors, 0)
+	}
+
+	elemType := reflect.TypeOf(elem)
+	for _, item := range batch {
+		if reflect.TypeOf(item) != elemType {
+			err := fmt.Errorf("collate: expected samples of same type %v, got %T", elemType, item)
+			return nil, err
+		}
+	}
+
+	switch kind := elemType.Kind(); {
+	case kind == reflect.String:
+		strs := make([]string, len(batch))
+		for i, item := range batch {
+			strs[i] = reflect.ValueOf(item).String()
+		}
+		return strs, nil
+
+	case isNumericKind(kind):
+		values := reflect.MakeSlice(reflect.SliceOf(elemType), 0, len(batch))
+		for _, item := range batch {
+			values = reflect.Append(values, reflect.ValueOf(item))
+		}
+		return ts.OfSlice(values.Interface())
+
+	case (kind == reflect.Slice || kind == reflect.Array) && isNumericKind(elemType.Elem().Kind()):
+		tensors := make([]*ts.Tensor, len(batch))
+		for i, item := range batch {
+			x, err := ts.OfSlice(item)
+			if err != nil {
+				return nil, err
+			}
+			tensors[i] = x
+		}
+		defer func() {
+			for _, x := range tensors {
+				if x != nil {
+					x.MustDrop()
+				}
+			}
+		}()
+		if pad != nil {
+			return pad.padTensors(tensors)
+		}
+		n := tensors[0].Numel()
+		for _, x := range tensors {
+			if x.Numel() != n {
+				err := fmt.Errorf("collate: expected slices of same length, got %v and %v. Use PaddingCollator for variable-length sequences", n, x.Numel())
+				return nil, err
+			}
+		}
+		return ts.Stack(tensors, 0)
+
+	case kind == reflect.Ptr && elemType.Elem().Kind() == reflect.Struct:
+		structs := make([]interface{}, len(batch))
+		for i, item := range batch {
+			v := reflect.ValueOf(item)
+			if v.IsNil() {
+				err := fmt.Errorf("collate: nil sample")
+				return nil, err
+			}
+			structs[i] = v.Elem().Interface()
+		}
+		return collate(structs, pad)
+
+	case kind == reflect.Struct:
+		out := make(map[string]interface{})
+		for f := 0; f < elemType.NumField(); f++ {
+			field := elemType.Field(f)
+			if !field.IsExported() {
+				continue
+			}
+			values := make([]interface{}, len(batch))
+			for i, item := range batch {
+				values[i] = reflect.ValueOf(item).Field(f).Interface()
+			}
+			v, err := collate(values, pad)
+			if err != nil {
+				err = fmt.Errorf("collate: field %q: %w", field.Name, err)
+				return nil, err
+			}
+			out[field.Name] = v
+		}
+		return out, nil
+
+	case kind == reflect.Map && elemType.Key().Kind() == reflect.String:
+		out := make(map[string]interface{})
+		keys := reflect.ValueOf(elem).MapKeys()
+		for _, key := range keys {
+			values := make([]interface{}, len(batch))
+			for i, item := range batch {
+				v := reflect.ValueOf(item).MapIndex(key)
+				if !v.IsValid() {
+					err := fmt.Errorf("collate: key %q not found in sample %v", key.String(), i)
+					return nil, err
+				}
+				values[i] = v.Interface()
+			}
+			v, err := collate(values, pad)
+			if err != nil {
+				err = fmt.Errorf("collate: key %q: %w", key.String(), err)
+				return nil, err
+			}
+			out[key.String()] = v
+		}
+		return out, nil
+
+	default:
+		err := fmt.Errorf("collate: unsupported sample type %v", elemType)
+		return nil, err
+	}
+}
+
+// padTensors pads tensors along the first dimension to the longest one.
+func (c *PaddingCollator) padTensors(tensors []*ts.Tensor) (*PaddedBatch, error) {
+	lengths := make([]int64, len(tensors))
+	var maxLen int64
+	for i, x := range tensors {
+		size, err := x.Size()
+		if err != nil {
+			return nil, err
+		}
+		if len(size) == 0 {
+			err := fmt.Errorf("PaddingCollator: expected sequence of at least 1 dimension, got scalar")
+			return nil, err
+		}
+		lengths[i] = size[0]
+		if size[0] > maxLen {
+			maxLen = size[0]
+		}
+	}
+
+	data, err := ts.PadSequence(tensors, true, c.PaddingValue)
+	if err != nil {
+		return nil, err
+	}
+
+	mask := make([]bool, int64(len(tensors))*maxLen)
+	for i, l := range lengths {
+		for j := int64(0); j < l; j++ {
+			mask[int64(i)*maxLen+j] = true
+		}
+	}
+	maskTs, err := ts.OfSlice(mask)
+	if err != nil {
+		return nil, err
+	}
+	maskTs, err = maskTs.View([]int64{int64(len(tensors)), maxLen}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	lengthsTs, err := ts.OfSlice(lengths)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaddedBatch{
+		Data:    data,
+		Lengths: lengthsTs,
+		Mask:    maskTs,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package dutil_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/dutil"
+	"github.com/sugarme/gotch/ts"
+)
+
+type sample struct {
+	Image *ts.Tensor
+	Label int64
+	Name  string
+}
+
+func TestDefaultCollator(t *testing.T) {
+	var data []sample
+	for i := 0; i < 4; i++ {
+		data = append(data, sample{
+			Image: ts.MustOnes([]int64{2, 2}, gotch.Float, gotch.CPU).MustMulScalar(ts.IntScalar(int64(i)), true),
+			Label: int64(i),
+			Name:  "img",
+		})
+	}
+	ds, err := dutil.NewSliceDataset(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := dutil.NewBatchSampler(len(data), 4, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl, err := dutil.NewDataLoader(ds, s, dutil.WithCollator(dutil.NewDefaultCollator()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := dl.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := batch.(map[string]interface{})
+
+	images := fields["Image"].(*ts.Tensor)
+	if !reflect.DeepEqual(images.MustSize(), []int64{4, 2, 2}) {
+		t.Errorf("want image shape [4 2 2], got %v", images.MustSize())
+	}
+	labels := fields["Label"].(*ts.Tensor)
+	if !reflect.DeepEqual(labels.Int64Values(), []int64{0, 1, 2, 3}) {
+		t.Errorf("want labels [0 1 2 3], got %v", labels.Int64Values())
+	}
+	names := fields["Name"].([]string)
+	if !reflect.DeepEqual(names, []string{"img", "img", "img", "img"}) {
+		t.Errorf("want names, got %v", names)
+	}
+}
+
+func TestDefaultCollator_Slices(t *testing.T) {
+	c := dutil.NewDefaultCollator()
+	got, err := c.Collate([]interface{}{[]float32{1, 2}, []float32{3, 4}, []float32{5, 6}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := got.(*ts.Tensor)
+	if !reflect.DeepEqual(x.MustSize(), []int64{3, 2}) {
+		t.Errorf("want shape [3 2], got %v", x.MustSize())
+	}
+	if !reflect.DeepEqual(x.Float64Values(), []float64{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("want [1 2 3 4 5 6], got %v", x.Float64Values())
+	}
+
+	_, err = c.Collate([]interface{}{[]float32{1, 2}, []float32{3}})
+	if err == nil {
+		t.Errorf("want error for variable-length slices")
+	}
+}
+
+func TestPaddingCollator(t *testing.T) {
+	c := dutil.NewPaddingCollator(0)
+	batch := []interface{}{
+		map[string]interface{}{"tokens": []int64{1, 2, 3}, "label": int64(1)},
+		map[string]interface{}{"tokens": []int64{4}, "label": int64(0)},
+		map[string]interface{}{"tokens": []int64{5, 6}, "label": int64(1)},
+	}
+	got, err := c.Collate(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := got.(map[string]interface{})
+
+	tokens := fields["tokens"].(*dutil.PaddedBatch)
+	if !reflect.DeepEqual(tokens.Data.MustSize(), []int64{3, 3}) {
+		t.Errorf("want shape [3 3], got %v", tokens.Data.MustSize())
+	}
+	if !reflect.DeepEqual(tokens.Data.Int64Values(), []int64{1, 2, 3, 4, 0, 0, 5, 6, 0}) {
+		t.Errorf("want padded data, got %v", tokens.Data.Int64Values())
+	}
+	if !reflect.DeepEqual(tokens.Lengths.Int64Values(), []int64{3, 1, 2}) {
+		t.Errorf("want lengths [3 1 2], got %v", tokens.Lengths.Int64Values())
+	}
+	if !reflect.DeepEqual(tokens.Mask.Vals(), []bool{true, true, true, true, false, false, true, true, false}) {
+		t.Errorf("want mask, got %v", tokens.Mask.Vals())
+	}
+
+	labels := fields["label"].(*ts.Tensor)
+	if !reflect.DeepEqual(labels.Int64Values(), []int64{1, 0, 1}) {
+		t.Errorf("want labels [1 0 1], got %v", labels.Int64Values())
+	}
+}
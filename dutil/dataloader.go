@@ -1,28 +1,138 @@
 package dutil
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"reflect"
-
-	"github.com/sugarme/gotch/ts"
+	"sync"
 )
 
 // DataLoader combines a dataset and a sampler and provides
 // an iterable over the given dataset.
+//
+// If number of workers is specified, batches are fetched by worker goroutines
+// and prefetched into a bounded queue. Batches are always returned in the order
+// of sampled indexes regardless of number of workers.
 type DataLoader struct {
 	dataset   Dataset
 	indexes   []int // order of samples in dataset for interation.
 	batchSize int
 	currIdx   int
 	sampler   Sampler
+
+	numWorkers int
+	prefetch   int
+	rand       *rand.Rand
+	ctx        context.Context
+	collator   Collator
+
+	// workers states
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	pending chan chan batchResult // batches in order of fetching
+	err     error
+}
+
+// DataLoaderOptions holds options for DataLoader.
+type DataLoaderOptions struct {
+	NumWorkers int             // number of worker goroutines. Default=0 (fetching in the calling goroutine)
+	Prefetch   int             // maximum number of batches fetched in advance. Default=2*NumWorkers
+	Rand       *rand.Rand      // random number generator for sampling. Default=nil (sampler's own)
+	Context    context.Context // context to stop workers. Default=context.Background()
+	Collator   Collator        // collator to merge samples to a batch. Default=nil (a slice of samples)
+}
+
+type DataLoaderOption func(*DataLoaderOptions)
+
+func NewDataLoaderOptions(options ...DataLoaderOption) DataLoaderOptions {
+	opts := DataLoaderOptions{
+		NumWorkers: 0,
+		Prefetch:   0,
+		Rand:       nil,
+		Context:    context.Background(),
+		Collator:   nil,
+	}
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	return opts
+}
+
+// WithNumWorkers sets number of worker goroutines to fetch batches.
+//
+// NOTE. Dataset `Item()` will be called concurrently from multiple goroutines.
+func WithNumWorkers(n int) DataLoaderOption {
+	return func(o *DataLoaderOptions) {
+		o.NumWorkers = n
+	}
+}
+
+// WithPrefetch sets maximum number of batches to be fetched in advance.
+func WithPrefetch(n int) DataLoaderOption {
+	return func(o *DataLoaderOptions) {
+		o.Prefetch = n
+	}
+}
+
+// WithSeed sets seed for sampling so that order of samples is deterministic.
+//
+// It takes effect on samplers implementing `RandSampler` interface.
+func WithSeed(seed int64) DataLoaderOption {
+	return func(o *DataLoaderOptions) {
+		o.Rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithRandGenerator sets random number generator for sampling. It's similar to
+// `WithSeed()` but the generator can be shared with other components, e.g. `KFold`.
+//
+// It takes effect on samplers implementing `RandSampler` interface.
+func WithRandGenerator(r *rand.Rand) DataLoaderOption {
+	return func(o *DataLoaderOptions) {
+		o.Rand = r
+	}
+}
+
+// WithContext sets context to stop workers when it is canceled.
+func WithContext(ctx context.Context) DataLoaderOption {
+	return func(o *DataLoaderOptions) {
+		o.Context = ctx
+	}
 }
 
-func NewDataLoader(data Dataset, s Sampler) (*DataLoader, error) {
+// WithCollator sets collator to merge samples to a batch. E.g. `NewDefaultCollator()`
+// to stack samples to tensors.
+//
+// NOTE. If number of workers is specified, collating is done in worker goroutines.
+func WithCollator(c Collator) DataLoaderOption {
+	return func(o *DataLoaderOptions) {
+		o.Collator = c
+	}
+}
+
+func NewDataLoader(data Dataset, s Sampler, opts ...DataLoaderOption) (*DataLoader, error) {
 	dkind, err := checkDKind(data)
 	if err != nil {
 		return nil, err
 	}
 
+	options := NewDataLoaderOptions(opts...)
+	if options.NumWorkers < 0 {
+		err := fmt.Errorf("Invalid number of workers: %v", options.NumWorkers)
+		return nil, err
+	}
+	if options.Prefetch < 0 {
+		err := fmt.Errorf("Invalid prefetch size: %v", options.Prefetch)
+		return nil, err
+	}
+	prefetch := options.Prefetch
+	if prefetch == 0 {
+		prefetch = 2 * options.NumWorkers
+	}
+
 	// Use default Sampler if no specified
 	if s == nil {
 		switch dkind {
@@ -36,13 +146,20 @@ func NewDataLoader(data Dataset, s Sampler) (*DataLoader, error) {
 		}
 	}
 
-	return &DataLoader{
-		dataset:   data,
-		indexes:   s.Sample(),
-		batchSize: s.BatchSize(),
-		currIdx:   0,
-		sampler:   s,
-	}, nil
+	dl := &DataLoader{
+		dataset:    data,
+		batchSize:  s.BatchSize(),
+		currIdx:    0,
+		sampler:    s,
+		numWorkers: options.NumWorkers,
+		prefetch:   prefetch,
+		rand:       options.Rand,
+		ctx:        options.Context,
+		collator:   options.Collator,
+	}
+	dl.indexes = dl.sample()
+
+	return dl, nil
 }
 
 func checkDKind(data Dataset) (DatasetKind, error) {
@@ -65,48 +182,177 @@ func checkDKind(data Dataset) (DatasetKind, error) {
 	}
 }
 
+// sample draws indexes from sampler using data loader random generator if any.
+func (dl *DataLoader) sample() []int {
+	if rs, ok := dl.sampler.(RandSampler); ok && dl.rand != nil {
+		return rs.SampleRand(dl.rand)
+	}
+
+	return dl.sampler.Sample()
+}
+
+// fetch gets items of given indexes from dataset and returns them in a slice
+// or collated by collator if specified.
+func (dl *DataLoader) fetch(indexes []int) (interface{}, error) {
+	if dl.collator != nil {
+		batch := make([]interface{}, len(indexes))
+		for i, idx := range indexes {
+			item, err := dl.dataset.Item(idx)
+			if err != nil {
+				return nil, err
+			}
+			batch[i] = item
+		}
+		return dl.collator.Collate(batch)
+	}
+
+	var items reflect.Value
+	for i, idx := range indexes {
+		item, err := dl.dataset.Item(idx)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			items = reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(item)), 0, len(indexes))
+		}
+		items = reflect.Append(items, reflect.ValueOf(item))
+	}
+
+	return items.Interface(), nil
+}
+
+type batchJob struct {
+	indexes []int
+	result  chan batchResult
+}
+
+type batchResult struct {
+	items interface{}
+	err   error
+}
+
+// start starts workers to fetch batches from current index.
+func (dl *DataLoader) start() {
+	ctx, cancel := context.WithCancel(dl.ctx)
+	dl.cancel = cancel
+
+	jobs := make(chan batchJob)
+	pending := make(chan chan batchResult, dl.prefetch)
+	dl.pending = pending
+
+	for w := 0; w < dl.numWorkers; w++ {
+		dl.wg.Add(1)
+		go func() {
+			defer dl.wg.Done()
+			for job := range jobs {
+				items, err := dl.fetch(job.indexes)
+				job.result <- batchResult{items, err}
+			}
+		}()
+	}
+
+	// Dispatcher: queues batches in order. It blocks when prefetch queue is full.
+	indexes, batchSize := dl.indexes, dl.batchSize
+	dl.wg.Add(1)
+	go func(start int) {
+		defer dl.wg.Done()
+		defer close(pending)
+		defer close(jobs)
+
+		for i := start; i < len(indexes); i += batchSize {
+			end := i + batchSize
+			if end > len(indexes) {
+				end = len(indexes)
+			}
+
+			result := make(chan batchResult, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- batchJob{indexes[i:end], result}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(dl.currIdx)
+}
+
+// stop stops workers if any and waits for them to exit.
+func (dl *DataLoader) stop() {
+	if dl.cancel == nil {
+		return
+	}
+
+	dl.cancel()
+	dl.wg.Wait()
+	dl.cancel = nil
+	dl.pending = nil
+}
+
 // Next acts as iterator to return next sample(s) from dataset.
 func (dl *DataLoader) Next() (interface{}, error) {
+	if dl.err != nil {
+		return nil, dl.err
+	}
+
 	if !dl.HasNext() {
 		err := fmt.Errorf("Next Error: no more item to iterate.\n")
 		return nil, err
 	}
 
-	// determine element dtype
-	elem, err := dl.dataset.Item(0)
-	if err != nil {
+	if err := dl.ctx.Err(); err != nil {
+		dl.stop()
 		return nil, err
 	}
 
-	elemType := reflect.TypeOf(elem)
-	// Free up memory if element is Tensor
-	switch elemType.String() {
-	case "[]tensor.Tensor":
-		for _, el := range elem.([]ts.Tensor) {
-			el.MustDrop()
-		}
-	case "*tensor.Tensor":
-		elem.(*ts.Tensor).MustDrop()
-	}
-
-	// Get a batch based on batch size
-	items := reflect.MakeSlice(reflect.SliceOf(elemType), 0, dl.dataset.Len())
 	nextIndex := dl.currIdx + dl.batchSize
-
 	// NOTE. length of indexes can be shorter than dataset length
 	if nextIndex >= len(dl.indexes) {
 		nextIndex = len(dl.indexes)
 	}
-	for i := dl.currIdx; i < nextIndex; i++ {
-		item, err := dl.dataset.Item(dl.indexes[i])
+
+	if dl.numWorkers == 0 {
+		items, err := dl.fetch(dl.indexes[dl.currIdx:nextIndex])
 		if err != nil {
 			return nil, err
 		}
-		items = reflect.Append(items, reflect.ValueOf(item))
+		dl.currIdx = nextIndex
+		return items, nil
+	}
+
+	if dl.pending == nil {
+		dl.start()
+	}
+
+	var res batchResult
+	select {
+	case result, ok := <-dl.pending:
+		if !ok {
+			dl.stop()
+			return nil, dl.ctx.Err()
+		}
+		select {
+		case res = <-result:
+		case <-dl.ctx.Done():
+			dl.stop()
+			return nil, dl.ctx.Err()
+		}
+	case <-dl.ctx.Done():
+		dl.stop()
+		return nil, dl.ctx.Err()
+	}
+
+	if res.err != nil {
+		dl.stop()
+		dl.err = res.err
+		return nil, res.err
 	}
 
 	dl.currIdx = nextIndex
-	return items.Interface(), nil
+	return res.items, nil
 }
 
 // HasNext returns whether there is a next item in the iteration.
@@ -116,12 +362,15 @@ func (dl *DataLoader) HasNext() bool {
 
 // Reset reset index to start position.
 func (dl *DataLoader) Reset(shuffleOpt ...bool) {
+	dl.stop()
+	dl.err = nil
+
 	shuffle := false
 	if len(shuffleOpt) > 0 {
 		shuffle = shuffleOpt[0]
 	}
 	if shuffle {
-		dl.indexes = dl.sampler.Sample()
+		dl.indexes = dl.sample()
 	}
 	dl.currIdx = 0
 }
@@ -130,3 +379,8 @@ func (dl *DataLoader) Reset(shuffleOpt ...bool) {
 func (dl *DataLoader) Len() int {
 	return len(dl.indexes)
 }
+
+// Close stops all workers. Prefetched batches are discarded.
+func (dl *DataLoader) Close() {
+	dl.stop()
+}
@@ -2,6 +2,8 @@ package dutil_test
 
 import (
 	// "reflect"
+	"context"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -42,3 +44,148 @@ func TestDataLoader_Next(t *testing.T) {
 		t.Errorf("Got: %v\n", got)
 	}
 }
+
+func collectBatches(t *testing.T, dl *dutil.DataLoader) [][]int {
+	var batches [][]int
+	for dl.HasNext() {
+		batch, err := dl.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		batches = append(batches, batch.([]int))
+	}
+	return batches
+}
+
+func TestDataLoader_Workers(t *testing.T) {
+	var data []int
+	for i := 0; i < 100; i++ {
+		data = append(data, i)
+	}
+	ds, err := dutil.NewSliceDataset(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := dutil.NewBatchSampler(len(data), 8, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl, err := dutil.NewDataLoader(ds, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := collectBatches(t, dl)
+
+	for _, numWorkers := range []int{1, 4} {
+		dl, err := dutil.NewDataLoader(ds, s, dutil.WithNumWorkers(numWorkers), dutil.WithPrefetch(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := collectBatches(t, dl)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("workers=%v: want %v, got %v", numWorkers, want, got)
+		}
+
+		// second epoch
+		dl.Reset()
+		got = collectBatches(t, dl)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("workers=%v (reset): want %v, got %v", numWorkers, want, got)
+		}
+		dl.Close()
+	}
+}
+
+func TestDataLoader_Seed(t *testing.T) {
+	data := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	ds, err := dutil.NewSliceDataset(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newLoader := func() *dutil.DataLoader {
+		s, err := dutil.NewBatchSampler(len(data), 3, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dl, err := dutil.NewDataLoader(ds, s, dutil.WithNumWorkers(2), dutil.WithSeed(42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dl
+	}
+
+	dl1 := newLoader()
+	defer dl1.Close()
+	dl2 := newLoader()
+	defer dl2.Close()
+	for epoch := 0; epoch < 3; epoch++ {
+		got1 := collectBatches(t, dl1)
+		got2 := collectBatches(t, dl2)
+		if !reflect.DeepEqual(got1, got2) {
+			t.Errorf("epoch %v: want same order, got %v and %v", epoch, got1, got2)
+		}
+		dl1.Reset(true)
+		dl2.Reset(true)
+	}
+}
+
+type errDataset struct {
+	*dutil.SliceDataset
+	errIdx int
+}
+
+func (ds *errDataset) Item(idx int) (interface{}, error) {
+	if idx == ds.errIdx {
+		return nil, fmt.Errorf("corrupted item %v", idx)
+	}
+	return ds.SliceDataset.Item(idx)
+}
+
+func TestDataLoader_WorkerError(t *testing.T) {
+	sds, err := dutil.NewSliceDataset([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := &errDataset{sds, 5}
+
+	dl, err := dutil.NewDataLoader(ds, nil, dutil.WithNumWorkers(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dl.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := dl.Next(); err != nil {
+			t.Fatalf("unexpected error at %v: %v", i, err)
+		}
+	}
+	if _, err := dl.Next(); err == nil {
+		t.Errorf("want error from Item, got nil")
+	}
+	if _, err := dl.Next(); err == nil {
+		t.Errorf("want error after failure, got nil")
+	}
+}
+
+func TestDataLoader_Context(t *testing.T) {
+	ds, err := dutil.NewSliceDataset([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dl, err := dutil.NewDataLoader(ds, nil, dutil.WithNumWorkers(2), dutil.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dl.Next(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := dl.Next(); err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
@@ -12,7 +12,7 @@ type KFold struct {
 	n       int
 	nfolds  int
 	shuffle bool
-	//seed int
+	rand    *rand.Rand
 }
 
 // Fold represents a partitions with
@@ -23,8 +23,9 @@ type Fold struct {
 }
 
 type KFoldOptions struct {
-	NFolds  int  // number of folds
-	Shuffle bool // whether suffling before splitting
+	NFolds  int        // number of folds
+	Shuffle bool       // whether suffling before splitting
+	Rand    *rand.Rand // random number generator for shuffling. Default=nil (see `newRand()`)
 }
 
 type KFoldOption func(*KFoldOptions)
@@ -54,6 +55,14 @@ func WithKFoldShuffle(shuffle bool) KFoldOption {
 	}
 }
 
+// WithKFoldRand sets random number generator for shuffling so that
+// splits are reproducible.
+func WithKFoldRand(r *rand.Rand) KFoldOption {
+	return func(o *KFoldOptions) {
+		o.Rand = r
+	}
+}
+
 // NewKFold creates a new KFold struct.
 func NewKFold(n int, opt ...KFoldOption) (*KFold, error) {
 	opts := NewKFoldOptions(opt...)
@@ -72,6 +81,7 @@ func NewKFold(n int, opt ...KFoldOption) (*KFold, error) {
 		n:       n,
 		nfolds:  opts.NFolds,
 		shuffle: opts.Shuffle,
+		rand:    opts.Rand,
 	}, nil
 }
 
@@ -81,7 +91,7 @@ func (kf *KFold) Split() []Fold {
 	fsize := nsamples / kf.nfolds
 	var indices []int
 
-	allIndices := rand.Perm(kf.n)
+	allIndices := newRand(kf.rand).Perm(kf.n)
 	// Drop last odd-time elements
 	indices = allIndices[:nsamples]
 
@@ -141,3 +151,93 @@ func values(data []int, keys []int) []int {
 	}
 	return vals
 }
+
+// StratifiedKFold splits data into folds that preserve
+// the percentage of samples of each class (label).
+type StratifiedKFold struct {
+	labels  []int
+	nfolds  int
+	shuffle bool
+	rand    *rand.Rand
+}
+
+// NewStratifiedKFold creates a new StratifiedKFold.
+//
+// labels: class label of each sample in dataset.
+func NewStratifiedKFold(labels []int, opt ...KFoldOption) (*StratifiedKFold, error) {
+	opts := NewKFoldOptions(opt...)
+	n := len(labels)
+
+	if opts.NFolds < 2 {
+		err := fmt.Errorf("nfolds must be at least 2. Got: %v\n", opts.NFolds)
+		return nil, err
+	}
+
+	if opts.NFolds > n {
+		err := fmt.Errorf("nfolds cannot be greater than number of samples (%v). Got: %v\n", n, opts.NFolds)
+		return nil, err
+	}
+
+	ls := make([]int, n)
+	copy(ls, labels)
+
+	return &StratifiedKFold{
+		labels:  ls,
+		nfolds:  opts.NFolds,
+		shuffle: opts.Shuffle,
+		rand:    opts.Rand,
+	}, nil
+}
+
+// Split splits data into folds. Samples of each class are distributed
+// to folds in turn so that sizes of a class in folds differ by at most one.
+func (kf *StratifiedKFold) Split() []Fold {
+	// group sample indices by class
+	classIndices := make(map[int][]int)
+	var classes []int
+	for i, l := range kf.labels {
+		if _, ok := classIndices[l]; !ok {
+			classes = append(classes, l)
+		}
+		classIndices[l] = append(classIndices[l], i)
+	}
+	sort.Ints(classes)
+
+	r := newRand(kf.rand)
+	folds := make([][]int, kf.nfolds)
+	// Continue assigning from where the previous class stopped
+	// to balance fold sizes.
+	next := 0
+	for _, c := range classes {
+		indices := classIndices[c]
+		if kf.shuffle {
+			r.Shuffle(len(indices), func(i, j int) {
+				indices[i], indices[j] = indices[j], indices[i]
+			})
+		}
+		for _, idx := range indices {
+			folds[next] = append(folds[next], idx)
+			next = (next + 1) % kf.nfolds
+		}
+	}
+
+	var splits []Fold
+	for i := 0; i < kf.nfolds; i++ {
+		test := folds[i]
+		var train []int
+		for j, f := range folds {
+			if j != i {
+				train = append(train, f...)
+			}
+		}
+		sort.Ints(test)
+		sort.Ints(train)
+
+		splits = append(splits, Fold{
+			Test:  test,
+			Train: train,
+		})
+	}
+
+	return splits
+}
@@ -1,6 +1,8 @@
 package dutil_test
 
 import (
+	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/sugarme/gotch/dutil"
@@ -54,3 +56,56 @@ func TestKFold_Split(t *testing.T) {
 		}
 	}
 }
+
+func TestStratifiedKFold_Split(t *testing.T) {
+	// 8 samples of class 0, 4 samples of class 1
+	labels := []int{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1}
+	nfolds := 4
+
+	kf, err := dutil.NewStratifiedKFold(labels, dutil.WithNFolds(nfolds), dutil.WithKFoldShuffle(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	splits := kf.Split()
+	if len(splits) != nfolds {
+		t.Fatalf("Want number of folds: %v. Got: %v\n", nfolds, len(splits))
+	}
+
+	seen := make(map[int]bool)
+	for _, f := range splits {
+		count := make(map[int]int)
+		for _, idx := range f.Test {
+			count[labels[idx]]++
+			if seen[idx] {
+				t.Errorf("Sample %v in multiple test folds", idx)
+			}
+			seen[idx] = true
+		}
+		if count[0] != 2 || count[1] != 1 {
+			t.Errorf("Want 2 samples of class 0 and 1 of class 1 in test fold. Got: %v\n", count)
+		}
+		if len(f.Train) != len(labels)-len(f.Test) {
+			t.Errorf("Want train length: %v. Got: %v\n", len(labels)-len(f.Test), len(f.Train))
+		}
+	}
+	if len(seen) != len(labels) {
+		t.Errorf("Want all %v samples tested. Got: %v\n", len(labels), len(seen))
+	}
+}
+
+func TestKFold_Rand(t *testing.T) {
+	split := func(seed int64) []dutil.Fold {
+		kf, err := dutil.NewKFold(20, dutil.WithNFolds(4), dutil.WithKFoldShuffle(true), dutil.WithKFoldRand(rand.New(rand.NewSource(seed))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kf.Split()
+	}
+
+	want := split(42)
+	got := split(42)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Want same splits with same seed.\nWant: %v\nGot: %v\n", want, got)
+	}
+}
@@ -2,8 +2,11 @@ package dutil
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
-	"time"
+	"sort"
+
+	"github.com/sugarme/gotch"
 )
 
 // Sampler represents an interface to draw sample
@@ -49,11 +52,13 @@ type RandomSampler struct {
 	size        int  // size of sampling
 	replacement bool // whether replacement or not
 	batchSize   int  // always = 1
+	rand        *rand.Rand
 }
 
 type RandOptions struct {
 	Size        int
 	Replacement bool
+	Rand        *rand.Rand // random number generator. Default=nil (see `newRand()`)
 }
 
 type RandOption func(*RandOptions)
@@ -83,6 +88,14 @@ func WithReplacement(replacement bool) RandOption {
 	}
 }
 
+// WithSamplerRand sets random number generator of the sampler so that
+// samples are reproducible.
+func WithSamplerRand(r *rand.Rand) RandOption {
+	return func(o *RandOptions) {
+		o.Rand = r
+	}
+}
+
 // NewRandomSampler creates a new RandomSampler.
 //
 // n : number of samples in dataset
@@ -107,12 +120,40 @@ func NewRandomSampler(n int, opt ...RandOption) (*RandomSampler, error) {
 		size:        size,
 		replacement: opts.Replacement,
 		batchSize:   1,
+		rand:        opts.Rand,
 	}, nil
 }
 
+// newRand returns r if it's not nil. Otherwise, it returns a new generator
+// derived from gotch generator, which is seeded by `gotch.ManualSeed()`.
+func newRand(r *rand.Rand) *rand.Rand {
+	if r != nil {
+		return r
+	}
+
+	return gotch.NewRand()
+}
+
+// RandSampler is a Sampler which can draw samples using a given
+// random number generator. DataLoader uses it to make sampling deterministic
+// when a seed is specified.
+type RandSampler interface {
+	Sampler
+	SampleRand(r *rand.Rand) []int
+}
+
 // Sample implements Sampler interface.
 func (s *RandomSampler) Sample() []int {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return s.SampleRand(newRand(s.rand))
+}
+
+// SetRand sets random number generator used by `Sample()`.
+func (s *RandomSampler) SetRand(r *rand.Rand) {
+	s.rand = r
+}
+
+// SampleRand implements RandSampler interface.
+func (s *RandomSampler) SampleRand(r *rand.Rand) []int {
 	var indices []int
 
 	if !s.replacement {
@@ -168,6 +209,7 @@ type BatchSampler struct {
 	batchSize int
 	shuffle   bool
 	dropLast  bool
+	rand      *rand.Rand
 }
 
 // NewBatchSampler creates a new BatchSampler.
@@ -192,6 +234,16 @@ func NewBatchSampler(n, batchSize int, dropLast bool, shuffleOpt ...bool) (*Batc
 
 // Sample implements Sampler interface
 func (s *BatchSampler) Sample() []int {
+	return s.SampleRand(newRand(s.rand))
+}
+
+// SetRand sets random number generator used by `Sample()`.
+func (s *BatchSampler) SetRand(r *rand.Rand) {
+	s.rand = r
+}
+
+// SampleRand implements RandSampler interface.
+func (s *BatchSampler) SampleRand(r *rand.Rand) []int {
 	var (
 		batch   []int
 		batches []int
@@ -205,7 +257,6 @@ func (s *BatchSampler) Sample() []int {
 		}
 	case true:
 		// random permutation
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
 		indices = r.Perm(s.n)
 	}
 
@@ -227,3 +278,316 @@ func (s *BatchSampler) Sample() []int {
 func (s *BatchSampler) BatchSize() int {
 	return s.batchSize
 }
+
+// DistributedSampler restricts sampling to a subset of dataset for a process
+// (rank) in distributed training. Each process gets a distinct shard of indices.
+//
+// Indices are shuffled deterministically by seed and epoch so that all processes
+// agree on the partitioning. If not dropping last, indices are padded by repeating
+// from the start so that all shards have the same length.
+type DistributedSampler struct {
+	n         int
+	rank      int
+	worldSize int
+	seed      int64
+	epoch     int
+	shuffle   bool
+	dropLast  bool
+	batchSize int // always = 1
+}
+
+type DistributedOptions struct {
+	Shuffle  bool // whether shuffling indices. Default=true
+	DropLast bool // whether dropping tail of data to make it evenly divisible across processes. Default=false
+}
+
+type DistributedOption func(*DistributedOptions)
+
+func NewDistributedOptions(options ...DistributedOption) DistributedOptions {
+	opts := DistributedOptions{
+		Shuffle:  true,
+		DropLast: false,
+	}
+
+	for _, o := range options {
+		o(&opts)
+	}
+
+	return opts
+}
+
+func WithDistributedShuffle(shuffle bool) DistributedOption {
+	return func(o *DistributedOptions) {
+		o.Shuffle = shuffle
+	}
+}
+
+func WithDropLast(dropLast bool) DistributedOption {
+	return func(o *DistributedOptions) {
+		o.DropLast = dropLast
+	}
+}
+
+// NewDistributedSampler creates a new DistributedSampler.
+//
+// n : number of samples in dataset
+// rank: rank of current process in [0, worldSize)
+// worldSize: number of processes
+// seed: random seed for shuffling. Must be the same across all processes.
+// epoch: current epoch. Should be updated with `SetEpoch()` at every epoch to get different shuffling.
+func NewDistributedSampler(n, rank, worldSize int, seed int64, epoch int, opt ...DistributedOption) (*DistributedSampler, error) {
+	opts := NewDistributedOptions(opt...)
+
+	if worldSize < 1 {
+		err := fmt.Errorf("Invalid world size: must be at least 1. Got %v", worldSize)
+		return nil, err
+	}
+	if rank < 0 || rank >= worldSize {
+		err := fmt.Errorf("Invalid rank: must be in range [0, %v). Got %v", worldSize, rank)
+		return nil, err
+	}
+	if n < 1 {
+		err := fmt.Errorf("Invalid number of samples: %v", n)
+		return nil, err
+	}
+	if opts.DropLast && n < worldSize {
+		err := fmt.Errorf("Number of samples (%v) is less than world size (%v) with dropping last", n, worldSize)
+		return nil, err
+	}
+
+	return &DistributedSampler{
+		n:         n,
+		rank:      rank,
+		worldSize: worldSize,
+		seed:      seed,
+		epoch:     epoch,
+		shuffle:   opts.Shuffle,
+		dropLast:  opts.DropLast,
+		batchSize: 1,
+	}, nil
+}
+
+// SetEpoch sets epoch for shuffling.
+func (s *DistributedSampler) SetEpoch(epoch int) {
+	s.epoch = epoch
+}
+
+// NumSamples returns number of samples of current rank.
+func (s *DistributedSampler) NumSamples() int {
+	if s.dropLast {
+		return s.n / s.worldSize
+	}
+
+	return (s.n + s.worldSize - 1) / s.worldSize
+}
+
+// Sample implements Sampler interface.
+func (s *DistributedSampler) Sample() []int {
+	var indices []int
+	if s.shuffle {
+		r := rand.New(rand.NewSource(s.seed + int64(s.epoch)))
+		indices = r.Perm(s.n)
+	} else {
+		indices = intRange(s.n)
+	}
+
+	totalSize := s.NumSamples() * s.worldSize
+	if totalSize > len(indices) {
+		// pad by repeating indices from the start
+		padding := totalSize - len(indices)
+		for padding > 0 {
+			m := padding
+			if m > s.n {
+				m = s.n
+			}
+			indices = append(indices, indices[:m]...)
+			padding -= m
+		}
+	} else {
+		indices = indices[:totalSize]
+	}
+
+	var shard []int
+	for i := s.rank; i < totalSize; i += s.worldSize {
+		shard = append(shard, indices[i])
+	}
+
+	return shard
+}
+
+// BatchSize implements Sampler interface.
+// It's always return 1.
+func (s *DistributedSampler) BatchSize() int {
+	return s.batchSize
+}
+
+// WeightedRandomSampler draws samples with given probabilities (weights).
+// Weights do not need to sum up to one.
+type WeightedRandomSampler struct {
+	weights     []float64
+	size        int  // size of sampling
+	replacement bool // whether samples are drawn with replacement
+	batchSize   int  // always = 1
+	rand        *rand.Rand
+}
+
+// NewWeightedRandomSampler creates a new WeightedRandomSampler.
+//
+// weights: weight of each sample in dataset
+// size: size of sampling
+// replacement: if true, a sample can be drawn multiple times. If false, size cannot
+// exceed number of samples with non-zero weight.
+func NewWeightedRandomSampler(weights []float64, size int, replacement bool) (*WeightedRandomSampler, error) {
+	var nonZero int
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			err := fmt.Errorf("Invalid weight at %v: weights must be finite and non-negative. Got %v", i, w)
+			return nil, err
+		}
+		if w > 0 {
+			nonZero++
+		}
+	}
+	if nonZero == 0 {
+		err := fmt.Errorf("Invalid weights: at least one weight must be positive")
+		return nil, err
+	}
+	if size < 1 {
+		err := fmt.Errorf("Invalid sampling size: %v", size)
+		return nil, err
+	}
+	if !replacement && size > nonZero {
+		err := fmt.Errorf("Sampling size (%v) can not be greater than number of samples with non-zero weight (%v) without replacement.", size, nonZero)
+		return nil, err
+	}
+
+	ws := make([]float64, len(weights))
+	copy(ws, weights)
+
+	return &WeightedRandomSampler{
+		weights:     ws,
+		size:        size,
+		replacement: replacement,
+		batchSize:   1,
+	}, nil
+}
+
+// Sample implements Sampler interface.
+func (s *WeightedRandomSampler) Sample() []int {
+	return s.SampleRand(newRand(s.rand))
+}
+
+// SetRand sets random number generator used by `Sample()`.
+func (s *WeightedRandomSampler) SetRand(r *rand.Rand) {
+	s.rand = r
+}
+
+// SampleRand implements RandSampler interface.
+func (s *WeightedRandomSampler) SampleRand(r *rand.Rand) []int {
+	indices := make([]int, 0, s.size)
+
+	if s.replacement {
+		// Inverse transform sampling with cumulative weights.
+		cumsum := make([]float64, len(s.weights))
+		var total float64
+		for i, w := range s.weights {
+			total += w
+			cumsum[i] = total
+		}
+		for len(indices) < s.size {
+			// u in (0, total] so that zero-weight samples are never drawn.
+			u := total - r.Float64()*total
+			idx := sort.SearchFloat64s(cumsum, u)
+			if idx >= len(cumsum) {
+				idx = len(cumsum) - 1
+			}
+			indices = append(indices, idx)
+		}
+		return indices
+	}
+
+	// Without replacement: weighted random sampling with keys u^(1/w).
+	// Ref. Efraimidis & Spirakis (2006) "Weighted random sampling with a reservoir".
+	type keyIdx struct {
+		key float64
+		idx int
+	}
+	var keys []keyIdx
+	for i, w := range s.weights {
+		if w == 0 {
+			continue
+		}
+		keys = append(keys, keyIdx{math.Log(r.Float64()) / w, i})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].key > keys[j].key
+	})
+	for _, k := range keys[:s.size] {
+		indices = append(indices, k.idx)
+	}
+
+	return indices
+}
+
+// BatchSize implements Sampler interface.
+// It's always return 1.
+func (s *WeightedRandomSampler) BatchSize() int {
+	return s.batchSize
+}
+
+// SubsetSampler draws samples from a given list of indices, e.g. a `Fold`
+// split by `KFold` or `StratifiedKFold`.
+type SubsetSampler struct {
+	indices   []int
+	shuffle   bool
+	batchSize int // always = 1
+	rand      *rand.Rand
+}
+
+// NewSubsetSampler creates a new SubsetSampler.
+func NewSubsetSampler(indices []int, shuffleOpt ...bool) *SubsetSampler {
+	shuffle := false
+	if len(shuffleOpt) > 0 {
+		shuffle = shuffleOpt[0]
+	}
+
+	idxs := make([]int, len(indices))
+	copy(idxs, indices)
+
+	return &SubsetSampler{
+		indices:   idxs,
+		shuffle:   shuffle,
+		batchSize: 1,
+	}
+}
+
+// Sample implements Sampler interface.
+func (s *SubsetSampler) Sample() []int {
+	return s.SampleRand(newRand(s.rand))
+}
+
+// SetRand sets random number generator used by `Sample()`.
+func (s *SubsetSampler) SetRand(r *rand.Rand) {
+	s.rand = r
+}
+
+// SampleRand implements RandSampler interface.
+func (s *SubsetSampler) SampleRand(r *rand.Rand) []int {
+	indices := make([]int, len(s.indices))
+	if !s.shuffle {
+		copy(indices, s.indices)
+		return indices
+	}
+
+	for i, j := range r.Perm(len(s.indices)) {
+		indices[i] = s.indices[j]
+	}
+
+	return indices
+}
+
+// BatchSize implements Sampler interface.
+// It's always return 1.
+func (s *SubsetSampler) BatchSize() int {
+	return s.batchSize
+}
@@ -2,9 +2,11 @@ package dutil_test
 
 import (
 	// "fmt"
+	"math/rand"
 	"reflect"
 	"testing"
 
+	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/dutil"
 )
 
@@ -142,3 +144,150 @@ func seq(n int) []int {
 	}
 	return s
 }
+
+func TestDistributedSampler(t *testing.T) {
+	n := 10
+	worldSize := 3
+
+	var all []int
+	var shards [][]int
+	for rank := 0; rank < worldSize; rank++ {
+		s, err := dutil.NewDistributedSampler(n, rank, worldSize, 42, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shard := s.Sample()
+		if len(shard) != 4 {
+			t.Errorf("Want shard length 4 (padded). Got: %v\n", len(shard))
+		}
+		// deterministic
+		if !reflect.DeepEqual(shard, s.Sample()) {
+			t.Errorf("Want same shard for same seed and epoch")
+		}
+		shards = append(shards, shard)
+		all = append(all, shard...)
+	}
+
+	seen := make(map[int]bool)
+	for _, idx := range all {
+		seen[idx] = true
+	}
+	if len(seen) != n {
+		t.Errorf("Want all %v samples covered. Got: %v\n", n, len(seen))
+	}
+
+	// different epoch, different shuffling
+	s, err := dutil.NewDistributedSampler(n, 0, worldSize, 42, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetEpoch(1)
+	if reflect.DeepEqual(shards[0], s.Sample()) {
+		t.Errorf("Want different shard for different epoch")
+	}
+
+	// drop last
+	s, err = dutil.NewDistributedSampler(n, 2, worldSize, 42, 0, dutil.WithDropLast(true), dutil.WithDistributedShuffle(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{2, 5, 8}
+	if got := s.Sample(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Want: %v. Got: %v\n", want, got)
+	}
+
+	// invalid rank
+	if _, err := dutil.NewDistributedSampler(n, 3, worldSize, 42, 0); err == nil {
+		t.Errorf("Expected error: invalid rank. Got nil.")
+	}
+}
+
+func TestWeightedRandomSampler(t *testing.T) {
+	weights := []float64{0, 1, 0, 3}
+
+	s, err := dutil.NewWeightedRandomSampler(weights, 1000, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := make(map[int]int)
+	for _, idx := range s.Sample() {
+		count[idx]++
+	}
+	if count[0] != 0 || count[2] != 0 {
+		t.Errorf("Unexpected samples with zero weight. Got: %v\n", count)
+	}
+	if count[3] < 2*count[1] {
+		t.Errorf("Want about 3 times more samples of index 3 than index 1. Got: %v\n", count)
+	}
+
+	s, err = dutil.NewWeightedRandomSampler(weights, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indices := s.Sample()
+	if len(indices) != 2 || isDup(indices) {
+		t.Errorf("Want 2 distinct samples. Got: %v\n", indices)
+	}
+
+	if _, err := dutil.NewWeightedRandomSampler(weights, 3, false); err == nil {
+		t.Errorf("Expected error: size greater than number of non-zero weights. Got nil.")
+	}
+}
+
+func TestSubsetSampler(t *testing.T) {
+	s := dutil.NewSubsetSampler([]int{7, 3, 5})
+	want := []int{7, 3, 5}
+	if got := s.Sample(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Want: %v. Got: %v\n", want, got)
+	}
+}
+
+func TestSampler_Rand(t *testing.T) {
+	newSamplers := func(seed int64) []dutil.Sampler {
+		rs, err := dutil.NewRandomSampler(20, dutil.WithSamplerRand(rand.New(rand.NewSource(seed))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := dutil.NewBatchSampler(20, 4, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs.SetRand(rand.New(rand.NewSource(seed)))
+
+		return []dutil.Sampler{rs, bs}
+	}
+
+	s1 := newSamplers(42)
+	s2 := newSamplers(42)
+	for i := range s1 {
+		// Consecutive epochs are reproducible.
+		for epoch := 0; epoch < 2; epoch++ {
+			got1, got2 := s1[i].Sample(), s2[i].Sample()
+			if !reflect.DeepEqual(got1, got2) {
+				t.Errorf("Sampler %T epoch %v: want same samples with same seed. Got: %v and %v\n", s1[i], epoch, got1, got2)
+			}
+		}
+	}
+}
+
+func TestSampler_ManualSeed(t *testing.T) {
+	samples := func(seed int64) [][]int {
+		gotch.ManualSeed(seed)
+		rs, err := dutil.NewRandomSampler(20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := dutil.NewBatchSampler(20, 4, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return [][]int{rs.Sample(), rs.Sample(), bs.Sample()}
+	}
+
+	got1 := samples(42)
+	got2 := samples(42)
+	if !reflect.DeepEqual(got1, got2) {
+		t.Errorf("want same samples after seeding with same seed. Got: %v and %v\n", got1, got2)
+	}
+}
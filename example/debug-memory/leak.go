@@ -0,0 +1,49 @@
+package main
+
+// Demo of leak tracker reporting live tensors by call site.
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+// leakyStep forgets to drop an intermediate tensor.
+func leakyStep(x *ts.Tensor) *ts.Tensor {
+	h := x.MustMulScalar(ts.FloatScalar(2.0), false) // leaked
+	return h.MustAddScalar(ts.FloatScalar(1.0), false)
+}
+
+func runLeakTracker(profileFile string) {
+	ts.SetLeakTracker(true)
+	defer ts.SetLeakTracker(false)
+
+	x := ts.MustRandn([]int64{256, 256}, gotch.Float, gotch.CPU)
+	var outputs []*ts.Tensor
+	for i := 0; i < 10; i++ {
+		y := leakyStep(x)
+		outputs = append(outputs, y)
+	}
+
+	fmt.Printf("Memory stats: %+v\n\n", ts.MemStats())
+	fmt.Println("Live tensors by call site:")
+	fmt.Println(ts.LiveTensorReport())
+
+	f, err := os.Create(profileFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := ts.WriteTensorProfile(f); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Tensor profile saved to %q. Inspect with:\n\tgo tool pprof -top %s\n", profileFile, profileFile)
+
+	for _, y := range outputs {
+		y.MustDrop()
+	}
+	x.MustDrop()
+}
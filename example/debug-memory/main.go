@@ -9,7 +9,10 @@ import (
 	"github.com/sugarme/gotch/ts"
 )
 
-var device string
+var (
+	device      string
+	leakProfile string
+)
 
 func createTensors(samples int) []ts.Tensor {
 	n := int(10e6)
@@ -38,6 +41,7 @@ func dropTensors(tensors []ts.Tensor) {
 
 func init() {
 	flag.StringVar(&device, "device", "CPU", "Select CPU or GPU to use")
+	flag.StringVar(&leakProfile, "leak-profile", "", "Run leak tracker demo and save tensor profile to given file")
 
 }
 
@@ -46,6 +50,11 @@ func main() {
 	// infor accordingly
 	flag.Parse()
 
+	if leakProfile != "" {
+		runLeakTracker(leakProfile)
+		return
+	}
+
 	switch device {
 	case "CPU":
 		var si *SI
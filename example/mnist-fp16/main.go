@@ -38,6 +38,15 @@ var device gotch.Device = gotch.CudaIfAvailable()
 // var dtype gotch.DType = gotch.Half
 var dtype gotch.DType = gotch.Float
 
+// Automatic mixed precision: model variables are kept in `dtype` (float32),
+// forward passes run in `ampDType` inside `ts.Autocast()` and the loss is
+// scaled by `nn.GradScaler` to prevent float16 gradients from underflowing.
+// Use gotch.BFloat16 on CPU.
+var (
+	useAmp   bool        = true
+	ampDType gotch.DType = gotch.Half
+)
+
 type Net struct {
 	conv1 *nn.Conv2D
 	conv2 *nn.Conv2D
@@ -96,6 +105,11 @@ func runCNN() {
 		log.Fatal(err)
 	}
 
+	scalerConfig := nn.DefaultGradScalerConfig()
+	// BFloat16 has the same exponent range as float32, no scaling needed.
+	scalerConfig.Enabled = useAmp && ampDType == gotch.Half
+	scaler := nn.NewGradScaler(scalerConfig)
+
 	var bestAccuracy float64 = 0.0
 	startTime := time.Now()
 
@@ -120,14 +134,24 @@ func runCNN() {
 
 			// Indexing
 			bImages := imagesTs.MustNarrow(0, int64(start), int64(size), false)
-			logits := net.ForwardT(bImages, true)
-
 			bLabels := labelsTs.MustNarrow(0, int64(start), int64(size), false)
-			loss := logits.CrossEntropyForLogits(bLabels)
 
-			loss = loss.MustSetRequiresGrad(true, true)
-			opt.BackwardStep(loss)
-			epocLoss = loss.Float64Values()[0]
+			if useAmp {
+				var loss *ts.Tensor
+				ts.MustAutocast(ampDType, func() {
+					logits := net.ForwardT(bImages, true)
+					loss = logits.CrossEntropyForLogits(bLabels)
+				})
+				opt.MustBackwardStepScaled(loss, scaler)
+				epocLoss = loss.Float64Values()[0]
+			} else {
+				logits := net.ForwardT(bImages, true)
+				loss := logits.CrossEntropyForLogits(bLabels)
+
+				loss = loss.MustSetRequiresGrad(true, true)
+				opt.BackwardStep(loss)
+				epocLoss = loss.Float64Values()[0]
+			}
 
 			runtime.GC()
 		}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 var (
@@ -12,6 +13,16 @@ var (
 	gotchEnvKey   string = "GOTCH_CACHE"
 	gotchDebugKey string = "GOTCH_DEBUG"
 	Debug         bool   = false
+
+	gotchMemLimitKey  string = "GOTCH_MEM_LIMIT"
+	gotchMemPolicyKey string = "GOTCH_MEM_POLICY"
+	MemLimit          int64  = 0    // high-water mark of C memory occupied by tensors (bytes). 0 means no limit. See `ts.SetMemLimit()`.
+	MemPolicy         string = "gc" // action when MemLimit is crossed, either "gc" or "block". See `ts.SetMemLimit()`.
+
+	gotchNumThreadsKey        string = "GOTCH_NUM_THREADS"
+	gotchNumInteropThreadsKey string = "GOTCH_NUM_INTEROP_THREADS"
+	NumThreads                int    = 0 // number of threads of libtorch intra-op pool. 0 means libtorch default. See `SetNumThreads()`.
+	NumInteropThreads         int    = 0 // number of threads of libtorch inter-op pool. 0 means libtorch default. See `SetNumInteropThreads()`.
 )
 
 func init() {
@@ -26,6 +37,28 @@ func initEnv() {
 		Debug = v
 	}
 
+	if v, err := parseByteSize(os.Getenv(gotchMemLimitKey)); err == nil {
+		MemLimit = v
+	}
+
+	if v := strings.ToLower(os.Getenv(gotchMemPolicyKey)); v == "gc" || v == "block" {
+		MemPolicy = v
+	}
+
+	if v, err := strconv.Atoi(os.Getenv(gotchNumThreadsKey)); err == nil && v > 0 {
+		NumThreads = v
+		if err := SetNumThreads(v); err != nil {
+			log.Printf("WARNING: %v\n", err)
+		}
+	}
+
+	if v, err := strconv.Atoi(os.Getenv(gotchNumInteropThreadsKey)); err == nil && v > 0 {
+		NumInteropThreads = v
+		if err := SetNumInteropThreads(v); err != nil {
+			log.Printf("WARNING: %v\n", err)
+		}
+	}
+
 	val := os.Getenv(gotchEnvKey)
 	if val != "" {
 		CachedDir = val
@@ -37,3 +70,35 @@ func initEnv() {
 		}
 	}
 }
+
+// parseByteSize parses size in bytes with optional unit suffix "KB", "MB", "GB" or "TB"
+// (multiples of 1024), e.g. "8GB".
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	multiplier := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			multiplier = u.size
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(v * float64(multiplier)), nil
+}
@@ -6,6 +6,8 @@ package libtch
 //#include "stdlib.h"
 //void callback_fn(void *, char *, tensor);
 //typedef void (*f)(void *, char *, tensor);
+//void blob_deleter(void *);
+//typedef void (*blob_deleter_fn)(void *);
 import "C"
 
 import (
@@ -59,6 +61,50 @@ func NewTensor() Ctensor {
 	return C.at_new_tensor()
 }
 
+// int64Ptr returns C pointer to the first element of a slice or nil if it's empty.
+func int64Ptr(v []int64) *C.int64_t {
+	if len(v) == 0 {
+		return nil
+	}
+
+	return (*C.int64_t)(unsafe.Pointer(&v[0]))
+}
+
+// tensor at_tensor_of_blob(void *data, int64_t *dims, size_t ndims, int64_t *strides, size_t nstrides, int type, int device);
+func AtTensorOfBlob(data unsafe.Pointer, dims []int64, strides []int64, kind int32, device int32) Ctensor {
+	cndims := C.size_t(len(dims))
+	cnstrides := C.size_t(len(strides))
+	ckind := *(*C.int)(unsafe.Pointer(&kind))
+	cdevice := *(*C.int)(unsafe.Pointer(&device))
+
+	return C.at_tensor_of_blob(data, int64Ptr(dims), cndims, int64Ptr(strides), cnstrides, ckind, cdevice)
+}
+
+// AtTensorOfBlobWithDeleter creates a tensor over `data` memory. When libtorch
+// releases the memory, the function stored at `ctx` in `PStore` is called and
+// `ctx` is freed.
+//
+// tensor at_tensor_of_blob_with_deleter(void *data, int64_t *dims, size_t ndims, int64_t *strides, size_t nstrides, int type, int device, void *ctx, void (*deleter)(void *));
+func AtTensorOfBlobWithDeleter(data unsafe.Pointer, dims []int64, strides []int64, kind int32, device int32, ctx unsafe.Pointer) Ctensor {
+	cndims := C.size_t(len(dims))
+	cnstrides := C.size_t(len(strides))
+	ckind := *(*C.int)(unsafe.Pointer(&kind))
+	cdevice := *(*C.int)(unsafe.Pointer(&device))
+
+	return C.at_tensor_of_blob_with_deleter(data, int64Ptr(dims), cndims, int64Ptr(strides), cnstrides, ckind, cdevice, ctx, C.blob_deleter_fn(C.blob_deleter))
+}
+
+// NOTE. It can be called from any thread, at the time the last tensor
+// sharing the memory is freed.
+//
+//export blob_deleter
+func blob_deleter(ctx unsafe.Pointer) {
+	if fn, ok := PStore.Get(ctx).(func()); ok && fn != nil {
+		fn()
+	}
+	PStore.Free(ctx)
+}
+
 // tensor at_tensor_of_data(void *vs, int64_t *dims, size_t ndims, size_t element_size_in_bytes, int type);
 func AtTensorOfData(vs unsafe.Pointer, dims []int64, ndims uint, elt_size_in_bytes uint, kind int) Ctensor {
 
@@ -136,10 +182,29 @@ func AtIsContiguous(ts Ctensor) bool {
 	return *(*bool)(unsafe.Pointer(&retVal))
 }
 
+// int at_is_view(tensor);
+func AtIsView(ts Ctensor) bool {
+	retVal := C.at_is_view(ts)
+	return *(*bool)(unsafe.Pointer(&retVal))
+}
+
 func GetAndResetLastErr() *C.char {
 	return C.get_and_reset_last_err()
 }
 
+// LastErr returns and resets the last libtorch error message of the current
+// OS thread. It returns an empty string if there's no error.
+func LastErr() string {
+	cptr := C.get_and_reset_last_err()
+	if cptr == nil {
+		return ""
+	}
+	msg := C.GoString(cptr)
+	C.free(unsafe.Pointer(cptr))
+
+	return msg
+}
+
 // int atc_cuda_device_count();
 func AtcCudaDeviceCount() int32 {
 	result := C.atc_cuda_device_count()
@@ -395,6 +460,28 @@ func AtToString(ts Ctensor, lineSize int64) string {
 	return goString
 }
 
+// int at_get_num_interop_threads();
+func AtGetNumInteropThreads() int {
+	cretVal := C.at_get_num_interop_threads()
+	return int(cretVal)
+}
+
+// int at_get_num_threads();
+func AtGetNumThreads() int {
+	cretVal := C.at_get_num_threads()
+	return int(cretVal)
+}
+
+// void at_set_num_interop_threads(int n_threads);
+func AtSetNumInteropThreads(n int) {
+	C.at_set_num_interop_threads(C.int(n))
+}
+
+// void at_set_num_threads(int n_threads);
+func AtSetNumThreads(n int) {
+	C.at_set_num_threads(C.int(n))
+}
+
 // void at_free(tensor);
 func AtFree(ts Ctensor) {
 	C.at_free(ts)
@@ -407,6 +494,96 @@ func AtGradSetEnabled(b int) int {
 	return *(*int)(unsafe.Pointer(&cretVal))
 }
 
+// bool at_grad_is_enabled();
+func AtGradIsEnabled() bool {
+	cretVal := C.at_grad_is_enabled()
+	return bool(cretVal)
+}
+
+// void *at_inference_mode_enter();
+func AtInferenceModeEnter() unsafe.Pointer {
+	return C.at_inference_mode_enter()
+}
+
+// void at_inference_mode_exit(void *guard);
+func AtInferenceModeExit(guard unsafe.Pointer) {
+	C.at_inference_mode_exit(guard)
+}
+
+// bool at_inference_mode_is_enabled();
+func AtInferenceModeIsEnabled() bool {
+	cretVal := C.at_inference_mode_is_enabled()
+	return bool(cretVal)
+}
+
+// void at__amp_non_finite_check_and_unscale(tensor, tensor, tensor);
+func At_AmpNonFiniteCheckAndUnscale(t Ctensor, foundInf Ctensor, invScale Ctensor) {
+	C.at__amp_non_finite_check_and_unscale(t, foundInf, invScale)
+}
+
+// void at_autocast_clear_cache();
+func AtAutocastClearCache() {
+	C.at_autocast_clear_cache()
+}
+
+// int at_autocast_decrement_nesting();
+func AtAutocastDecrementNesting() int {
+	cretVal := C.at_autocast_decrement_nesting()
+	return int(cretVal)
+}
+
+// int at_autocast_increment_nesting();
+func AtAutocastIncrementNesting() int {
+	cretVal := C.at_autocast_increment_nesting()
+	return int(cretVal)
+}
+
+// bool at_autocast_is_enabled();
+func AtAutocastIsEnabled() bool {
+	cretVal := C.at_autocast_is_enabled()
+	return bool(cretVal)
+}
+
+// bool at_autocast_set_enabled(bool b);
+func AtAutocastSetEnabled(b bool) bool {
+	cretVal := C.at_autocast_set_enabled(C.bool(b))
+	return bool(cretVal)
+}
+
+// bool at_autocast_cpu_is_enabled();
+func AtAutocastCpuIsEnabled() bool {
+	cretVal := C.at_autocast_cpu_is_enabled()
+	return bool(cretVal)
+}
+
+// bool at_autocast_cpu_set_enabled(bool b);
+func AtAutocastCpuSetEnabled(b bool) bool {
+	cretVal := C.at_autocast_cpu_set_enabled(C.bool(b))
+	return bool(cretVal)
+}
+
+// int at_autocast_get_cpu_dtype();
+func AtAutocastGetCpuDtype() int32 {
+	cretVal := C.at_autocast_get_cpu_dtype()
+	return int32(cretVal)
+}
+
+// void at_autocast_set_cpu_dtype(int dtype);
+func AtAutocastSetCpuDtype(dtype int32) {
+	C.at_autocast_set_cpu_dtype(C.int(dtype))
+}
+
+// int at_autocast_get_gpu_dtype();
+func AtAutocastGetGpuDtype() int32 {
+	cretVal := C.at_autocast_get_gpu_dtype()
+	return int32(cretVal)
+}
+
+// void at_autocast_set_gpu_dtype(int dtype);
+func AtAutocastSetGpuDtype(dtype int32) {
+	C.at_autocast_set_gpu_dtype(C.int(dtype))
+}
+
 /*
  * optimizer ato_adam(double learning_rate,
  *                    double beta1,
@@ -901,6 +1078,44 @@ func AtmTrain(m Cmodule) {
 	C.atm_train(m)
 }
 
+// int64_t ato_get_state(optimizer, tensor param, tensor *tensors);
+func AtoGetState(coptimizer Coptimizer, param Ctensor, tensors *Ctensor) int64 {
+	cstep := C.ato_get_state(coptimizer, param, tensors)
+	return *(*int64)(unsafe.Pointer(&cstep))
+}
+
+// void ato_set_state(optimizer, tensor param, tensor *tensors, int64_t step);
+func AtoSetState(coptimizer Coptimizer, param Ctensor, tensors *Ctensor, step int64) {
+	cstep := *(*C.int64_t)(unsafe.Pointer(&step))
+	C.ato_set_state(coptimizer, param, tensors, cstep)
+}
+
+// void at_manual_seed(int64_t);
+func AtManualSeed(seed int64) {
+	C.at_manual_seed(C.int64_t(seed))
+}
+
+// void at_set_deterministic(bool b, bool warn_only);
+func AtSetDeterministic(b bool, warnOnly bool) {
+	C.at_set_deterministic(C.bool(b), C.bool(warnOnly))
+}
+
+// bool at_deterministic();
+func AtDeterministic() bool {
+	cretVal := C.at_deterministic()
+	return bool(cretVal)
+}
+
+// tensor at_get_rng_state();
+func AtGetRngState() Ctensor {
+	return C.at_get_rng_state()
+}
+
+// void at_set_rng_state(tensor);
+func AtSetRngState(tensor Ctensor) {
+	C.at_set_rng_state(tensor)
+}
+
 func AtoConstantPadNd(ptr *Ctensor, self Ctensor, padData []int64, padLen int, value Cscalar) {
 	cpadDataPtr := (*C.int64_t)(unsafe.Pointer(&padData[0]))
 	cpadLen := *(*C.int)(unsafe.Pointer(&padLen))
@@ -1,5 +1,6 @@
 #include "torch_api.h"
 #include "ATen/core/interned_strings.h"
+#include <ATen/CPUGeneratorImpl.h>
 #include <ATen/autocast_mode.h>
 #include <stdexcept>
 #include <torch/csrc/autograd/engine.h>
@@ -29,6 +30,29 @@ char *get_and_reset_last_err() {
 
 void at_manual_seed(int64_t seed) { torch::manual_seed(seed); }
 
+void at_set_deterministic(bool b, bool warn_only) {
+  PROTECT(at::globalContext().setDeterministicAlgorithms(b, warn_only);
+          at::globalContext().setDeterministicCuDNN(b);
+          if (b) at::globalContext().setBenchmarkCuDNN(false);)
+}
+
+bool at_deterministic() {
+  PROTECT(return at::globalContext().deterministicAlgorithms();)
+  return false;
+}
+
+tensor at_get_rng_state() {
+  PROTECT(auto gen = at::detail::getDefaultCPUGenerator();
+          std::lock_guard<std::mutex> lock(gen.mutex());
+          return new torch::Tensor(gen.get_state());)
+  return nullptr;
+}
+
+void at_set_rng_state(tensor t) {
+  PROTECT(auto gen = at::detail::getDefaultCPUGenerator();
+          std::lock_guard<std::mutex> lock(gen.mutex()); gen.set_state(*t);)
+}
+
 vector<torch::Tensor> of_carray_tensor(torch::Tensor **vs, int len) {
   vector<torch::Tensor> result;
   for (int i = 0; i < len; ++i)
@@ -72,6 +96,21 @@ tensor at_tensor_of_blob(void *data, int64_t *dims, size_t ndims,
   return nullptr;
 }
 
+tensor at_tensor_of_blob_with_deleter(void *data, int64_t *dims, size_t ndims,
+                                      int64_t *strides, size_t nstrides,
+                                      int type, int device, void *ctx,
+                                      void (*deleter)(void *)) {
+  PROTECT(at::TensorOptions blobOptions = at::TensorOptions()
+                                              .device(device_of_int(device))
+                                              .dtype(torch::ScalarType(type));
+          return new torch::Tensor(torch::from_blob(
+              data, torch::IntArrayRef(dims, ndims),
+              torch::IntArrayRef(strides, nstrides),
+              [ctx, deleter](void *) { deleter(ctx); }, blobOptions));)
+
+  return nullptr;
+}
+
 tensor at_tensor_of_data(void *vs, int64_t *dims, size_t ndims,
                          size_t element_size_in_bytes, int type) {
   PROTECT(torch::Tensor tensor = torch::zeros(torch::IntArrayRef(dims, ndims),
@@ -150,6 +189,11 @@ int at_is_contiguous(tensor t) {
   return -1;
 }
 
+int at_is_view(tensor t) {
+  PROTECT(return t->is_view();)
+  return -1;
+}
+
 // void at__amp_non_finite_check_and_unscale(tensor t, tensor found_inf, tensor
 // inf_scale) { PROTECT( at::_amp_non_finite_check_and_unscale_(*t, *found_inf,
 // *inf_scale);
@@ -184,6 +228,37 @@ bool at_autocast_set_enabled(bool b) {
   return -1;
 }
 
+bool at_autocast_cpu_is_enabled() {
+  PROTECT(return at::autocast::is_cpu_enabled();)
+  return false;
+}
+
+bool at_autocast_cpu_set_enabled(bool b) {
+  PROTECT(bool is_enabled = at::autocast::is_cpu_enabled();
+          at::autocast::set_cpu_enabled(b); return is_enabled;)
+  return false;
+}
+
+int at_autocast_get_cpu_dtype() {
+  PROTECT(return static_cast<int>(at::autocast::get_autocast_cpu_dtype());)
+  return -1;
+}
+
+void at_autocast_set_cpu_dtype(int dtype) {
+  PROTECT(at::autocast::set_autocast_cpu_dtype(
+              static_cast<at::ScalarType>(dtype));)
+}
+
+int at_autocast_get_gpu_dtype() {
+  PROTECT(return static_cast<int>(at::autocast::get_autocast_gpu_dtype());)
+  return -1;
+}
+
+void at_autocast_set_gpu_dtype(int dtype) {
+  PROTECT(at::autocast::set_autocast_gpu_dtype(
+              static_cast<at::ScalarType>(dtype));)
+}
+
 int at_device(tensor t) {
   PROTECT(auto device = t->device(); if (device.type() == at::kCPU) return -1;
           if (device.type() == at::kCUDA) return device.index();)
@@ -205,6 +280,25 @@ int at_grad_set_enabled(int b) {
   return -1;
 }
 
+bool at_grad_is_enabled() {
+  PROTECT(return torch::autograd::GradMode::is_enabled();)
+  return false;
+}
+
+void *at_inference_mode_enter() {
+  PROTECT(return new c10::InferenceMode(true);)
+  return nullptr;
+}
+
+void at_inference_mode_exit(void *guard) {
+  PROTECT(delete static_cast<c10::InferenceMode *>(guard);)
+}
+
+bool at_inference_mode_is_enabled() {
+  PROTECT(return c10::InferenceMode::is_enabled();)
+  return false;
+}
+
 tensor at_get(tensor t, int index) {
   PROTECT(return new torch::Tensor((*t)[index]);)
   return nullptr;
@@ -483,6 +577,93 @@ void ato_add_parameter(optimizer t, tensor tensor, size_t group) {
                                                 .push_back(*tensor);)
 }
 
+static tensor state_output(const torch::Tensor &t) {
+  if (!t.defined())
+    return nullptr;
+  return new torch::Tensor(t.detach().clone());
+}
+
+static torch::Tensor state_input(tensor t) {
+  if (t == nullptr)
+    return torch::Tensor();
+  return t->detach().clone();
+}
+
+static int64_t get_state(optimizer t, tensor param, tensor *tensors) {
+  tensors[0] = nullptr;
+  tensors[1] = nullptr;
+  tensors[2] = nullptr;
+  auto &state = t->state();
+  auto it = state.find(param->unsafeGetTensorImpl());
+  if (it == state.end())
+    return -1;
+
+  torch::optim::OptimizerParamState *s = it->second.get();
+  if (auto p = dynamic_cast<torch::optim::AdamParamState *>(s)) {
+    tensors[0] = state_output(p->exp_avg());
+    tensors[1] = state_output(p->exp_avg_sq());
+    tensors[2] = state_output(p->max_exp_avg_sq());
+    return p->step();
+  } else if (auto p = dynamic_cast<torch::optim::AdamWParamState *>(s)) {
+    tensors[0] = state_output(p->exp_avg());
+    tensors[1] = state_output(p->exp_avg_sq());
+    tensors[2] = state_output(p->max_exp_avg_sq());
+    return p->step();
+  } else if (auto p = dynamic_cast<torch::optim::RMSpropParamState *>(s)) {
+    tensors[0] = state_output(p->square_avg());
+    tensors[1] = state_output(p->momentum_buffer());
+    tensors[2] = state_output(p->grad_avg());
+    return p->step();
+  } else if (auto p = dynamic_cast<torch::optim::SGDParamState *>(s)) {
+    tensors[0] = state_output(p->momentum_buffer());
+    return 0;
+  }
+  throw std::invalid_argument("unexpected optimizer state");
+}
+
+static void set_state(optimizer t, tensor param, tensor *tensors,
+                      int64_t step) {
+  torch::NoGradGuard no_grad;
+  void *key = param->unsafeGetTensorImpl();
+  torch::optim::OptimizerOptions *d = &(t->defaults());
+  if (dynamic_cast<torch::optim::AdamOptions *>(d)) {
+    auto s = std::make_unique<torch::optim::AdamParamState>();
+    s->step(step);
+    s->exp_avg(state_input(tensors[0]));
+    s->exp_avg_sq(state_input(tensors[1]));
+    s->max_exp_avg_sq(state_input(tensors[2]));
+    t->state()[key] = std::move(s);
+  } else if (dynamic_cast<torch::optim::AdamWOptions *>(d)) {
+    auto s = std::make_unique<torch::optim::AdamWParamState>();
+    s->step(step);
+    s->exp_avg(state_input(tensors[0]));
+    s->exp_avg_sq(state_input(tensors[1]));
+    s->max_exp_avg_sq(state_input(tensors[2]));
+    t->state()[key] = std::move(s);
+  } else if (dynamic_cast<torch::optim::RMSpropOptions *>(d)) {
+    auto s = std::make_unique<torch::optim::RMSpropParamState>();
+    s->step(step);
+    s->square_avg(state_input(tensors[0]));
+    s->momentum_buffer(state_input(tensors[1]));
+    s->grad_avg(state_input(tensors[2]));
+    t->state()[key] = std::move(s);
+  } else if (dynamic_cast<torch::optim::SGDOptions *>(d)) {
+    auto s = std::make_unique<torch::optim::SGDParamState>();
+    s->momentum_buffer(state_input(tensors[0]));
+    t->state()[key] = std::move(s);
+  } else
+    throw std::invalid_argument("unexpected optimizer");
+}
+
+int64_t ato_get_state(optimizer t, tensor param, tensor *tensors) {
+  PROTECT(return get_state(t, param, tensors);)
+  return -1;
+}
+
+void ato_set_state(optimizer t, tensor param, tensor *tensors, int64_t step) {
+  PROTECT(set_state(t, param, tensors, step);)
+}
+
 template <class T> void set_lr(optimizer t, double learning_rate) {
   torch::optim::OptimizerOptions *d = &(t->defaults());
   if (auto p = dynamic_cast<T *>(d)) {
@@ -31,10 +31,21 @@ typedef void *ivalue;
 
 char *get_and_reset_last_err(); // thread-local
 void at_manual_seed(int64_t);
+void at_set_deterministic(bool b, bool warn_only);
+bool at_deterministic();
+/* Get/set state of the default CPU random number generator. */
+tensor at_get_rng_state();
+void at_set_rng_state(tensor);
 tensor at_new_tensor();
 tensor at_tensor_of_blob(void *data, int64_t *dims, size_t ndims,
                          int64_t *strides, size_t nstrides, int type,
                          int device);
+// Same as at_tensor_of_blob but deleter(ctx) is called when the tensor
+// storage is released.
+tensor at_tensor_of_blob_with_deleter(void *data, int64_t *dims, size_t ndims,
+                                      int64_t *strides, size_t nstrides,
+                                      int type, int device, void *ctx,
+                                      void (*deleter)(void *));
 tensor at_tensor_of_data(void *vs, int64_t *dims, size_t ndims,
                          size_t element_size_in_bytes, int type);
 void at_copy_data(tensor tensor, void *vs, size_t numel,
@@ -51,6 +62,7 @@ void at_shape(tensor, int64_t *);
 void at_stride(tensor, int64_t *);
 int at_scalar_type(tensor);
 int at_is_contiguous(tensor);
+int at_is_view(tensor);
 
 void at__amp_non_finite_check_and_unscale(tensor, tensor, tensor);
 
@@ -59,10 +71,20 @@ int at_autocast_decrement_nesting();
 int at_autocast_increment_nesting();
 bool at_autocast_is_enabled();
 bool at_autocast_set_enabled(bool b);
+bool at_autocast_cpu_is_enabled();
+bool at_autocast_cpu_set_enabled(bool b);
+int at_autocast_get_cpu_dtype();
+void at_autocast_set_cpu_dtype(int dtype);
+int at_autocast_get_gpu_dtype();
+void at_autocast_set_gpu_dtype(int dtype);
 
 void at_backward(tensor, int, int);
 int at_requires_grad(tensor);
 int at_grad_set_enabled(int);
+bool at_grad_is_enabled();
+void *at_inference_mode_enter();
+void at_inference_mode_exit(void *guard);
+bool at_inference_mode_is_enabled();
 
 tensor at_get(tensor, int index);
 void at_fill_double(tensor, double);
@@ -135,6 +157,15 @@ void ato_zero_grad(optimizer);
 void ato_step(optimizer);
 void ato_free(optimizer);
 
+/* Optimizer state of a parameter. [tensors] has 3 slots for state tensors:
+ * - Adam/AdamW: exp_avg, exp_avg_sq, max_exp_avg_sq
+ * - RMSprop: square_avg, momentum_buffer, grad_avg
+ * - SGD: momentum_buffer
+ * Undefined state tensors are nullptr. [ato_get_state] returns step or -1 if
+ * parameter has no state. */
+int64_t ato_get_state(optimizer, tensor param, tensor *tensors);
+void ato_set_state(optimizer, tensor param, tensor *tensors, int64_t step);
+
 // TT. APIs for learning rate scheduler
 void ato_set_learning_rates(optimizer, double *learning_rates, int lrs_num);
 int64_t ato_param_group_num(optimizer);
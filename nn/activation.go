@@ -0,0 +1,429 @@
+package nn
+
+// Activation layers.
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// ReLU:
+// =====
+
+// ReLU applies max(0, x) element-wise.
+type ReLU struct{}
+
+func NewReLU() *ReLU {
+	return new(ReLU)
+}
+
+func (m *ReLU) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustRelu(false)
+}
+
+// ReLU6 applies min(max(0, x), 6) element-wise.
+type ReLU6 struct{}
+
+func NewReLU6() *ReLU6 {
+	return new(ReLU6)
+}
+
+func (m *ReLU6) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustRelu6(false)
+}
+
+// LeakyReLU:
+// ==========
+
+// LeakyReLU applies max(0, x) + negativeSlope * min(0, x) element-wise.
+type LeakyReLU struct {
+	NegativeSlope float64 // PyTorch default=0.01
+}
+
+func NewLeakyReLU(negativeSlope float64) *LeakyReLU {
+	return &LeakyReLU{negativeSlope}
+}
+
+func (m *LeakyReLU) Forward(x *ts.Tensor) *ts.Tensor {
+	if m.NegativeSlope == 0.01 {
+		return x.MustLeakyRelu(false)
+	}
+
+	neg := x.MustClampMax(ts.FloatScalar(0), false).MustMulScalar(ts.FloatScalar(m.NegativeSlope), true)
+	out := x.MustRelu(false).MustAdd(neg, true)
+	neg.MustDrop()
+
+	return out
+}
+
+// ELU, SELU, CELU:
+// ================
+
+// ELU applies max(0, x) + min(0, alpha * (exp(x) - 1)) element-wise.
+type ELU struct {
+	Alpha float64 // PyTorch default=1.0
+}
+
+func NewELU(alpha float64) *ELU {
+	return &ELU{alpha}
+}
+
+func (m *ELU) Forward(x *ts.Tensor) *ts.Tensor {
+	if m.Alpha == 1.0 {
+		return x.MustElu(false)
+	}
+
+	return expLinear(x, m.Alpha, 1.0)
+}
+
+// SELU applies scale * ELU(x) with fixed alpha and scale from
+// `Self-Normalizing Neural Networks` (https://arxiv.org/abs/1706.02515).
+type SELU struct{}
+
+func NewSELU() *SELU {
+	return new(SELU)
+}
+
+func (m *SELU) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustSelu(false)
+}
+
+// CELU applies max(0, x) + min(0, alpha * (exp(x/alpha) - 1)) element-wise.
+type CELU struct {
+	Alpha float64 // PyTorch default=1.0
+}
+
+func NewCELU(alpha float64) *CELU {
+	return &CELU{alpha}
+}
+
+func (m *CELU) Forward(x *ts.Tensor) *ts.Tensor {
+	if m.Alpha == 1.0 {
+		return x.MustCelu(false)
+	}
+
+	return expLinear(x, m.Alpha, 1.0/m.Alpha)
+}
+
+// expLinear computes max(0, x) + alpha * (exp(min(0, x) * inputScale) - 1).
+func expLinear(x *ts.Tensor, alpha, inputScale float64) *ts.Tensor {
+	neg := x.MustClampMax(ts.FloatScalar(0), false).
+		MustMulScalar(ts.FloatScalar(inputScale), true).
+		MustExpm1(true).
+		MustMulScalar(ts.FloatScalar(alpha), true)
+
+	out := x.MustRelu(false).MustAdd(neg, true)
+	neg.MustDrop()
+
+	return out
+}
+
+// GELU:
+// =====
+
+// GELU applies the Gaussian Error Linear Unit function x * Φ(x) where Φ(x)
+// is the cumulative distribution function of the standard normal distribution.
+type GELU struct {
+	Approximate string // either "none" (exact) or "tanh". Default="none"
+}
+
+// NewGELU creates a GELU layer. Optional `approximate` is either "none" (default) or "tanh".
+func NewGELU(approximateOpt ...string) *GELU {
+	approximate := "none"
+	if len(approximateOpt) > 0 {
+		approximate = approximateOpt[0]
+	}
+	if approximate != "none" && approximate != "tanh" {
+		log.Fatalf("NewGELU() failed: unsupported approximate %q. Expected 'none' or 'tanh'\n", approximate)
+	}
+
+	return &GELU{approximate}
+}
+
+func (m *GELU) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustGelu(m.Approximate, false)
+}
+
+// SiLU, Mish:
+// ===========
+
+// SiLU applies x * sigmoid(x) element-wise. It's also known as Swish.
+type SiLU struct{}
+
+func NewSiLU() *SiLU {
+	return new(SiLU)
+}
+
+func (m *SiLU) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustSilu(false)
+}
+
+// Mish applies x * tanh(softplus(x)) element-wise.
+type Mish struct{}
+
+func NewMish() *Mish {
+	return new(Mish)
+}
+
+func (m *Mish) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustMish(false)
+}
+
+// Hardswish, Hardsigmoid:
+// =======================
+
+// Hardswish applies x * relu6(x + 3) / 6 element-wise.
+type Hardswish struct{}
+
+func NewHardswish() *Hardswish {
+	return new(Hardswish)
+}
+
+func (m *Hardswish) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustHardswish(false)
+}
+
+// Hardsigmoid applies relu6(x + 3) / 6 element-wise.
+type Hardsigmoid struct{}
+
+func NewHardsigmoid() *Hardsigmoid {
+	return new(Hardsigmoid)
+}
+
+func (m *Hardsigmoid) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustHardsigmoid(false)
+}
+
+// Sigmoid, Tanh:
+// ==============
+
+// Sigmoid applies 1 / (1 + exp(-x)) element-wise.
+type Sigmoid struct{}
+
+func NewSigmoid() *Sigmoid {
+	return new(Sigmoid)
+}
+
+func (m *Sigmoid) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustSigmoid(false)
+}
+
+// Tanh applies the hyperbolic tangent function element-wise.
+type Tanh struct{}
+
+func NewTanh() *Tanh {
+	return new(Tanh)
+}
+
+func (m *Tanh) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustTanh(false)
+}
+
+// Softplus, Softsign, Tanhshrink:
+// ===============================
+
+// Softplus applies log(1 + exp(beta * x)) / beta element-wise. For numerical
+// stability, it reverts to the linear function when beta * x > threshold.
+type Softplus struct {
+	Beta      float64 // PyTorch default=1.0
+	Threshold float64 // PyTorch default=20.0
+}
+
+func NewSoftplus(beta, threshold float64) *Softplus {
+	return &Softplus{beta, threshold}
+}
+
+func (m *Softplus) Forward(x *ts.Tensor) *ts.Tensor {
+	if m.Beta == 1.0 && m.Threshold == 20.0 {
+		return x.MustSoftplus(false)
+	}
+
+	bx := x.MustMulScalar(ts.FloatScalar(m.Beta), false)
+	linear := bx.MustGt(ts.FloatScalar(m.Threshold), false)
+	soft := bx.MustExp(true).MustLog1p(true).MustDivScalar(ts.FloatScalar(m.Beta), true)
+	out := x.MustWhereSelf(linear, soft, false)
+	linear.MustDrop()
+	soft.MustDrop()
+
+	return out
+}
+
+// Softsign applies x / (1 + |x|) element-wise.
+type Softsign struct{}
+
+func NewSoftsign() *Softsign {
+	return new(Softsign)
+}
+
+func (m *Softsign) Forward(x *ts.Tensor) *ts.Tensor {
+	denom := x.MustAbs(false).MustAddScalar(ts.FloatScalar(1), true)
+	out := x.MustDiv(denom, false)
+	denom.MustDrop()
+
+	return out
+}
+
+// Tanhshrink applies x - tanh(x) element-wise.
+type Tanhshrink struct{}
+
+func NewTanhshrink() *Tanhshrink {
+	return new(Tanhshrink)
+}
+
+func (m *Tanhshrink) Forward(x *ts.Tensor) *ts.Tensor {
+	tanh := x.MustTanh(false)
+	out := x.MustSub(tanh, false)
+	tanh.MustDrop()
+
+	return out
+}
+
+// GLU, Softmax, LogSoftmax:
+// =========================
+
+// GLU applies the gated linear unit a * sigmoid(b) where input is split
+// in half along dimension `Dim` into a and b.
+type GLU struct {
+	Dim int64 // PyTorch default=-1
+}
+
+func NewGLU(dim int64) *GLU {
+	return &GLU{dim}
+}
+
+func (m *GLU) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustGlu(m.Dim, false)
+}
+
+// Softmax applies exp(x_i) / sum_j(exp(x_j)) along dimension `Dim`.
+type Softmax struct {
+	Dim int64
+}
+
+func NewSoftmax(dim int64) *Softmax {
+	return &Softmax{dim}
+}
+
+func (m *Softmax) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustSoftmax(m.Dim, x.DType(), false)
+}
+
+// LogSoftmax applies log(softmax(x)) along dimension `Dim`.
+type LogSoftmax struct {
+	Dim int64
+}
+
+func NewLogSoftmax(dim int64) *LogSoftmax {
+	return &LogSoftmax{dim}
+}
+
+func (m *LogSoftmax) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustLogSoftmax(m.Dim, x.DType(), false)
+}
+
+// PReLU:
+// ======
+
+// PReLU config.
+type PReLUConfig struct {
+	WsInit Init
+}
+
+func DefaultPReLUConfig() *PReLUConfig {
+	return &PReLUConfig{
+		WsInit: NewConstInit(0.25),
+	}
+}
+
+// PReLU applies max(0, x) + weight * min(0, x) element-wise with a learnable weight.
+type PReLU struct {
+	Ws *ts.Tensor
+}
+
+// NewPReLU creates a PReLU layer with `numParameters` weights which is either
+// 1 (a weight shared across all channels) or the number of input channels
+// (dimension 1 of input).
+func NewPReLU(vs *Path, numParameters int64, config *PReLUConfig) *PReLU {
+	return &PReLU{
+		Ws: vs.MustNewVar("weight", []int64{numParameters}, config.WsInit),
+	}
+}
+
+func (m *PReLU) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustPrelu(m.Ws, false)
+}
+
+// NewActivation:
+// ==============
+
+// NewActivation returns an activation module from its (case-insensitive) name,
+// with PyTorch default parameters. It's useful to build models from config files.
+//
+// Supported names: "identity", "relu", "relu6", "leaky_relu", "elu", "selu", "celu",
+// "gelu", "gelu_tanh", "silu" (or "swish"), "mish", "hardswish", "hardsigmoid",
+// "sigmoid", "tanh", "softplus", "softsign", "tanhshrink", "glu", "softmax" and
+// "log_softmax". "glu", "softmax" and "log_softmax" apply along the last dimension.
+//
+// PReLU is not supported as it has a learnable parameter. Use `NewPReLU()` instead.
+func NewActivation(name string) (ts.Module, error) {
+	switch strings.ToLower(name) {
+	case "identity":
+		return NewIdentity(), nil
+	case "relu":
+		return NewReLU(), nil
+	case "relu6":
+		return NewReLU6(), nil
+	case "leaky_relu":
+		return NewLeakyReLU(0.01), nil
+	case "elu":
+		return NewELU(1.0), nil
+	case "selu":
+		return NewSELU(), nil
+	case "celu":
+		return NewCELU(1.0), nil
+	case "gelu":
+		return NewGELU("none"), nil
+	case "gelu_tanh":
+		return NewGELU("tanh"), nil
+	case "silu", "swish":
+		return NewSiLU(), nil
+	case "mish":
+		return NewMish(), nil
+	case "hardswish":
+		return NewHardswish(), nil
+	case "hardsigmoid":
+		return NewHardsigmoid(), nil
+	case "sigmoid":
+		return NewSigmoid(), nil
+	case "tanh":
+		return NewTanh(), nil
+	case "softplus":
+		return NewSoftplus(1.0, 20.0), nil
+	case "softsign":
+		return NewSoftsign(), nil
+	case "tanhshrink":
+		return NewTanhshrink(), nil
+	case "glu":
+		return NewGLU(-1), nil
+	case "softmax":
+		return NewSoftmax(-1), nil
+	case "log_softmax":
+		return NewLogSoftmax(-1), nil
+	default:
+		err := fmt.Errorf("NewActivation() failed: unsupported activation %q", name)
+		return nil, err
+	}
+}
+
+// MustNewActivation returns an activation module from its name. It panics if error.
+func MustNewActivation(name string) ts.Module {
+	m, err := NewActivation(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return m
+}
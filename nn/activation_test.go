@@ -0,0 +1,66 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+)
+
+func TestActivations(t *testing.T) {
+	x := tensorOf([]float64{-1, 0, 2})
+	one := tensorOf([]float64{1})
+
+	assertAllValues(t, []valuesCase{
+		{"ReLU", nn.NewReLU().Forward(x), []float64{0, 0, 2}},
+		{"ReLU6", nn.NewReLU6().Forward(tensorOf([]float64{-1, 3, 8})), []float64{0, 3, 6}},
+		{"LeakyReLU", nn.NewLeakyReLU(0.01).Forward(x), []float64{-0.01, 0, 2}},
+		{"LeakyReLU slope", nn.NewLeakyReLU(0.1).Forward(x), []float64{-0.1, 0, 2}},
+		{"ELU", nn.NewELU(1).Forward(x), []float64{math.Exp(-1) - 1, 0, 2}},
+		{"ELU alpha", nn.NewELU(2).Forward(x), []float64{-1.2642411176571153, 0, 2}},
+		{"SELU", nn.NewSELU().Forward(x), []float64{-1.1113307378125625, 0, 2 * 1.0507009873554805}},
+		{"CELU alpha", nn.NewCELU(2).Forward(x), []float64{-0.7869386805747332, 0, 2}},
+		{"GELU", nn.NewGELU().Forward(one), []float64{0.8413447460685429}},
+		{"GELU tanh", nn.NewGELU("tanh").Forward(one), []float64{0.8411919906082768}},
+		{"SiLU", nn.NewSiLU().Forward(one), []float64{0.7310585786300049}},
+		{"Mish", nn.NewMish().Forward(one), []float64{0.8650983882673103}},
+		{"Hardswish", nn.NewHardswish().Forward(one), []float64{4.0 / 6}},
+		{"Hardsigmoid", nn.NewHardsigmoid().Forward(one), []float64{4.0 / 6}},
+		{"Sigmoid", nn.NewSigmoid().Forward(one), []float64{0.7310585786300049}},
+		{"Tanh", nn.NewTanh().Forward(one), []float64{math.Tanh(1)}},
+		{"Softplus", nn.NewSoftplus(1, 20).Forward(tensorOf([]float64{0})), []float64{math.Ln2}},
+		{"Softplus beta threshold", nn.NewSoftplus(2, 1).Forward(tensorOf([]float64{0, 1})), []float64{0.34657359027997264, 1}},
+		{"Softsign", nn.NewSoftsign().Forward(tensorOf([]float64{-1, 3})), []float64{-0.5, 0.75}},
+		{"Tanhshrink", nn.NewTanhshrink().Forward(one), []float64{0.23840584404423515}},
+		{"GLU", nn.NewGLU(-1).Forward(tensorOf([]float64{1, 2, 0, 0})), []float64{0.5, 1}},
+		{"Softmax", nn.NewSoftmax(-1).Forward(tensorOf([]float64{0, math.Log(3)})), []float64{0.25, 0.75}},
+		{"LogSoftmax", nn.NewLogSoftmax(-1).Forward(tensorOf([]float64{0, math.Log(3)})), []float64{math.Log(0.25), math.Log(0.75)}},
+	})
+}
+
+func TestPReLU(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	m := nn.NewPReLU(vs.Root(), 2, nn.DefaultPReLUConfig())
+
+	ws, ok := vs.Variables()["weight"]
+	if !ok {
+		t.Fatalf("Expected 'weight' variable")
+	}
+	assertShape(t, "PReLU weight", &ws, []int64{2})
+
+	x := float32TensorOf([]float32{-1, 2, -4, 4}, 1, 2, 2)
+	assertValues(t, "PReLU", m.Forward(x), []float64{-0.25, 2, -1, 4})
+}
+
+func TestNewActivation(t *testing.T) {
+	m, err := nn.NewActivation("GELU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertValues(t, "NewActivation gelu", m.Forward(tensorOf([]float64{1})), []float64{0.8413447460685429})
+
+	if _, err := nn.NewActivation("unknown"); err == nil {
+		t.Errorf("Expected error for unsupported activation")
+	}
+}
@@ -0,0 +1,364 @@
+package nn
+
+// Multi-head attention.
+
+import (
+	"log"
+	"math"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+// MultiheadAttentionConfig is configuration for MultiheadAttention.
+type MultiheadAttentionConfig struct {
+	Dropout    float64 // dropout probability on attention weights. Default=0.0
+	Bias       bool    // add bias to input and output projections. Default=true
+	KDim       int64   // number of features of key. Default=0 (same as embedDim)
+	VDim       int64   // number of features of value. Default=0 (same as embedDim)
+	BatchFirst bool    // input/output shape is (batch, seq, feature) if true, (seq, batch, feature) otherwise. Default=false
+}
+
+// DefaultMultiheadAttentionConfig creates default MultiheadAttentionConfig.
+func DefaultMultiheadAttentionConfig() *MultiheadAttentionConfig {
+	return &MultiheadAttentionConfig{
+		Dropout:    0.0,
+		Bias:       true,
+		KDim:       0,
+		VDim:       0,
+		BatchFirst: false,
+	}
+}
+
+// MultiheadAttention allows the model to jointly attend to information from
+// different representation subspaces.
+//
+// Variables are named the same as PyTorch `nn.MultiheadAttention` so that
+// PyTorch weights can be loaded directly:
+// - "in_proj_weight" (or "q_proj_weight", "k_proj_weight", "v_proj_weight" if key/value dimensions differ from embedDim)
+// - "in_proj_bias"
+// - "out_proj.weight", "out_proj.bias"
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.MultiheadAttention.html
+type MultiheadAttention struct {
+	EmbedDim   int64
+	KDim       int64
+	VDim       int64
+	NumHeads   int64
+	HeadDim    int64
+	Dropout    float64
+	BatchFirst bool
+
+	InProjWeight *ts.Tensor // packed query, key, value projection weights. Nil if KDim or VDim differ from EmbedDim.
+	QProjWeight  *ts.Tensor // optional
+	KProjWeight  *ts.Tensor // optional
+	VProjWeight  *ts.Tensor // optional
+	InProjBias   *ts.Tensor // optional
+	OutProj      *Linear
+}
+
+// NewMultiheadAttention creates a new MultiheadAttention.
+//
+// embedDim - total dimension of the model. It will be split across numHeads.
+// numHeads - number of parallel attention heads.
+func NewMultiheadAttention(vs *Path, embedDim, numHeads int64, c *MultiheadAttentionConfig) *MultiheadAttention {
+	if numHeads <= 0 || embedDim%numHeads != 0 {
+		log.Fatalf("NewMultiheadAttention() failed: embedDim (%v) must be divisible by numHeads (%v)\n", embedDim, numHeads)
+	}
+
+	kdim, vdim := c.KDim, c.VDim
+	if kdim <= 0 {
+		kdim = embedDim
+	}
+	if vdim <= 0 {
+		vdim = embedDim
+	}
+
+	m := &MultiheadAttention{
+		EmbedDim:   embedDim,
+		KDim:       kdim,
+		VDim:       vdim,
+		NumHeads:   numHeads,
+		HeadDim:    embedDim / numHeads,
+		Dropout:    c.Dropout,
+		BatchFirst: c.BatchFirst,
+	}
+
+	if kdim == embedDim && vdim == embedDim {
+		m.InProjWeight = vs.MustNewVar("in_proj_weight", []int64{3 * embedDim, embedDim}, xavierUniformInit(embedDim, 3*embedDim))
+	} else {
+		m.QProjWeight = vs.MustNewVar("q_proj_weight", []int64{embedDim, embedDim}, xavierUniformInit(embedDim, embedDim))
+		m.KProjWeight = vs.MustNewVar("k_proj_weight", []int64{embedDim, kdim}, xavierUniformInit(kdim, embedDim))
+		m.VProjWeight = vs.MustNewVar("v_proj_weight", []int64{embedDim, vdim}, xavierUniformInit(vdim, embedDim))
+	}
+
+	if c.Bias {
+		m.InProjBias = vs.MustNewVar("in_proj_bias", []int64{3 * embedDim}, NewConstInit(0.0))
+	}
+
+	outCfg := DefaultLinearConfig()
+	outCfg.BsInit = NewConstInit(0.0)
+	outCfg.Bias = c.Bias
+	m.OutProj = NewLinear(vs.Sub("out_proj"), embedDim, embedDim, outCfg)
+
+	return m
+}
+
+// xavierUniformInit creates uniform initializer with Xavier (Glorot) bound.
+func xavierUniformInit(fanIn, fanOut int64) Init {
+	bound := math.Sqrt(6.0 / float64(fanIn+fanOut))
+	return NewUniformInit(-bound, bound)
+}
+
+// AttentionOptions are options for MultiheadAttention forward pass.
+type AttentionOptions struct {
+	// KeyPaddingMask of shape (batch, srcLen) marks keys to be ignored.
+	// Bool mask: true means ignored. Float mask: added to attention scores.
+	KeyPaddingMask *ts.Tensor
+
+	// AttnMask of shape (tgtLen, srcLen) or (batch*numHeads, tgtLen, srcLen) prevents
+	// attention to certain positions. Bool mask: true means not allowed to attend.
+	// Float mask: added to attention scores.
+	AttnMask *ts.Tensor
+
+	// IsCausal applies a causal mask if AttnMask is not given.
+	IsCausal bool
+
+	// NeedWeights returns attention weights. Attention is calculated with
+	// `ts.ScaledDotProductAttention` (fused kernels) only if NeedWeights=false.
+	NeedWeights bool
+
+	// AverageAttnWeights averages returned attention weights across heads.
+	AverageAttnWeights bool
+}
+
+type AttentionOption func(*AttentionOptions)
+
+func defaultAttentionOptions() *AttentionOptions {
+	return &AttentionOptions{
+		KeyPaddingMask:     nil,
+		AttnMask:           nil,
+		IsCausal:           false,
+		NeedWeights:        false,
+		AverageAttnWeights: true,
+	}
+}
+
+func WithKeyPaddingMask(mask *ts.Tensor) AttentionOption {
+	return func(o *AttentionOptions) {
+		o.KeyPaddingMask = mask
+	}
+}
+
+func WithAttnMask(mask *ts.Tensor) AttentionOption {
+	return func(o *AttentionOptions) {
+		o.AttnMask = mask
+	}
+}
+
+func WithIsCausal(v bool) AttentionOption {
+	return func(o *AttentionOptions) {
+		o.IsCausal = v
+	}
+}
+
+func WithNeedWeights(v bool) AttentionOption {
+	return func(o *AttentionOptions) {
+		o.NeedWeights = v
+	}
+}
+
+func WithAverageAttnWeights(v bool) AttentionOption {
+	return func(o *AttentionOptions) {
+		o.AverageAttnWeights = v
+	}
+}
+
+// ForwardAttn computes attention of query over key and value.
+//
+// Shape of query is (tgtLen, batch, embedDim) and shape of key/value is (srcLen, batch, kdim/vdim)
+// or batch first if `BatchFirst=true`. It returns attention output of the same shape
+// as query and attention weights of shape (batch, tgtLen, srcLen), or
+// (batch, numHeads, tgtLen, srcLen) if `AverageAttnWeights=false`. Attention weights
+// are nil unless option `WithNeedWeights(true)` is specified.
+func (m *MultiheadAttention) ForwardAttn(query, key, value *ts.Tensor, train bool, opts ...AttentionOption) (*ts.Tensor, *ts.Tensor) {
+	o := defaultAttentionOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if !m.BatchFirst {
+		query = query.MustTranspose(0, 1, false)
+		key = key.MustTranspose(0, 1, false)
+		value = value.MustTranspose(0, 1, false)
+		defer query.MustDrop()
+		defer key.MustDrop()
+		defer value.MustDrop()
+	}
+
+	var (
+		size = query.MustSize()
+		n    = size[0]
+		l    = size[1]
+		s    = key.MustSize()[1]
+	)
+
+	q, k, v := m.inProjection(query, key, value)
+	q = m.splitHeads(q, n, l)
+	k = m.splitHeads(k, n, s)
+	v = m.splitHeads(v, n, s)
+	defer q.MustDrop()
+	defer k.MustDrop()
+	defer v.MustDrop()
+
+	dropoutP := 0.0
+	if train {
+		dropoutP = m.Dropout
+	}
+
+	mask, isCausal := attentionMask(o, query.DType(), query.MustDevice(), n, m.NumHeads, l, s)
+	if mask != nil {
+		defer mask.MustDrop()
+	}
+
+	var attn, weights *ts.Tensor
+	if !o.NeedWeights {
+		attnMask := ts.None
+		if mask != nil {
+			attnMask = mask
+		}
+		attn = ts.MustScaledDotProductAttention(q, k, v, attnMask, dropoutP, isCausal, nil)
+	} else {
+		kt := k.MustTranspose(-2, -1, false)
+		scores := q.MustMulScalar(ts.FloatScalar(1.0/math.Sqrt(float64(m.HeadDim))), false).MustMatmul(kt, true)
+		kt.MustDrop()
+		if mask != nil {
+			scores = scores.MustAdd(mask, true)
+		}
+		weights = scores.MustSoftmax(-1, query.DType(), true)
+		w := ts.MustDropout(weights, dropoutP, train)
+		attn = w.MustMatmul(v, true)
+		if o.AverageAttnWeights {
+			weights = weights.MustMeanDim([]int64{1}, false, query.DType(), true)
+		}
+	}
+
+	// Merge heads: (batch, numHeads, tgtLen, headDim) -> (batch, tgtLen, embedDim)
+	attn = attn.MustTranspose(1, 2, true).MustContiguous(true).MustView([]int64{n, l, m.EmbedDim}, true)
+	out := m.OutProj.Forward(attn)
+	attn.MustDrop()
+
+	if !m.BatchFirst {
+		out = out.MustTranspose(0, 1, true)
+	}
+
+	return out, weights
+}
+
+// inProjection projects query, key and value with input projection weights.
+func (m *MultiheadAttention) inProjection(query, key, value *ts.Tensor) (q, k, v *ts.Tensor) {
+	var (
+		e          = m.EmbedDim
+		wq, wk, wv = m.QProjWeight, m.KProjWeight, m.VProjWeight
+		bq, bk, bv = ts.None, ts.None, ts.None
+		views      []*ts.Tensor
+	)
+
+	if m.InProjWeight != nil {
+		wq = m.InProjWeight.MustNarrow(0, 0, e, false)
+		wk = m.InProjWeight.MustNarrow(0, e, e, false)
+		wv = m.InProjWeight.MustNarrow(0, 2*e, e, false)
+		views = append(views, wq, wk, wv)
+	}
+	if m.InProjBias != nil {
+		bq = m.InProjBias.MustNarrow(0, 0, e, false)
+		bk = m.InProjBias.MustNarrow(0, e, e, false)
+		bv = m.InProjBias.MustNarrow(0, 2*e, e, false)
+		views = append(views, bq, bk, bv)
+	}
+
+	q = ts.MustLinear(query, wq, bq)
+	k = ts.MustLinear(key, wk, bk)
+	v = ts.MustLinear(value, wv, bv)
+
+	for _, x := range views {
+		x.MustDrop()
+	}
+
+	return q, k, v
+}
+
+// splitHeads reshapes (batch, seqLen, embedDim) to (batch, numHeads, seqLen, headDim).
+func (m *MultiheadAttention) splitHeads(x *ts.Tensor, n, seqLen int64) *ts.Tensor {
+	return x.MustView([]int64{n, seqLen, m.NumHeads, m.HeadDim}, true).MustTranspose(1, 2, true)
+}
+
+// attentionMask merges attention mask and key padding mask into a float mask
+// broadcastable to (batch, numHeads, tgtLen, srcLen) where masked positions are -inf.
+//
+// It returns nil mask if there's nothing to mask. If only a causal mask is
+// requested and attention weights are not needed, it returns nil mask and
+// isCausal=true so that causal masking is done by the fused attention kernel.
+func attentionMask(o *AttentionOptions, dtype gotch.DType, device gotch.Device, n, numHeads, l, s int64) (mask *ts.Tensor, isCausal bool) {
+	if o.IsCausal && o.AttnMask == nil && o.KeyPaddingMask == nil && !o.NeedWeights {
+		return nil, true
+	}
+
+	var masks []*ts.Tensor
+	switch {
+	case o.AttnMask != nil:
+		m := toFloatMask(o.AttnMask, dtype)
+		if m.Dim() == 3 {
+			m = m.MustView([]int64{n, numHeads, l, s}, true)
+		}
+		masks = append(masks, m)
+	case o.IsCausal:
+		causal := ts.MustOnes([]int64{l, s}, gotch.Bool, device).MustTriu(1, true)
+		masks = append(masks, toFloatMask(causal, dtype))
+		causal.MustDrop()
+	}
+
+	if o.KeyPaddingMask != nil {
+		m := toFloatMask(o.KeyPaddingMask, dtype).MustView([]int64{n, 1, 1, s}, true)
+		masks = append(masks, m)
+	}
+
+	if len(masks) == 0 {
+		return nil, false
+	}
+
+	mask = masks[0]
+	for _, m := range masks[1:] {
+		mask = mask.MustAdd(m, true)
+		m.MustDrop()
+	}
+
+	return mask, false
+}
+
+// toFloatMask converts a boolean mask (true means masked) to a float mask
+// with -inf at masked positions. Float masks are returned as a copy of given dtype.
+func toFloatMask(mask *ts.Tensor, dtype gotch.DType) *ts.Tensor {
+	if mask.DType() == gotch.Bool {
+		return ts.MustZeros(mask.MustSize(), dtype, mask.MustDevice()).MustMaskedFill(mask, ts.FloatScalar(math.Inf(-1)), true)
+	}
+
+	return mask.MustTotype(dtype, false)
+}
+
+// Implement Module, ModuleT interfaces for MultiheadAttention (self-attention):
+// =============================================================================
+
+// Forward implements Module interface for MultiheadAttention. It computes
+// self-attention of input in evaluation mode.
+func (m *MultiheadAttention) Forward(xs *ts.Tensor) *ts.Tensor {
+	out, _ := m.ForwardAttn(xs, xs, xs, false)
+	return out
+}
+
+// ForwardT implements ModuleT interface for MultiheadAttention. It computes
+// self-attention of input.
+func (m *MultiheadAttention) ForwardT(xs *ts.Tensor, train bool) *ts.Tensor {
+	out, _ := m.ForwardAttn(xs, xs, xs, train)
+	return out
+}
@@ -0,0 +1,273 @@
+package nn
+
+// Training checkpoints.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+const (
+	checkpointFormat      = "gotch-checkpoint"
+	checkpointModelPrefix = "model."
+	checkpointStatePrefix = "optimizer.state."
+	checkpointRNGState    = "rng_state"
+)
+
+// optimizerMetadata is optimizer state (except state tensors) saved in checkpoint metadata.
+type optimizerMetadata struct {
+	StepCount   int                  `json:"step_count"`
+	ParamGroups []map[string]float64 `json:"param_groups"`
+}
+
+// SaveCheckpoint saves a training checkpoint to a single file. It bundles
+// model weights, optimizer state, learning rate scheduler state, training step
+// and state of the CPU random number generator so that training can be resumed
+// with `LoadCheckpoint()`.
+//
+// Optimizer and scheduler are optional (can be nil). The checkpoint is saved in
+// safetensors format where tensors are prefixed with "model." and "optimizer.state."
+// and other states are saved as JSON in the header metadata.
+func SaveCheckpoint(path string, vs *VarStore, opt *Optimizer, sched *LRScheduler, step int) error {
+	var (
+		namedTensors []ts.NamedTensor
+		owned        []*ts.Tensor // tensors to be freed after saving
+	)
+	defer func() {
+		for _, x := range owned {
+			x.MustDrop()
+		}
+	}()
+
+	vs.Lock()
+	for name, v := range vs.vars {
+		if v.Type == "parameter" || (v.Type == "buffer" && v.Persitent) {
+			namedTensors = append(namedTensors, ts.NamedTensor{
+				Name:   checkpointModelPrefix + name,
+				Tensor: v.Tensor,
+			})
+		}
+	}
+	vs.Unlock()
+
+	metadata := map[string]string{
+		"format": checkpointFormat,
+		"step":   strconv.Itoa(step),
+	}
+
+	if opt != nil {
+		s, err := opt.StateDict()
+		if err != nil {
+			err = fmt.Errorf("SaveCheckpoint() failed: %w", err)
+			return err
+		}
+		for name, x := range s.State {
+			namedTensors = append(namedTensors, ts.NamedTensor{
+				Name:   checkpointStatePrefix + name,
+				Tensor: x,
+			})
+			owned = append(owned, x)
+		}
+
+		data, err := json.Marshal(optimizerMetadata{
+			StepCount:   s.StepCount,
+			ParamGroups: s.ParamGroups,
+		})
+		if err != nil {
+			err = fmt.Errorf("SaveCheckpoint() failed: %w", err)
+			return err
+		}
+		metadata["optimizer"] = string(data)
+	}
+
+	if sched != nil {
+		state, err := sched.StateDict()
+		if err != nil {
+			err = fmt.Errorf("SaveCheckpoint() failed: %w", err)
+			return err
+		}
+		data, err := json.Marshal(jsonState(state))
+		if err != nil {
+			err = fmt.Errorf("SaveCheckpoint() failed: %w", err)
+			return err
+		}
+		metadata["scheduler"] = string(data)
+	}
+
+	rngState, err := ts.GetRNGState()
+	if err != nil {
+		err = fmt.Errorf("SaveCheckpoint() failed: %w", err)
+		return err
+	}
+	owned = append(owned, rngState)
+	namedTensors = append(namedTensors, ts.NamedTensor{
+		Name:   checkpointRNGState,
+		Tensor: rngState,
+	})
+
+	if err := ts.SaveSafetensors(namedTensors, path, metadata); err != nil {
+		err = fmt.Errorf("SaveCheckpoint() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// MustSaveCheckpoint saves a training checkpoint. It panics if error occurred.
+func MustSaveCheckpoint(path string, vs *VarStore, opt *Optimizer, sched *LRScheduler, step int) {
+	if err := SaveCheckpoint(path, vs, opt, sched, step); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// LoadCheckpoint loads a training checkpoint saved by `SaveCheckpoint()` and
+// returns the saved training step.
+//
+// Model weights are copied to variables of the VarStore. Optimizer and scheduler
+// are optional (can be nil) and should be built the same way as when saving.
+// State of the CPU random number generator is also restored.
+func LoadCheckpoint(path string, vs *VarStore, opt *Optimizer, sched *LRScheduler) (int, error) {
+	namedTensors, metadata, err := ts.LoadSafetensorsWithMetadata(path)
+	if err != nil {
+		err = fmt.Errorf("LoadCheckpoint() failed: %w", err)
+		return 0, err
+	}
+	defer func() {
+		for _, nt := range namedTensors {
+			nt.Tensor.MustDrop()
+		}
+	}()
+
+	if metadata["format"] != checkpointFormat {
+		err := fmt.Errorf("LoadCheckpoint() failed: %q is not a checkpoint file", path)
+		return 0, err
+	}
+	step, err := strconv.Atoi(metadata["step"])
+	if err != nil {
+		err = fmt.Errorf("LoadCheckpoint() failed: invalid step: %w", err)
+		return 0, err
+	}
+
+	var (
+		weights  []ts.NamedTensor
+		state    = make(map[string]*ts.Tensor)
+		rngState *ts.Tensor
+	)
+	for _, nt := range namedTensors {
+		switch {
+		case strings.HasPrefix(nt.Name, checkpointModelPrefix):
+			weights = append(weights, ts.NamedTensor{
+				Name:   strings.TrimPrefix(nt.Name, checkpointModelPrefix),
+				Tensor: nt.Tensor,
+			})
+		case strings.HasPrefix(nt.Name, checkpointStatePrefix):
+			state[strings.TrimPrefix(nt.Name, checkpointStatePrefix)] = nt.Tensor
+		case nt.Name == checkpointRNGState:
+			rngState = nt.Tensor
+		}
+	}
+
+	if err := vs.LoadWeights(weights); err != nil {
+		err = fmt.Errorf("LoadCheckpoint() failed: %w", err)
+		return 0, err
+	}
+
+	if opt != nil {
+		data, ok := metadata["optimizer"]
+		if !ok {
+			err := fmt.Errorf("LoadCheckpoint() failed: no optimizer state found in checkpoint")
+			return 0, err
+		}
+		var m optimizerMetadata
+		if err := json.Unmarshal([]byte(data), &m); err != nil {
+			err = fmt.Errorf("LoadCheckpoint() failed: invalid optimizer state: %w", err)
+			return 0, err
+		}
+		s := &OptimizerState{
+			StepCount:   m.StepCount,
+			ParamGroups: m.ParamGroups,
+			State:       state,
+		}
+		if err := opt.LoadStateDict(s); err != nil {
+			err = fmt.Errorf("LoadCheckpoint() failed: %w", err)
+			return 0, err
+		}
+	}
+
+	if sched != nil {
+		data, ok := metadata["scheduler"]
+		if !ok {
+			err := fmt.Errorf("LoadCheckpoint() failed: no scheduler state found in checkpoint")
+			return 0, err
+		}
+		var s map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &s); err != nil {
+			err = fmt.Errorf("LoadCheckpoint() failed: invalid scheduler state: %w", err)
+			return 0, err
+		}
+		if err := sched.LoadStateDict(s); err != nil {
+			err = fmt.Errorf("LoadCheckpoint() failed: %w", err)
+			return 0, err
+		}
+	}
+
+	if rngState != nil {
+		if err := ts.SetRNGState(rngState); err != nil {
+			err = fmt.Errorf("LoadCheckpoint() failed: %w", err)
+			return 0, err
+		}
+	}
+
+	return step, nil
+}
+
+// MustLoadCheckpoint loads a training checkpoint. It panics if error occurred.
+func MustLoadCheckpoint(path string, vs *VarStore, opt *Optimizer, sched *LRScheduler) int {
+	step, err := LoadCheckpoint(path, vs, opt, sched)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return step
+}
+
+// jsonState converts non-finite float values of a state dict to strings
+// ("inf", "-inf", "nan") as they are not supported by JSON.
+func jsonState(state map[string]interface{}) map[string]interface{} {
+	jsonFloat := func(v float64) interface{} {
+		switch {
+		case math.IsInf(v, 1):
+			return "inf"
+		case math.IsInf(v, -1):
+			return "-inf"
+		case math.IsNaN(v):
+			return "nan"
+		default:
+			return v
+		}
+	}
+
+	out := make(map[string]interface{}, len(state))
+	for k, v := range state {
+		switch v := v.(type) {
+		case float64:
+			out[k] = jsonFloat(v)
+		case []float64:
+			vals := make([]interface{}, len(v))
+			for i, x := range v {
+				vals[i] = jsonFloat(x)
+			}
+			out[k] = vals
+		default:
+			out[k] = v
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,80 @@
+package nn_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestCheckpoint(t *testing.T) {
+	x := ts.MustRandn([]int64{8, 4}, gotch.Float, gotch.CPU)
+	y := ts.MustRandn([]int64{8, 2}, gotch.Float, gotch.CPU)
+
+	build := func() (*nn.VarStore, *nn.Linear, *nn.Optimizer, *nn.LRScheduler) {
+		vs := nn.NewVarStore(gotch.CPU)
+		model := nn.NewLinear(vs.Root(), 4, 2, nn.DefaultLinearConfig())
+		opt, err := nn.DefaultAdamWConfig().Build(vs, 1e-2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sched := nn.NewExponentialLR(opt, 0.9).Build()
+		return vs, model, opt, sched
+	}
+
+	vs1, model1, opt1, sched1 := build()
+	for i := 0; i < 3; i++ {
+		loss := model1.Forward(x).MustMseLoss(y, 1, true)
+		opt1.MustZeroGrad()
+		loss.MustBackward()
+		opt1.MustStep()
+		sched1.Step()
+		loss.MustDrop()
+	}
+
+	file := filepath.Join(t.TempDir(), "checkpoint.safetensors")
+	if err := nn.SaveCheckpoint(file, vs1, opt1, sched1, 3); err != nil {
+		t.Fatal(err)
+	}
+	want := ts.MustRandn([]int64{4}, gotch.Float, gotch.CPU).Float64Values()
+
+	vs2, model2, opt2, sched2 := build()
+	step, err := nn.LoadCheckpoint(file, vs2, opt2, sched2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != 3 {
+		t.Errorf("want step 3, got %v", step)
+	}
+	if !reflect.DeepEqual(model1.Ws.Float64Values(), model2.Ws.Float64Values()) {
+		t.Errorf("want same weights, got %v and %v", model1.Ws.Float64Values(), model2.Ws.Float64Values())
+	}
+	if !reflect.DeepEqual(opt1.GetLRs(), opt2.GetLRs()) {
+		t.Errorf("want lrs %v, got %v", opt1.GetLRs(), opt2.GetLRs())
+	}
+	if opt2.StepCount() != 3 {
+		t.Errorf("want optimizer step count 3, got %v", opt2.StepCount())
+	}
+
+	// RNG state is restored.
+	got := ts.MustRandn([]int64{4}, gotch.Float, gotch.CPU).Float64Values()
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want same random numbers after loading, got %v and %v", want, got)
+	}
+
+	// Scheduler continues from saved state.
+	sched1.Step()
+	sched2.Step()
+	if !reflect.DeepEqual(opt1.GetLRs(), opt2.GetLRs()) {
+		t.Errorf("want lrs %v, got %v", opt1.GetLRs(), opt2.GetLRs())
+	}
+
+	// Model and optimizer only.
+	vs3, _, opt3, _ := build()
+	if _, err := nn.LoadCheckpoint(file, vs3, opt3, nil); err != nil {
+		t.Fatal(err)
+	}
+}
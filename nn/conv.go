@@ -4,6 +4,7 @@ package nn
 
 import (
 	"fmt"
+	"log"
 	"math"
 	"reflect"
 
@@ -15,13 +16,15 @@ import (
 
 // Conv1DConfig is configuration struct for convolution 1D.
 type Conv1DConfig struct {
-	Stride   []int64
-	Padding  []int64
-	Dilation []int64
-	Groups   int64
-	Bias     bool
-	WsInit   Init
-	BsInit   Init
+	Stride        []int64
+	Padding       []int64 // either 1 (symmetric) or 2 (begin, end of each dimension) values
+	PaddingPreset string  // optional. Either "same" or "valid". If set, it overrides Padding.
+	PaddingMode   string  // either "zeros" (default), "reflect", "replicate" or "circular"
+	Dilation      []int64
+	Groups        int64
+	Bias          bool
+	WsInit        Init
+	BsInit        Init
 }
 
 // Conv1DConfigOpt is option for Conv1DConfig.
@@ -41,6 +44,28 @@ func WithPadding1D(val int64) Conv1DConfigOpt {
 	}
 }
 
+// WithPaddings1D adds per-dimension padding 1D option. `vals` are either 1
+// symmetric values or 2 (begin, end) values of each dimension, e.g., [left, right].
+func WithPaddings1D(vals ...int64) Conv1DConfigOpt {
+	return func(cfg *Conv1DConfig) {
+		cfg.Padding = vals
+	}
+}
+
+// WithPaddingPreset1D adds padding preset 1D option. It's either "same" or "valid".
+func WithPaddingPreset1D(val string) Conv1DConfigOpt {
+	return func(cfg *Conv1DConfig) {
+		cfg.PaddingPreset = val
+	}
+}
+
+// WithPaddingMode1D adds padding mode 1D option. It's either "zeros", "reflect", "replicate" or "circular".
+func WithPaddingMode1D(val string) Conv1DConfigOpt {
+	return func(cfg *Conv1DConfig) {
+		cfg.PaddingMode = val
+	}
+}
+
 // WithDilation1D adds dilation 1D option.
 func WithDilation1D(val int64) Conv1DConfigOpt {
 	return func(cfg *Conv1DConfig) {
@@ -79,13 +104,14 @@ func WithBsInit1D(val Init) Conv1DConfigOpt {
 func DefaultConv1DConfig() *Conv1DConfig {
 	negSlope := math.Sqrt(5)
 	return &Conv1DConfig{
-		Stride:   []int64{1},
-		Padding:  []int64{0},
-		Dilation: []int64{1},
-		Groups:   1,
-		Bias:     true,
-		WsInit:   NewKaimingUniformInit(WithKaimingNegativeSlope(negSlope)),
-		BsInit:   nil,
+		Stride:      []int64{1},
+		Padding:     []int64{0},
+		PaddingMode: "zeros",
+		Dilation:    []int64{1},
+		Groups:      1,
+		Bias:        true,
+		WsInit:      NewKaimingUniformInit(WithKaimingNegativeSlope(negSlope)),
+		BsInit:      nil,
 	}
 }
 
@@ -104,13 +130,15 @@ func NewConv1DConfig(opts ...Conv1DConfigOpt) *Conv1DConfig {
 
 // Conv2DConfig is configuration for convolution 2D.
 type Conv2DConfig struct {
-	Stride   []int64
-	Padding  []int64
-	Dilation []int64
-	Groups   int64
-	Bias     bool
-	WsInit   Init
-	BsInit   Init
+	Stride        []int64
+	Padding       []int64 // either 2 (symmetric) or 4 (begin, end of each dimension) values
+	PaddingPreset string  // optional. Either "same" or "valid". If set, it overrides Padding.
+	PaddingMode   string  // either "zeros" (default), "reflect", "replicate" or "circular"
+	Dilation      []int64
+	Groups        int64
+	Bias          bool
+	WsInit        Init
+	BsInit        Init
 }
 
 // Conv2DConfigOpt is option type for Conv2DConfig.
@@ -130,6 +158,28 @@ func WithPadding2D(val int64) Conv2DConfigOpt {
 	}
 }
 
+// WithPaddings2D adds per-dimension padding 2D option. `vals` are either 2
+// symmetric values or 4 (begin, end) values of each dimension, e.g., [top, bottom, left, right].
+func WithPaddings2D(vals ...int64) Conv2DConfigOpt {
+	return func(cfg *Conv2DConfig) {
+		cfg.Padding = vals
+	}
+}
+
+// WithPaddingPreset2D adds padding preset 2D option. It's either "same" or "valid".
+func WithPaddingPreset2D(val string) Conv2DConfigOpt {
+	return func(cfg *Conv2DConfig) {
+		cfg.PaddingPreset = val
+	}
+}
+
+// WithPaddingMode2D adds padding mode 2D option. It's either "zeros", "reflect", "replicate" or "circular".
+func WithPaddingMode2D(val string) Conv2DConfigOpt {
+	return func(cfg *Conv2DConfig) {
+		cfg.PaddingMode = val
+	}
+}
+
 // WithDilation2D adds dilation 2D option.
 func WithDilation2D(val int64) Conv2DConfigOpt {
 	return func(cfg *Conv2DConfig) {
@@ -169,13 +219,14 @@ func WithBsInit2D(val Init) Conv2DConfigOpt {
 func DefaultConv2DConfig() *Conv2DConfig {
 	negSlope := math.Sqrt(5)
 	return &Conv2DConfig{
-		Stride:   []int64{1, 1},
-		Padding:  []int64{0, 0},
-		Dilation: []int64{1, 1},
-		Groups:   1,
-		Bias:     true,
-		WsInit:   NewKaimingUniformInit(WithKaimingNegativeSlope(negSlope)),
-		BsInit:   nil,
+		Stride:      []int64{1, 1},
+		Padding:     []int64{0, 0},
+		PaddingMode: "zeros",
+		Dilation:    []int64{1, 1},
+		Groups:      1,
+		Bias:        true,
+		WsInit:      NewKaimingUniformInit(WithKaimingNegativeSlope(negSlope)),
+		BsInit:      nil,
 	}
 }
 
@@ -194,13 +245,15 @@ func NewConv2DConfig(opts ...Conv2DConfigOpt) *Conv2DConfig {
 
 // Conv3DConfig is configuration struct for convolution 3D.
 type Conv3DConfig struct {
-	Stride   []int64
-	Padding  []int64
-	Dilation []int64
-	Groups   int64
-	Bias     bool
-	WsInit   Init
-	BsInit   Init
+	Stride        []int64
+	Padding       []int64 // either 3 (symmetric) or 6 (begin, end of each dimension) values
+	PaddingPreset string  // optional. Either "same" or "valid". If set, it overrides Padding.
+	PaddingMode   string  // either "zeros" (default), "reflect", "replicate" or "circular"
+	Dilation      []int64
+	Groups        int64
+	Bias          bool
+	WsInit        Init
+	BsInit        Init
 }
 
 // Conv3DConfigOpt is option type for Conv3DConfig.
@@ -220,6 +273,28 @@ func WithPadding3D(val int64) Conv3DConfigOpt {
 	}
 }
 
+// WithPaddings3D adds per-dimension padding 3D option. `vals` are either 3
+// symmetric values or 6 (begin, end) values of each dimension, e.g., [front, back, top, bottom, left, right].
+func WithPaddings3D(vals ...int64) Conv3DConfigOpt {
+	return func(cfg *Conv3DConfig) {
+		cfg.Padding = vals
+	}
+}
+
+// WithPaddingPreset3D adds padding preset 3D option. It's either "same" or "valid".
+func WithPaddingPreset3D(val string) Conv3DConfigOpt {
+	return func(cfg *Conv3DConfig) {
+		cfg.PaddingPreset = val
+	}
+}
+
+// WithPaddingMode3D adds padding mode 3D option. It's either "zeros", "reflect", "replicate" or "circular".
+func WithPaddingMode3D(val string) Conv3DConfigOpt {
+	return func(cfg *Conv3DConfig) {
+		cfg.PaddingMode = val
+	}
+}
+
 // WithDilation3D adds dilation 3D option.
 func WithDilation3D(val int64) Conv3DConfigOpt {
 	return func(cfg *Conv3DConfig) {
@@ -259,13 +334,14 @@ func WithBsInit3D(val Init) Conv3DConfigOpt {
 func DefaultConv3DConfig() *Conv3DConfig {
 	negSlope := math.Sqrt(5)
 	return &Conv3DConfig{
-		Stride:   []int64{1, 1, 1},
-		Padding:  []int64{0, 0, 0},
-		Dilation: []int64{1, 1, 1},
-		Groups:   1,
-		Bias:     true,
-		WsInit:   NewKaimingUniformInit(WithKaimingNegativeSlope(negSlope)),
-		BsInit:   nil,
+		Stride:      []int64{1, 1, 1},
+		Padding:     []int64{0, 0, 0},
+		PaddingMode: "zeros",
+		Dilation:    []int64{1, 1, 1},
+		Groups:      1,
+		Bias:        true,
+		WsInit:      NewKaimingUniformInit(WithKaimingNegativeSlope(negSlope)),
+		BsInit:      nil,
 	}
 }
 
@@ -284,6 +360,8 @@ type Conv1D struct {
 	Ws     *ts.Tensor
 	Bs     *ts.Tensor // optional
 	Config *Conv1DConfig
+
+	kernelSize []int64 // spatial dimensions of Ws
 }
 
 // NewConv1D creates Conv1D struct.
@@ -315,9 +393,10 @@ func NewConv1D(vs *Path, inDim, outDim, k int64, cfg *Conv1DConfig) *Conv1D {
 	}
 
 	return &Conv1D{
-		Ws:     ws,
-		Bs:     bs,
-		Config: cfg,
+		Ws:         ws,
+		Bs:         bs,
+		Config:     cfg,
+		kernelSize: []int64{k},
 	}
 }
 
@@ -326,16 +405,28 @@ type Conv2D struct {
 	Ws     *ts.Tensor
 	Bs     *ts.Tensor // optional
 	Config *Conv2DConfig
+
+	kernelSize []int64 // spatial dimensions of Ws
 }
 
-// NewConv2D creates new Conv2D.
+// NewConv2D creates new Conv2D with kernel size k in all dimensions.
 func NewConv2D(vs *Path, inDim, outDim int64, k int64, cfg *Conv2DConfig) *Conv2D {
+	return NewConv2DWithKernelSize(vs, inDim, outDim, []int64{k, k}, cfg)
+}
+
+// NewConv2DWithKernelSize creates new Conv2D with per-dimension kernel size
+// (height and width).
+func NewConv2DWithKernelSize(vs *Path, inDim, outDim int64, kernelSize []int64, cfg *Conv2DConfig) *Conv2D {
+	if len(kernelSize) != 2 {
+		err := fmt.Errorf("NewConv2DWithKernelSize() failed: expected kernel size of 2 values, got %v", kernelSize)
+		panic(err)
+	}
 	var (
 		ws *ts.Tensor
 		bs *ts.Tensor = ts.NewTensor()
 	)
 	weightSize := []int64{outDim, int64(inDim / cfg.Groups)}
-	weightSize = append(weightSize, k, k)
+	weightSize = append(weightSize, kernelSize...)
 	ws = vs.MustNewVar("weight", weightSize, cfg.WsInit)
 
 	if cfg.Bias {
@@ -358,9 +449,10 @@ func NewConv2D(vs *Path, inDim, outDim int64, k int64, cfg *Conv2DConfig) *Conv2
 	}
 
 	return &Conv2D{
-		Ws:     ws,
-		Bs:     bs,
-		Config: cfg,
+		Ws:         ws,
+		Bs:         bs,
+		Config:     cfg,
+		kernelSize: kernelSize,
 	}
 }
 
@@ -369,16 +461,28 @@ type Conv3D struct {
 	Ws     *ts.Tensor
 	Bs     *ts.Tensor // optional
 	Config *Conv3DConfig
+
+	kernelSize []int64 // spatial dimensions of Ws
 }
 
-// NewConv3D creates new Conv3D struct.
+// NewConv3D creates new Conv3D with kernel size k in all dimensions.
 func NewConv3D(vs *Path, inDim, outDim, k int64, cfg *Conv3DConfig) *Conv3D {
+	return NewConv3DWithKernelSize(vs, inDim, outDim, []int64{k, k, k}, cfg)
+}
+
+// NewConv3DWithKernelSize creates new Conv3D with per-dimension kernel size
+// (depth, height and width).
+func NewConv3DWithKernelSize(vs *Path, inDim, outDim int64, kernelSize []int64, cfg *Conv3DConfig) *Conv3D {
+	if len(kernelSize) != 3 {
+		err := fmt.Errorf("NewConv3DWithKernelSize() failed: expected kernel size of 3 values, got %v", kernelSize)
+		panic(err)
+	}
 	var (
 		ws *ts.Tensor
 		bs *ts.Tensor = ts.NewTensor()
 	)
 	weightSize := []int64{outDim, int64(inDim / cfg.Groups)}
-	weightSize = append(weightSize, k, k, k)
+	weightSize = append(weightSize, kernelSize...)
 	ws = vs.MustNewVar("weight", weightSize, cfg.WsInit)
 
 	if cfg.Bias {
@@ -401,9 +505,10 @@ func NewConv3D(vs *Path, inDim, outDim, k int64, cfg *Conv3DConfig) *Conv3D {
 	}
 
 	return &Conv3D{
-		Ws:     ws,
-		Bs:     bs,
-		Config: cfg,
+		Ws:         ws,
+		Bs:         bs,
+		Config:     cfg,
+		kernelSize: kernelSize,
 	}
 }
 
@@ -475,9 +580,10 @@ func NewConv(vs *Path, inDim, outDim int64, ksizes []int64, config interface{})
 		weightSize = append(weightSize, ksizes...)
 		ws = vs.MustNewVar("weight", weightSize, cfg.WsInit)
 		return &Conv1D{
-			Ws:     ws,
-			Bs:     bs,
-			Config: cfg,
+			Ws:         ws,
+			Bs:         bs,
+			Config:     cfg,
+			kernelSize: ksizes,
 		}
 	case len(ksizes) == 2 && configT.String() == "*nn.Conv2DConfig":
 		cfg := config.(*Conv2DConfig)
@@ -488,9 +594,10 @@ func NewConv(vs *Path, inDim, outDim int64, ksizes []int64, config interface{})
 		weightSize = append(weightSize, ksizes...)
 		ws = vs.MustNewVar("weight", weightSize, cfg.WsInit)
 		return &Conv2D{
-			Ws:     ws,
-			Bs:     bs,
-			Config: cfg,
+			Ws:         ws,
+			Bs:         bs,
+			Config:     cfg,
+			kernelSize: ksizes,
 		}
 	case len(ksizes) == 3 && configT.String() == "*nn.Conv3DConfig":
 		cfg := config.(*Conv3DConfig)
@@ -501,9 +608,10 @@ func NewConv(vs *Path, inDim, outDim int64, ksizes []int64, config interface{})
 		weightSize = append(weightSize, ksizes...)
 		ws = vs.MustNewVar("weight", weightSize, cfg.WsInit)
 		return &Conv3D{
-			Ws:     ws,
-			Bs:     bs,
-			Config: cfg,
+			Ws:         ws,
+			Bs:         bs,
+			Config:     cfg,
+			kernelSize: ksizes,
 		}
 	default:
 		err := fmt.Errorf("Expected nd length from 1 to 3. Got %v - configT name: '%v'\n", len(ksizes), configT.String())
@@ -515,14 +623,33 @@ func NewConv(vs *Path, inDim, outDim int64, ksizes []int64, config interface{})
 // ============================================
 
 func (c *Conv1D) Forward(xs *ts.Tensor) *ts.Tensor {
-	return ts.MustConv1d(xs, c.Ws, c.Bs, c.Config.Stride, c.Config.Padding, c.Config.Dilation, c.Config.Groups)
+	x, padding := convInput(xs, convKernelSize(c.Ws, c.kernelSize), c.Config.Padding, c.Config.PaddingPreset, c.Config.PaddingMode, c.Config.Stride, c.Config.Dilation)
+	out := ts.MustConv1d(x, c.Ws, c.Bs, c.Config.Stride, padding, c.Config.Dilation, c.Config.Groups)
+	if x != xs {
+		x.MustDrop()
+	}
+
+	return out
 }
 
 func (c *Conv2D) Forward(xs *ts.Tensor) *ts.Tensor {
-	return ts.MustConv2d(xs, c.Ws, c.Bs, c.Config.Stride, c.Config.Padding, c.Config.Dilation, c.Config.Groups)
+	x, padding := convInput(xs, convKernelSize(c.Ws, c.kernelSize), c.Config.Padding, c.Config.PaddingPreset, c.Config.PaddingMode, c.Config.Stride, c.Config.Dilation)
+	out := ts.MustConv2d(x, c.Ws, c.Bs, c.Config.Stride, padding, c.Config.Dilation, c.Config.Groups)
+	if x != xs {
+		x.MustDrop()
+	}
+
+	return out
 }
+
 func (c *Conv3D) Forward(xs *ts.Tensor) *ts.Tensor {
-	return ts.MustConv3d(xs, c.Ws, c.Bs, c.Config.Stride, c.Config.Padding, c.Config.Dilation, c.Config.Groups)
+	x, padding := convInput(xs, convKernelSize(c.Ws, c.kernelSize), c.Config.Padding, c.Config.PaddingPreset, c.Config.PaddingMode, c.Config.Stride, c.Config.Dilation)
+	out := ts.MustConv3d(x, c.Ws, c.Bs, c.Config.Stride, padding, c.Config.Dilation, c.Config.Groups)
+	if x != xs {
+		x.MustDrop()
+	}
+
+	return out
 }
 
 // Implement ModuleT for Conv1D, Conv2D, Conv3D:
@@ -531,12 +658,101 @@ func (c *Conv3D) Forward(xs *ts.Tensor) *ts.Tensor {
 // NOTE: `train` param won't be used, will be?
 
 func (c *Conv1D) ForwardT(xs *ts.Tensor, train bool) *ts.Tensor {
-	return ts.MustConv1d(xs, c.Ws, c.Bs, c.Config.Stride, c.Config.Padding, c.Config.Dilation, c.Config.Groups)
+	return c.Forward(xs)
 }
 
 func (c *Conv2D) ForwardT(xs *ts.Tensor, train bool) *ts.Tensor {
-	return ts.MustConv2d(xs, c.Ws, c.Bs, c.Config.Stride, c.Config.Padding, c.Config.Dilation, c.Config.Groups)
+	return c.Forward(xs)
 }
+
 func (c *Conv3D) ForwardT(xs *ts.Tensor, train bool) *ts.Tensor {
-	return ts.MustConv3d(xs, c.Ws, c.Bs, c.Config.Stride, c.Config.Padding, c.Config.Dilation, c.Config.Groups)
+	return c.Forward(xs)
+}
+
+// convKernelSize returns kernel size stored at construction or reads it from
+// weight `ws` if the module wasn't built by a constructor.
+func convKernelSize(ws *ts.Tensor, kernelSize []int64) []int64 {
+	if len(kernelSize) > 0 {
+		return kernelSize
+	}
+
+	return ws.MustSize()[2:]
+}
+
+// convParam returns value of stride or dilation at dimension i. Nil or empty
+// values default to 1.
+func convParam(v []int64, i int) int64 {
+	if len(v) == 0 {
+		return 1
+	}
+
+	return poolParam(v, i)
+}
+
+// convInput resolves padding of a convolution with kernel size `kernel`. It returns
+// the input to be convolved and the symmetric padding to pass to the convolution op.
+//
+// Asymmetric padding and padding modes other than "zeros" can't be done by
+// the convolution op. In that case, the input is padded explicitly and
+// returned as a new tensor, and the convolution padding is zero.
+func convInput(xs *ts.Tensor, kernel []int64, padding []int64, preset, mode string, stride, dilation []int64) (*ts.Tensor, []int64) {
+	nd := len(kernel)
+
+	// (begin, end) padding of each spatial dimension.
+	pads := make([]int64, 2*nd)
+	switch preset {
+	case "":
+		switch len(padding) {
+		case 0:
+		case 1, nd:
+			for i := 0; i < nd; i++ {
+				pads[2*i] = poolParam(padding, i)
+				pads[2*i+1] = poolParam(padding, i)
+			}
+		case 2 * nd:
+			copy(pads, padding)
+		default:
+			log.Fatalf("Conv%vD: expected padding of %v or %v values, got %v\n", nd, nd, 2*nd, padding)
+		}
+	case "valid":
+	case "same":
+		for i := 0; i < nd; i++ {
+			if convParam(stride, i) != 1 {
+				log.Fatalf("Conv%vD: padding 'same' is not supported for strided convolutions, got stride %v\n", nd, stride)
+			}
+			total := convParam(dilation, i) * (kernel[i] - 1)
+			pads[2*i] = total / 2
+			pads[2*i+1] = total - total/2
+		}
+	default:
+		log.Fatalf("Conv%vD: unsupported padding preset %q. Expected 'same' or 'valid'\n", nd, preset)
+	}
+
+	var padMode string
+	switch mode {
+	case "", "zeros":
+		padMode = "constant"
+	case "reflect", "replicate", "circular":
+		padMode = mode
+	default:
+		log.Fatalf("Conv%vD: unsupported padding mode %q. Expected 'zeros', 'reflect', 'replicate' or 'circular'\n", nd, mode)
+	}
+
+	symmetric := true
+	convPadding := make([]int64, nd)
+	for i := 0; i < nd; i++ {
+		symmetric = symmetric && pads[2*i] == pads[2*i+1]
+		convPadding[i] = pads[2*i]
+	}
+	if symmetric && padMode == "constant" {
+		return xs, convPadding
+	}
+
+	// Pad op takes (begin, end) padding starting from the last dimension.
+	pad := make([]int64, 0, 2*nd)
+	for i := nd - 1; i >= 0; i-- {
+		pad = append(pad, pads[2*i], pads[2*i+1])
+	}
+
+	return xs.MustPad(pad, padMode, nil, false), make([]int64, nd)
 }
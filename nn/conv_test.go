@@ -0,0 +1,53 @@
+package nn_test
+
+import (
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+)
+
+func TestConv1D_Padding(t *testing.T) {
+	x := float32TensorOf([]float32{1, 2, 3}, 1, 1, 3)
+
+	tests := []struct {
+		name string
+		k    int64
+		opts []nn.Conv1DConfigOpt
+		want []float64
+	}{
+		{"zeros", 3, []nn.Conv1DConfigOpt{nn.WithPadding1D(1)}, []float64{3, 6, 5}},
+		{"same", 3, []nn.Conv1DConfigOpt{nn.WithPaddingPreset1D("same")}, []float64{3, 6, 5}},
+		{"same even kernel", 2, []nn.Conv1DConfigOpt{nn.WithPaddingPreset1D("same")}, []float64{3, 5, 3}},
+		{"same dilation", 2, []nn.Conv1DConfigOpt{nn.WithPaddingPreset1D("same"), nn.WithDilation1D(2)}, []float64{2, 4, 2}},
+		{"valid", 3, []nn.Conv1DConfigOpt{nn.WithPadding1D(1), nn.WithPaddingPreset1D("valid")}, []float64{6}},
+		{"asymmetric", 3, []nn.Conv1DConfigOpt{nn.WithPaddings1D(2, 0)}, []float64{1, 3, 6}},
+		{"reflect", 3, []nn.Conv1DConfigOpt{nn.WithPadding1D(1), nn.WithPaddingMode1D("reflect")}, []float64{5, 6, 7}},
+		{"replicate", 3, []nn.Conv1DConfigOpt{nn.WithPaddingPreset1D("same"), nn.WithPaddingMode1D("replicate")}, []float64{4, 6, 8}},
+		{"circular", 3, []nn.Conv1DConfigOpt{nn.WithPadding1D(1), nn.WithPaddingMode1D("circular")}, []float64{6, 6, 6}},
+	}
+
+	for _, tt := range tests {
+		vs := nn.NewVarStore(gotch.CPU)
+		opts := append([]nn.Conv1DConfigOpt{nn.WithWsInit1D(nn.NewConstInit(1)), nn.WithBias1D(false)}, tt.opts...)
+		conv := nn.NewConv1D(vs.Root(), 1, 1, tt.k, nn.NewConv1DConfig(opts...))
+		assertValues(t, tt.name, conv.Forward(x), tt.want)
+	}
+}
+
+func TestConv2D_KernelSize(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	cfg := nn.NewConv2DConfig(nn.WithWsInit2D(nn.NewConstInit(1)), nn.WithBias2D(false), nn.WithPaddingPreset2D("same"))
+	conv := nn.NewConv2DWithKernelSize(vs.Root(), 1, 1, []int64{1, 3}, cfg)
+	assertShape(t, "weight", conv.Ws, []int64{1, 1, 1, 3})
+
+	x := float32TensorOf([]float32{1, 2, 3, 4, 5, 6}, 1, 1, 2, 3)
+	out := conv.Forward(x)
+	assertShape(t, "Conv2D same", out, []int64{1, 1, 2, 3})
+	assertValues(t, "Conv2D same", out, []float64{3, 6, 5, 9, 15, 11})
+
+	// Asymmetric padding: [top, bottom, left, right].
+	cfg = nn.NewConv2DConfig(nn.WithWsInit2D(nn.NewConstInit(1)), nn.WithBias2D(false), nn.WithPaddings2D(1, 0, 0, 2))
+	conv = nn.NewConv2DWithKernelSize(vs.Root().Sub("asym"), 1, 1, []int64{2, 2}, cfg)
+	assertShape(t, "Conv2D asymmetric", conv.Forward(x), []int64{1, 1, 2, 4})
+}
@@ -0,0 +1,675 @@
+package nn
+
+// Optimizer algorithms implemented in Go. Update rules follow Pytorch `torch.optim`.
+
+import (
+	"math"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// addScaled_ computes dst += alpha * src in-place.
+func addScaled_(dst, src *ts.Tensor, alpha float64) {
+	x := src.MustMulScalar(ts.FloatScalar(alpha), false)
+	dst.MustAdd_(x)
+	x.MustDrop()
+}
+
+// l2Grad returns gradient with L2 penalty (grad + wd * param) added.
+func l2Grad(param, grad *ts.Tensor, wd float64) *ts.Tensor {
+	if wd == 0 {
+		return grad.MustShallowClone()
+	}
+	g := param.MustMulScalar(ts.FloatScalar(wd), false)
+	g.MustAdd_(grad)
+
+	return g
+}
+
+// ema_ updates moving average in-place: x = beta * x + (1 - beta) * v
+func ema_(x, v *ts.Tensor, beta float64) {
+	x.MustLerp_(v, ts.FloatScalar(1-beta))
+}
+
+// emaSquare_ updates moving average of squared values in-place: x = beta * x + (1 - beta) * v^2
+func emaSquare_(x, v *ts.Tensor, beta float64) {
+	x.MustMulScalar_(ts.FloatScalar(beta))
+	v2 := v.MustSquare(false)
+	addScaled_(x, v2, 1-beta)
+	v2.MustDrop()
+}
+
+// zerosState creates zero-initialized state tensors of the same shape as param.
+func zerosState(param *ts.Tensor, names ...string) (map[string]*ts.Tensor, error) {
+	state := make(map[string]*ts.Tensor, len(names))
+	for _, name := range names {
+		x, err := param.ZerosLike(false)
+		if err != nil {
+			dropState(state)
+			return nil, err
+		}
+		state[name] = x
+	}
+
+	return state, nil
+}
+
+// Adagrad optimizer:
+// ==================
+
+// AdagradConfig holds parameters for building the Adagrad optimizer.
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.optim.Adagrad.html
+type AdagradConfig struct {
+	LRDecay                 float64
+	Wd                      float64
+	InitialAccumulatorValue float64
+	Eps                     float64
+}
+
+// DefaultAdagradConfig creates AdagradConfig with default values.
+func DefaultAdagradConfig() *AdagradConfig {
+	return &AdagradConfig{
+		LRDecay:                 0.0,
+		Wd:                      0.0,
+		InitialAccumulatorValue: 0.0,
+		Eps:                     1e-10,
+	}
+}
+
+// NewAdagradConfig creates AdagradConfig with specified values.
+func NewAdagradConfig(lrDecay, wd, initialAccumulatorValue, eps float64) *AdagradConfig {
+	return &AdagradConfig{
+		LRDecay:                 lrDecay,
+		Wd:                      wd,
+		InitialAccumulatorValue: initialAccumulatorValue,
+		Eps:                     eps,
+	}
+}
+
+// Implement OptimizerConfig interface for AdagradConfig
+func (c *AdagradConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c, lr), nil
+}
+
+func (c *AdagradConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// Implement GoOptimizerAlgorithm interface for AdagradConfig
+func (c *AdagradConfig) HyperParams() map[string]float64 {
+	return map[string]float64{
+		"lr_decay":                  c.LRDecay,
+		"weight_decay":              c.Wd,
+		"initial_accumulator_value": c.InitialAccumulatorValue,
+		"eps":                       c.Eps,
+	}
+}
+
+func (c *AdagradConfig) StateNames() []string {
+	return []string{"sum"}
+}
+
+func (c *AdagradConfig) InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error) {
+	sum, err := param.FullLike(ts.FloatScalar(group["initial_accumulator_value"]), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]*ts.Tensor{"sum": sum}, nil
+}
+
+func (c *AdagradConfig) Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error {
+	g := l2Grad(param, grad, group["weight_decay"])
+	defer g.MustDrop()
+
+	clr := group["lr"] / (1 + float64(step-1)*group["lr_decay"])
+	sum := state["sum"]
+	sum.MustAddcmul_(g, g)
+
+	std := sum.MustSqrt(false).MustAddScalar(ts.FloatScalar(group["eps"]), true)
+	update := g.MustDiv(std, false)
+	addScaled_(param, update, -clr)
+	std.MustDrop()
+	update.MustDrop()
+
+	return nil
+}
+
+// Adadelta optimizer:
+// ===================
+
+// AdadeltaConfig holds parameters for building the Adadelta optimizer.
+//
+// NOTE. Learning rate scales delta before it is applied. Pytorch default is 1.0.
+// Ref. https://pytorch.org/docs/stable/generated/torch.optim.Adadelta.html
+type AdadeltaConfig struct {
+	Rho float64
+	Eps float64
+	Wd  float64
+}
+
+// DefaultAdadeltaConfig creates AdadeltaConfig with default values.
+func DefaultAdadeltaConfig() *AdadeltaConfig {
+	return &AdadeltaConfig{
+		Rho: 0.9,
+		Eps: 1e-6,
+		Wd:  0.0,
+	}
+}
+
+// NewAdadeltaConfig creates AdadeltaConfig with specified values.
+func NewAdadeltaConfig(rho, eps, wd float64) *AdadeltaConfig {
+	return &AdadeltaConfig{
+		Rho: rho,
+		Eps: eps,
+		Wd:  wd,
+	}
+}
+
+// Implement OptimizerConfig interface for AdadeltaConfig
+func (c *AdadeltaConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c, lr), nil
+}
+
+func (c *AdadeltaConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// Implement GoOptimizerAlgorithm interface for AdadeltaConfig
+func (c *AdadeltaConfig) HyperParams() map[string]float64 {
+	return map[string]float64{
+		"rho":          c.Rho,
+		"eps":          c.Eps,
+		"weight_decay": c.Wd,
+	}
+}
+
+func (c *AdadeltaConfig) StateNames() []string {
+	return []string{"square_avg", "acc_delta"}
+}
+
+func (c *AdadeltaConfig) InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error) {
+	return zerosState(param, c.StateNames()...)
+}
+
+func (c *AdadeltaConfig) Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error {
+	g := l2Grad(param, grad, group["weight_decay"])
+	defer g.MustDrop()
+
+	rho, eps := group["rho"], group["eps"]
+	squareAvg, accDelta := state["square_avg"], state["acc_delta"]
+	emaSquare_(squareAvg, g, rho)
+
+	// delta = sqrt(acc_delta + eps) / sqrt(square_avg + eps) * grad
+	std := squareAvg.MustAddScalar(ts.FloatScalar(eps), false).MustSqrt(true)
+	delta := accDelta.MustAddScalar(ts.FloatScalar(eps), false).MustSqrt(true)
+	delta.MustDiv_(std)
+	delta.MustMul_(g)
+	emaSquare_(accDelta, delta, rho)
+	addScaled_(param, delta, -group["lr"])
+	std.MustDrop()
+	delta.MustDrop()
+
+	return nil
+}
+
+// Adamax optimizer:
+// =================
+
+// AdamaxConfig holds parameters for building the Adamax optimizer (a variant
+// of Adam based on infinity norm).
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.optim.Adamax.html
+type AdamaxConfig struct {
+	Beta1 float64
+	Beta2 float64
+	Eps   float64
+	Wd    float64
+}
+
+// DefaultAdamaxConfig creates AdamaxConfig with default values.
+func DefaultAdamaxConfig() *AdamaxConfig {
+	return &AdamaxConfig{
+		Beta1: 0.9,
+		Beta2: 0.999,
+		Eps:   1e-8,
+		Wd:    0.0,
+	}
+}
+
+// NewAdamaxConfig creates AdamaxConfig with specified values.
+func NewAdamaxConfig(beta1, beta2, eps, wd float64) *AdamaxConfig {
+	return &AdamaxConfig{
+		Beta1: beta1,
+		Beta2: beta2,
+		Eps:   eps,
+		Wd:    wd,
+	}
+}
+
+// Implement OptimizerConfig interface for AdamaxConfig
+func (c *AdamaxConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c, lr), nil
+}
+
+func (c *AdamaxConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// Implement GoOptimizerAlgorithm interface for AdamaxConfig
+func (c *AdamaxConfig) HyperParams() map[string]float64 {
+	return map[string]float64{
+		"beta1":        c.Beta1,
+		"beta2":        c.Beta2,
+		"eps":          c.Eps,
+		"weight_decay": c.Wd,
+	}
+}
+
+func (c *AdamaxConfig) StateNames() []string {
+	return []string{"exp_avg", "exp_inf"}
+}
+
+func (c *AdamaxConfig) InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error) {
+	return zerosState(param, c.StateNames()...)
+}
+
+func (c *AdamaxConfig) Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error {
+	g := l2Grad(param, grad, group["weight_decay"])
+	defer g.MustDrop()
+
+	beta1, beta2 := group["beta1"], group["beta2"]
+	expAvg, expInf := state["exp_avg"], state["exp_inf"]
+	ema_(expAvg, g, beta1)
+
+	// exp_inf = max(beta2 * exp_inf, |grad| + eps)
+	expInf.MustMulScalar_(ts.FloatScalar(beta2))
+	absGrad := g.MustAbs(false).MustAddScalar(ts.FloatScalar(group["eps"]), true)
+	maxInf := expInf.MustMaximum(absGrad, false)
+	expInf.Copy_(maxInf)
+	absGrad.MustDrop()
+	maxInf.MustDrop()
+
+	clr := group["lr"] / (1 - math.Pow(beta1, float64(step)))
+	update := expAvg.MustDiv(expInf, false)
+	addScaled_(param, update, -clr)
+	update.MustDrop()
+
+	return nil
+}
+
+// NAdam optimizer:
+// ================
+
+// NAdamConfig holds parameters for building the NAdam optimizer (Adam with
+// Nesterov momentum).
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.optim.NAdam.html
+type NAdamConfig struct {
+	Beta1         float64
+	Beta2         float64
+	Eps           float64
+	Wd            float64
+	MomentumDecay float64
+}
+
+// DefaultNAdamConfig creates NAdamConfig with default values.
+func DefaultNAdamConfig() *NAdamConfig {
+	return &NAdamConfig{
+		Beta1:         0.9,
+		Beta2:         0.999,
+		Eps:           1e-8,
+		Wd:            0.0,
+		MomentumDecay: 4e-3,
+	}
+}
+
+// NewNAdamConfig creates NAdamConfig with specified values.
+func NewNAdamConfig(beta1, beta2, eps, wd, momentumDecay float64) *NAdamConfig {
+	return &NAdamConfig{
+		Beta1:         beta1,
+		Beta2:         beta2,
+		Eps:           eps,
+		Wd:            wd,
+		MomentumDecay: momentumDecay,
+	}
+}
+
+// Implement OptimizerConfig interface for NAdamConfig
+func (c *NAdamConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c, lr), nil
+}
+
+func (c *NAdamConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// Implement GoOptimizerAlgorithm interface for NAdamConfig
+func (c *NAdamConfig) HyperParams() map[string]float64 {
+	return map[string]float64{
+		"beta1":          c.Beta1,
+		"beta2":          c.Beta2,
+		"eps":            c.Eps,
+		"weight_decay":   c.Wd,
+		"momentum_decay": c.MomentumDecay,
+	}
+}
+
+func (c *NAdamConfig) StateNames() []string {
+	return []string{"exp_avg", "exp_avg_sq", "mu_product"}
+}
+
+func (c *NAdamConfig) InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error) {
+	state, err := zerosState(param, "exp_avg", "exp_avg_sq")
+	if err != nil {
+		return nil, err
+	}
+	muProduct, err := ts.OfSlice([]float64{1.0})
+	if err != nil {
+		dropState(state)
+		return nil, err
+	}
+	state["mu_product"] = muProduct
+
+	return state, nil
+}
+
+func (c *NAdamConfig) Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error {
+	g := l2Grad(param, grad, group["weight_decay"])
+	defer g.MustDrop()
+
+	lr, beta1, beta2, eps := group["lr"], group["beta1"], group["beta2"], group["eps"]
+	momentumDecay := group["momentum_decay"]
+	expAvg, expAvgSq := state["exp_avg"], state["exp_avg_sq"]
+
+	t := float64(step)
+	biasCorrection2 := 1 - math.Pow(beta2, t)
+	mu := beta1 * (1 - 0.5*math.Pow(0.96, t*momentumDecay))
+	muNext := beta1 * (1 - 0.5*math.Pow(0.96, (t+1)*momentumDecay))
+	muProduct := state["mu_product"].Float64Values()[0] * mu
+	state["mu_product"].MustFill_(ts.FloatScalar(muProduct))
+
+	ema_(expAvg, g, beta1)
+	emaSquare_(expAvgSq, g, beta2)
+
+	// denom = sqrt(exp_avg_sq / bias_correction2) + eps
+	denom := expAvgSq.MustDivScalar(ts.FloatScalar(biasCorrection2), false).MustSqrt(true)
+	denom.MustAddScalar_(ts.FloatScalar(eps))
+
+	gradUpdate := g.MustDiv(denom, false)
+	addScaled_(param, gradUpdate, -lr*(1-mu)/(1-muProduct))
+	avgUpdate := expAvg.MustDiv(denom, false)
+	addScaled_(param, avgUpdate, -lr*muNext/(1-muProduct*muNext))
+	denom.MustDrop()
+	gradUpdate.MustDrop()
+	avgUpdate.MustDrop()
+
+	return nil
+}
+
+// RAdam optimizer:
+// ================
+
+// RAdamConfig holds parameters for building the RAdam optimizer (Rectified Adam).
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.optim.RAdam.html
+type RAdamConfig struct {
+	Beta1 float64
+	Beta2 float64
+	Eps   float64
+	Wd    float64
+}
+
+// DefaultRAdamConfig creates RAdamConfig with default values.
+func DefaultRAdamConfig() *RAdamConfig {
+	return &RAdamConfig{
+		Beta1: 0.9,
+		Beta2: 0.999,
+		Eps:   1e-8,
+		Wd:    0.0,
+	}
+}
+
+// NewRAdamConfig creates RAdamConfig with specified values.
+func NewRAdamConfig(beta1, beta2, eps, wd float64) *RAdamConfig {
+	return &RAdamConfig{
+		Beta1: beta1,
+		Beta2: beta2,
+		Eps:   eps,
+		Wd:    wd,
+	}
+}
+
+// Implement OptimizerConfig interface for RAdamConfig
+func (c *RAdamConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c, lr), nil
+}
+
+func (c *RAdamConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// Implement GoOptimizerAlgorithm interface for RAdamConfig
+func (c *RAdamConfig) HyperParams() map[string]float64 {
+	return map[string]float64{
+		"beta1":        c.Beta1,
+		"beta2":        c.Beta2,
+		"eps":          c.Eps,
+		"weight_decay": c.Wd,
+	}
+}
+
+func (c *RAdamConfig) StateNames() []string {
+	return []string{"exp_avg", "exp_avg_sq"}
+}
+
+func (c *RAdamConfig) InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error) {
+	return zerosState(param, c.StateNames()...)
+}
+
+func (c *RAdamConfig) Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error {
+	g := l2Grad(param, grad, group["weight_decay"])
+	defer g.MustDrop()
+
+	lr, beta1, beta2, eps := group["lr"], group["beta1"], group["beta2"], group["eps"]
+	expAvg, expAvgSq := state["exp_avg"], state["exp_avg_sq"]
+	ema_(expAvg, g, beta1)
+	emaSquare_(expAvgSq, g, beta2)
+
+	t := float64(step)
+	biasCorrection1 := 1 - math.Pow(beta1, t)
+	biasCorrection2 := 1 - math.Pow(beta2, t)
+
+	// Maximum length of the approximated SMA and its length at step t.
+	rhoInf := 2/(1-beta2) - 1
+	rhoT := rhoInf - 2*t*math.Pow(beta2, t)/biasCorrection2
+
+	if rhoT <= 5 {
+		// Variance is not tractable: un-adapted momentum update.
+		addScaled_(param, expAvg, -lr/biasCorrection1)
+		return nil
+	}
+
+	rect := math.Sqrt((rhoT - 4) * (rhoT - 2) * rhoInf / ((rhoInf - 4) * (rhoInf - 2) * rhoT))
+	// adaptive lr = sqrt(bias_correction2) / (sqrt(exp_avg_sq) + eps)
+	denom := expAvgSq.MustSqrt(false).MustAddScalar(ts.FloatScalar(eps), true)
+	update := expAvg.MustDiv(denom, false)
+	addScaled_(param, update, -lr*rect*math.Sqrt(biasCorrection2)/biasCorrection1)
+	denom.MustDrop()
+	update.MustDrop()
+
+	return nil
+}
+
+// LAMB optimizer:
+// ===============
+
+// LAMBConfig holds parameters for building the LAMB optimizer (Layer-wise
+// Adaptive Moments for Batch training). Weight decay is decoupled as in AdamW
+// and the update is scaled by trust ratio ||param|| / ||update||.
+//
+// Ref. https://arxiv.org/abs/1904.00962
+type LAMBConfig struct {
+	Beta1 float64
+	Beta2 float64
+	Eps   float64
+	Wd    float64
+}
+
+// DefaultLAMBConfig creates LAMBConfig with default values.
+func DefaultLAMBConfig() *LAMBConfig {
+	return &LAMBConfig{
+		Beta1: 0.9,
+		Beta2: 0.999,
+		Eps:   1e-6,
+		Wd:    0.0,
+	}
+}
+
+// NewLAMBConfig creates LAMBConfig with specified values.
+func NewLAMBConfig(beta1, beta2, eps, wd float64) *LAMBConfig {
+	return &LAMBConfig{
+		Beta1: beta1,
+		Beta2: beta2,
+		Eps:   eps,
+		Wd:    wd,
+	}
+}
+
+// Implement OptimizerConfig interface for LAMBConfig
+func (c *LAMBConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c, lr), nil
+}
+
+func (c *LAMBConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// Implement GoOptimizerAlgorithm interface for LAMBConfig
+func (c *LAMBConfig) HyperParams() map[string]float64 {
+	return map[string]float64{
+		"beta1":        c.Beta1,
+		"beta2":        c.Beta2,
+		"eps":          c.Eps,
+		"weight_decay": c.Wd,
+	}
+}
+
+func (c *LAMBConfig) StateNames() []string {
+	return []string{"exp_avg", "exp_avg_sq"}
+}
+
+func (c *LAMBConfig) InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error) {
+	return zerosState(param, c.StateNames()...)
+}
+
+func (c *LAMBConfig) Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error {
+	lr, beta1, beta2, eps, wd := group["lr"], group["beta1"], group["beta2"], group["eps"], group["weight_decay"]
+	expAvg, expAvgSq := state["exp_avg"], state["exp_avg_sq"]
+	ema_(expAvg, grad, beta1)
+	emaSquare_(expAvgSq, grad, beta2)
+
+	t := float64(step)
+	biasCorrection1 := 1 - math.Pow(beta1, t)
+	biasCorrection2 := 1 - math.Pow(beta2, t)
+
+	// update = m_hat / (sqrt(v_hat) + eps) + wd * param
+	denom := expAvgSq.MustDivScalar(ts.FloatScalar(biasCorrection2), false).MustSqrt(true)
+	denom.MustAddScalar_(ts.FloatScalar(eps))
+	update := expAvg.MustDivScalar(ts.FloatScalar(biasCorrection1), false)
+	update.MustDiv_(denom)
+	if wd != 0 {
+		addScaled_(update, param, wd)
+	}
+
+	paramNorm := param.MustNorm(false).Float64Values(true)[0]
+	updateNorm := update.MustNorm(false).Float64Values(true)[0]
+	trustRatio := 1.0
+	if paramNorm > 0 && updateNorm > 0 {
+		trustRatio = paramNorm / updateNorm
+	}
+	addScaled_(param, update, -lr*trustRatio)
+	denom.MustDrop()
+	update.MustDrop()
+
+	return nil
+}
+
+// Lion optimizer:
+// ===============
+
+// LionConfig holds parameters for building the Lion optimizer (EvoLved Sign
+// Momentum). Weight decay is decoupled.
+//
+// NOTE. Lion usually needs a learning rate 3-10x smaller than AdamW.
+// Ref. https://arxiv.org/abs/2302.06675
+type LionConfig struct {
+	Beta1 float64
+	Beta2 float64
+	Wd    float64
+}
+
+// DefaultLionConfig creates LionConfig with default values.
+func DefaultLionConfig() *LionConfig {
+	return &LionConfig{
+		Beta1: 0.9,
+		Beta2: 0.99,
+		Wd:    0.0,
+	}
+}
+
+// NewLionConfig creates LionConfig with specified values.
+func NewLionConfig(beta1, beta2, wd float64) *LionConfig {
+	return &LionConfig{
+		Beta1: beta1,
+		Beta2: beta2,
+		Wd:    wd,
+	}
+}
+
+// Implement OptimizerConfig interface for LionConfig
+func (c *LionConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c, lr), nil
+}
+
+func (c *LionConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// Implement GoOptimizerAlgorithm interface for LionConfig
+func (c *LionConfig) HyperParams() map[string]float64 {
+	return map[string]float64{
+		"beta1":        c.Beta1,
+		"beta2":        c.Beta2,
+		"weight_decay": c.Wd,
+	}
+}
+
+func (c *LionConfig) StateNames() []string {
+	return []string{"exp_avg"}
+}
+
+func (c *LionConfig) InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error) {
+	return zerosState(param, c.StateNames()...)
+}
+
+func (c *LionConfig) Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error {
+	lr, beta1, beta2, wd := group["lr"], group["beta1"], group["beta2"], group["weight_decay"]
+	expAvg := state["exp_avg"]
+
+	if wd != 0 {
+		param.MustMulScalar_(ts.FloatScalar(1 - lr*wd))
+	}
+
+	// update = sign(beta1 * exp_avg + (1 - beta1) * grad)
+	update := expAvg.MustMulScalar(ts.FloatScalar(beta1), false)
+	addScaled_(update, grad, 1-beta1)
+	update.MustSign_()
+	addScaled_(param, update, -lr)
+	update.MustDrop()
+
+	ema_(expAvg, grad, beta2)
+
+	return nil
+}
@@ -0,0 +1,450 @@
+package nn
+
+// Optimizers implemented in Go on top of tensor operations.
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// GoOptimizerAlgorithm defines update rule of an optimizer implemented in Go.
+//
+// Custom optimizers can be built by implementing this interface and wrapping
+// it in `GoOptimizerConfig`.
+type GoOptimizerAlgorithm interface {
+	// HyperParams returns default hyperparameters (except learning rate "lr")
+	// for all parameter groups. E.g. {"beta1": 0.9, "weight_decay": 0}.
+	HyperParams() map[string]float64
+
+	// StateNames returns names of per-parameter state tensors.
+	StateNames() []string
+
+	// InitState creates initial state tensors of a parameter keyed by state names.
+	InitState(param *ts.Tensor, group map[string]float64) (map[string]*ts.Tensor, error)
+
+	// Update updates parameter in-place given its gradient and state.
+	//
+	// Step is the number of updates of the parameter including this one (1-based)
+	// and group holds hyperparameters of the parameter group including "lr".
+	// It is called in no-grad mode.
+	Update(param, grad *ts.Tensor, state map[string]*ts.Tensor, step int64, group map[string]float64) error
+}
+
+// goParam is a parameter tracked by GoOptimizer.
+type goParam struct {
+	tensor *ts.Tensor
+	state  map[string]*ts.Tensor // nil if not initialized yet
+	step   int64
+}
+
+// goParamGroup is a group of parameters sharing the same hyperparameters.
+type goParamGroup struct {
+	params      []*goParam
+	hyperParams map[string]float64
+}
+
+// GoOptimizer is an optimizer implemented in Go. It supports per parameter
+// group hyperparameters and keeps per-parameter state as named tensors.
+type GoOptimizer struct {
+	algo   GoOptimizerAlgorithm
+	lr     float64
+	groups []*goParamGroup
+	params map[*ts.Tensor]*goParam
+}
+
+// NewGoOptimizer creates a new GoOptimizer with a given algorithm and learning rate.
+func NewGoOptimizer(algo GoOptimizerAlgorithm, lr float64) *GoOptimizer {
+	return &GoOptimizer{
+		algo:   algo,
+		lr:     lr,
+		groups: nil,
+		params: make(map[*ts.Tensor]*goParam),
+	}
+}
+
+func (o *GoOptimizer) newGroup() *goParamGroup {
+	hyperParams := map[string]float64{"lr": o.lr}
+	for k, v := range o.algo.HyperParams() {
+		hyperParams[k] = v
+	}
+
+	return &goParamGroup{hyperParams: hyperParams}
+}
+
+// AddParameter adds a parameter to a parameter group. Missing groups are
+// created with default hyperparameters.
+func (o *GoOptimizer) AddParameter(param *ts.Tensor, group uint) error {
+	if _, ok := o.params[param]; ok {
+		return nil
+	}
+	for uint(len(o.groups)) <= group {
+		o.groups = append(o.groups, o.newGroup())
+	}
+
+	p := &goParam{tensor: param}
+	o.groups[group].params = append(o.groups[group].params, p)
+	o.params[param] = p
+
+	return nil
+}
+
+// AddParamGroup adds a new parameter group with default hyperparameters.
+func (o *GoOptimizer) AddParamGroup(tensors []*ts.Tensor) error {
+	o.groups = append(o.groups, o.newGroup())
+	group := uint(len(o.groups) - 1)
+	for _, x := range tensors {
+		if err := o.AddParameter(x, group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParamGroupNum returns number of parameter groups.
+func (o *GoOptimizer) ParamGroupNum() (int64, error) {
+	return int64(len(o.groups)), nil
+}
+
+// SetLearningRate sets learning rate for all parameter groups.
+func (o *GoOptimizer) SetLearningRate(lr float64) error {
+	o.lr = lr
+	for _, g := range o.groups {
+		g.hyperParams["lr"] = lr
+	}
+
+	return nil
+}
+
+// GetLearningRates returns learning rates of all parameter groups.
+func (o *GoOptimizer) GetLearningRates() ([]float64, error) {
+	lrs := make([]float64, len(o.groups))
+	for i, g := range o.groups {
+		lrs[i] = g.hyperParams["lr"]
+	}
+
+	return lrs, nil
+}
+
+// SetLearningRates sets learning rates for all parameter groups respectively.
+func (o *GoOptimizer) SetLearningRates(lrs []float64) error {
+	if len(lrs) != len(o.groups) {
+		err := fmt.Errorf("GoOptimizer.SetLearningRates() failed: expected %v learning rates, got %v", len(o.groups), len(lrs))
+		return err
+	}
+	for i, g := range o.groups {
+		g.hyperParams["lr"] = lrs[i]
+	}
+
+	return nil
+}
+
+// SetMomentum sets "momentum" hyperparameter (or "beta1" for Adam-like
+// optimizers) for all parameter groups.
+func (o *GoOptimizer) SetMomentum(m float64) error {
+	defaults := o.algo.HyperParams()
+	var key string
+	switch {
+	case hasKey(defaults, "momentum"):
+		key = "momentum"
+	case hasKey(defaults, "beta1"):
+		key = "beta1"
+	default:
+		err := fmt.Errorf("GoOptimizer.SetMomentum() failed: optimizer %T has no momentum", o.algo)
+		return err
+	}
+	for _, g := range o.groups {
+		g.hyperParams[key] = m
+	}
+
+	return nil
+}
+
+func hasKey(m map[string]float64, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// SetHyperParam sets a hyperparameter of a parameter group.
+func (o *GoOptimizer) SetHyperParam(group int, name string, value float64) error {
+	if group < 0 || group >= len(o.groups) {
+		err := fmt.Errorf("GoOptimizer.SetHyperParam() failed: invalid param group %v (number of groups: %v)", group, len(o.groups))
+		return err
+	}
+	if name != "lr" && !hasKey(o.algo.HyperParams(), name) {
+		err := fmt.Errorf("GoOptimizer.SetHyperParam() failed: unknown hyperparameter %q for %T", name, o.algo)
+		return err
+	}
+	o.groups[group].hyperParams[name] = value
+
+	return nil
+}
+
+// ParamGroups returns a copy of hyperparameters of all parameter groups.
+func (o *GoOptimizer) ParamGroups() []map[string]float64 {
+	groups := make([]map[string]float64, len(o.groups))
+	for i, g := range o.groups {
+		group := make(map[string]float64, len(g.hyperParams))
+		for k, v := range g.hyperParams {
+			group[k] = v
+		}
+		groups[i] = group
+	}
+
+	return groups
+}
+
+// SetParamGroups sets hyperparameters of all parameter groups. Hyperparameters
+// missing from input are left unchanged.
+func (o *GoOptimizer) SetParamGroups(groups []map[string]float64) error {
+	if len(groups) != len(o.groups) {
+		err := fmt.Errorf("GoOptimizer.SetParamGroups() failed: expected %v param groups, got %v", len(o.groups), len(groups))
+		return err
+	}
+	for i, group := range groups {
+		keys := make([]string, 0, len(group))
+		for k := range group {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := o.SetHyperParam(i, k, group[k]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ZeroGrad sets gradients of all parameters to zero.
+func (o *GoOptimizer) ZeroGrad() error {
+	for _, g := range o.groups {
+		for _, p := range g.params {
+			p.tensor.ZeroGrad()
+		}
+	}
+
+	return nil
+}
+
+// Step performs an optimization step for parameters having gradients.
+func (o *GoOptimizer) Step() error {
+	var err error
+	ts.NoGrad(func() {
+		for _, g := range o.groups {
+			for _, p := range g.params {
+				if err = o.stepParam(p, g.hyperParams); err != nil {
+					return
+				}
+			}
+		}
+	})
+	if err != nil {
+		err = fmt.Errorf("GoOptimizer.Step() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+func (o *GoOptimizer) stepParam(p *goParam, group map[string]float64) error {
+	grad, err := p.tensor.Grad(false)
+	if err != nil {
+		return err
+	}
+	defer grad.MustDrop()
+	if !grad.MustDefined() {
+		return nil
+	}
+
+	if p.state == nil {
+		var (
+			state map[string]*ts.Tensor
+			err   error
+		)
+		// States live across steps so they're not freed by any `ts.Scope`.
+		ts.WithoutScope(func() {
+			state, err = o.algo.InitState(p.tensor, group)
+		})
+		if err != nil {
+			return err
+		}
+		p.state = state
+	}
+	p.step += 1
+
+	return o.algo.Update(p.tensor, grad, p.state, p.step, group)
+}
+
+// GetState returns copies of state tensors of a parameter in the order of
+// algorithm `StateNames()`. Step is -1 if parameter has no state yet.
+func (o *GoOptimizer) GetState(param *ts.Tensor) ([]*ts.Tensor, int64, error) {
+	p, ok := o.params[param]
+	if !ok || p.state == nil {
+		return nil, -1, nil
+	}
+
+	names := o.algo.StateNames()
+	states := make([]*ts.Tensor, len(names))
+	for i, name := range names {
+		x, ok := p.state[name]
+		if !ok {
+			continue
+		}
+		copied, err := copyTensor(x)
+		if err != nil {
+			for _, s := range states {
+				if s != nil {
+					s.MustDrop()
+				}
+			}
+			return nil, -1, err
+		}
+		states[i] = copied
+	}
+
+	return states, p.step, nil
+}
+
+// SetState sets state tensors of a parameter in the order of algorithm
+// `StateNames()`. Nil states are initialized by algorithm. State tensors are copied.
+func (o *GoOptimizer) SetState(param *ts.Tensor, states []*ts.Tensor, step int64) error {
+	p, ok := o.params[param]
+	if !ok {
+		err := fmt.Errorf("GoOptimizer.SetState() failed: parameter not found in optimizer")
+		return err
+	}
+	names := o.algo.StateNames()
+	if len(states) > len(names) {
+		err := fmt.Errorf("GoOptimizer.SetState() failed: expected at most %v state tensors, got %v", len(names), len(states))
+		return err
+	}
+
+	var (
+		group = o.groupOf(p)
+		state map[string]*ts.Tensor
+		err   error
+	)
+	ts.WithoutScope(func() {
+		state, err = o.setState(param, group, names, states)
+	})
+	if err != nil {
+		err = fmt.Errorf("GoOptimizer.SetState() failed: %w", err)
+		return err
+	}
+
+	dropState(p.state)
+	p.state = state
+	p.step = step
+
+	return nil
+}
+
+// setState initializes state of a parameter with copies of given state tensors.
+func (o *GoOptimizer) setState(param *ts.Tensor, group map[string]float64, names []string, states []*ts.Tensor) (map[string]*ts.Tensor, error) {
+	state, err := o.algo.InitState(param, group)
+	if err != nil {
+		return nil, err
+	}
+	for i, x := range states {
+		if x == nil {
+			continue
+		}
+		if old, ok := state[names[i]]; ok {
+			old.MustDrop()
+		}
+		copied, err := copyTensor(x)
+		if err != nil {
+			dropState(state)
+			return nil, err
+		}
+		state[names[i]] = copied
+	}
+
+	return state, nil
+}
+
+// copyTensor returns a copy of tensor data without autograd history.
+func copyTensor(x *ts.Tensor) (*ts.Tensor, error) {
+	copied, err := x.ZerosLike(false)
+	if err != nil {
+		return nil, err
+	}
+	ts.NoGrad(func() {
+		copied.Copy_(x)
+	})
+
+	return copied, nil
+}
+
+func (o *GoOptimizer) groupOf(p *goParam) map[string]float64 {
+	for _, g := range o.groups {
+		for _, x := range g.params {
+			if x == p {
+				return g.hyperParams
+			}
+		}
+	}
+
+	return o.newGroup().hyperParams
+}
+
+func dropState(state map[string]*ts.Tensor) {
+	for _, x := range state {
+		x.MustDrop()
+	}
+}
+
+// Drop frees up memory of optimizer state.
+func (o *GoOptimizer) Drop() {
+	for _, p := range o.params {
+		dropState(p.state)
+		p.state = nil
+	}
+}
+
+// GoOptimizerConfig builds a GoOptimizer with a custom algorithm.
+type GoOptimizerConfig struct {
+	Algorithm GoOptimizerAlgorithm
+}
+
+// NewGoOptimizerConfig creates a config to build an optimizer with a custom algorithm.
+func NewGoOptimizerConfig(algo GoOptimizerAlgorithm) *GoOptimizerConfig {
+	return &GoOptimizerConfig{
+		Algorithm: algo,
+	}
+}
+
+// Implement OptimizerConfig interface for GoOptimizerConfig
+func (c *GoOptimizerConfig) buildCOpt(lr float64) (optimizerImpl, error) {
+	return NewGoOptimizer(c.Algorithm, lr), nil
+}
+
+// Build builds an optimizer with a custom algorithm.
+func (c *GoOptimizerConfig) Build(vs *VarStore, lr float64) (*Optimizer, error) {
+	return defaultBuild(c, vs, lr)
+}
+
+// SetHyperParam sets a hyperparameter (e.g. "lr", "weight_decay") of a parameter group.
+//
+// NOTE. It is only supported by optimizers implemented in Go (e.g. `AdagradConfig`, `LionConfig`).
+func (opt *Optimizer) SetHyperParam(group int, name string, value float64) error {
+	gopt, ok := opt.opt.(*GoOptimizer)
+	if !ok {
+		err := fmt.Errorf("Optimizer.SetHyperParam() failed: not supported by optimizer config %T", opt.config)
+		return err
+	}
+
+	return gopt.SetHyperParam(group, name, value)
+}
+
+// MustSetHyperParam sets a hyperparameter of a parameter group. It panics if error occurred.
+func (opt *Optimizer) MustSetHyperParam(group int, name string, value float64) {
+	if err := opt.SetHyperParam(group, name, value); err != nil {
+		log.Fatal(err)
+	}
+}
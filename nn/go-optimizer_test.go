@@ -0,0 +1,180 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestGoOptimizers(t *testing.T) {
+	x := ts.MustArangeStart(ts.IntScalar(1), ts.IntScalar(15), gotch.Float, gotch.CPU).MustView([]int64{-1, 1}, true)
+	y := x.MustMulScalar(ts.FloatScalar(0.42), false).MustAddScalar(ts.FloatScalar(1.337), false)
+
+	tests := []struct {
+		name   string
+		config nn.OptimizerConfig
+		lr     float64
+	}{
+		{"Adagrad", nn.DefaultAdagradConfig(), 0.5},
+		{"Adadelta", nn.DefaultAdadeltaConfig(), 1.0},
+		{"Adamax", nn.DefaultAdamaxConfig(), 0.05},
+		{"NAdam", nn.DefaultNAdamConfig(), 0.05},
+		{"RAdam", nn.DefaultRAdamConfig(), 0.05},
+		{"LAMB", nn.DefaultLAMBConfig(), 0.05},
+		{"Lion", nn.DefaultLionConfig(), 0.01},
+	}
+
+	for _, tt := range tests {
+		vs := nn.NewVarStore(gotch.CPU)
+		cfg := &nn.LinearConfig{
+			WsInit: nn.NewConstInit(0.1),
+			BsInit: nn.NewConstInit(0.1),
+			Bias:   true,
+		}
+		model := nn.NewLinear(vs.Root(), 1, 1, cfg)
+		opt, err := tt.config.Build(vs, tt.lr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		initialLoss := x.Apply(model).MustMseLoss(y, 1, true).Float64Values(true)[0]
+		for i := 0; i < 200; i++ {
+			loss := model.Forward(x).MustMseLoss(y, 1, true)
+			opt.MustZeroGrad()
+			loss.MustBackward()
+			opt.MustStep()
+			loss.MustDrop()
+		}
+		finalLoss := x.Apply(model).MustMseLoss(y, 1, true).Float64Values(true)[0]
+		if math.IsNaN(finalLoss) || finalLoss > initialLoss*0.1 {
+			t.Errorf("%s: want loss reduced from %v, got %v", tt.name, initialLoss, finalLoss)
+		}
+	}
+}
+
+// One step on loss = w^2 from w = 1 (grad = 2).
+func TestGoOptimizers_FirstStep(t *testing.T) {
+	tests := []struct {
+		name   string
+		config nn.OptimizerConfig
+		want   float64
+	}{
+		// sum = 4; w = 1 - 0.1 * 2 / sqrt(4)
+		{"Adagrad", nn.DefaultAdagradConfig(), 0.9},
+		// w = 1 - 0.1 * sign(0.1 * 2)
+		{"Lion", nn.DefaultLionConfig(), 0.9},
+		// exp_avg = 0.2; exp_inf = 2; w = 1 - 0.1 / (1 - 0.9) * 0.2 / 2
+		{"Adamax", nn.NewAdamaxConfig(0.9, 0.999, 0, 0), 0.9},
+		// rho_t <= 5: w = 1 - 0.1 * exp_avg / (1 - 0.9)
+		{"RAdam", nn.DefaultRAdamConfig(), 0.8},
+	}
+
+	for _, tt := range tests {
+		vs := nn.NewVarStore(gotch.CPU)
+		w := vs.Root().MustOnes("w", []int64{1})
+		opt, err := tt.config.Build(vs, 0.1)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		loss := w.MustSquare(false).MustSum(gotch.Float, true)
+		opt.MustZeroGrad()
+		loss.MustBackward()
+		opt.MustStep()
+
+		got := w.Float64Values()[0]
+		if math.Abs(got-tt.want) > 1e-5 {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestGoOptimizer_HyperParams(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	w1 := vs.Root().MustOnes("w1", []int64{1})
+	p := vs.Root().Sub("g1")
+	p.SetGroup(1)
+	w2 := p.MustOnes("w2", []int64{1})
+
+	opt, err := nn.DefaultLionConfig().Build(vs, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := opt.ParamGroupNum(); n != 2 {
+		t.Fatalf("want 2 param groups, got %v", n)
+	}
+	opt.MustSetHyperParam(1, "lr", 0.2)
+	if err := opt.SetHyperParam(0, "foo", 1); err == nil {
+		t.Errorf("want error for unknown hyperparameter")
+	}
+
+	loss := w1.MustAdd(w2, false).MustSum(gotch.Float, true)
+	opt.MustZeroGrad()
+	loss.MustBackward()
+	opt.MustStep()
+
+	if got := w1.Float64Values()[0]; math.Abs(got-0.9) > 1e-6 {
+		t.Errorf("want w1 0.9, got %v", got)
+	}
+	if got := w2.Float64Values()[0]; math.Abs(got-0.8) > 1e-6 {
+		t.Errorf("want w2 0.8, got %v", got)
+	}
+}
+
+func TestGoOptimizer_StateDict(t *testing.T) {
+	x := ts.MustRandn([]int64{8, 3}, gotch.Float, gotch.CPU)
+	y := ts.MustRandn([]int64{8, 1}, gotch.Float, gotch.CPU)
+
+	build := func() (*nn.VarStore, *nn.Linear, *nn.Optimizer) {
+		vs := nn.NewVarStore(gotch.CPU)
+		model := nn.NewLinear(vs.Root(), 3, 1, nn.DefaultLinearConfig())
+		opt, err := nn.DefaultNAdamConfig().Build(vs, 0.01)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vs, model, opt
+	}
+	step := func(model *nn.Linear, opt *nn.Optimizer) {
+		loss := model.Forward(x).MustMseLoss(y, 1, true)
+		opt.MustZeroGrad()
+		loss.MustBackward()
+		opt.MustStep()
+		loss.MustDrop()
+	}
+
+	vs1, model1, opt1 := build()
+	for i := 0; i < 3; i++ {
+		step(model1, opt1)
+	}
+	opt1.MustSetHyperParam(0, "weight_decay", 0.01)
+
+	sd := opt1.MustStateDict()
+	defer sd.Drop()
+	for _, name := range []string{"weight.exp_avg", "weight.exp_avg_sq", "weight.mu_product", "weight.step"} {
+		if _, ok := sd.State[name]; !ok {
+			t.Errorf("missing state %q", name)
+		}
+	}
+	if got := sd.ParamGroups[0]["weight_decay"]; got != 0.01 {
+		t.Errorf("want weight_decay 0.01, got %v", got)
+	}
+
+	vs2, model2, opt2 := build()
+	if err := vs2.Copy(vs1); err != nil {
+		t.Fatal(err)
+	}
+	opt2.MustLoadStateDict(sd)
+
+	step(model1, opt1)
+	step(model2, opt2)
+	w1, w2 := model1.Ws.Float64Values(), model2.Ws.Float64Values()
+	for i := range w1 {
+		if math.Abs(w1[i]-w2[i]) > 1e-6 {
+			t.Errorf("want same weights after step, got %v and %v", w1, w2)
+			break
+		}
+	}
+}
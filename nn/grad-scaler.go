@@ -0,0 +1,295 @@
+package nn
+
+// Gradient scaling for mixed precision training.
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+// GradScalerConfig holds parameters of a GradScaler.
+type GradScalerConfig struct {
+	InitScale      float64 // initial scale factor
+	GrowthFactor   float64 // factor the scale is multiplied by after GrowthInterval steps without inf/NaN gradients
+	BackoffFactor  float64 // factor the scale is multiplied by when inf/NaN gradients are found
+	GrowthInterval int     // number of consecutive steps without inf/NaN gradients before growing the scale
+	Enabled        bool    // if false, GradScaler methods are pass-through
+}
+
+// DefaultGradScalerConfig creates GradScalerConfig with default values.
+func DefaultGradScalerConfig() *GradScalerConfig {
+	return &GradScalerConfig{
+		InitScale:      65536.0,
+		GrowthFactor:   2.0,
+		BackoffFactor:  0.5,
+		GrowthInterval: 2000,
+		Enabled:        true,
+	}
+}
+
+// scalerOptState is per-optimizer state of a GradScaler between two updates.
+type scalerOptState struct {
+	unscaled bool
+	foundInf bool
+}
+
+// GradScaler scales losses to prevent gradients in reduced precision (e.g. float16)
+// from underflowing. It unscales gradients before optimizer steps, skips steps if
+// gradients contain inf or NaN values and adjusts the scale factor dynamically.
+//
+// Example:
+//
+//	scaler := nn.NewGradScaler(nn.DefaultGradScalerConfig())
+//	for _, batch := range batches {
+//		var loss *ts.Tensor
+//		ts.MustAutocast(gotch.Half, func() {
+//			loss = model.ForwardT(batch.Images, true).CrossEntropyForLogits(batch.Labels)
+//		})
+//		opt.MustZeroGrad()
+//		scaler.Scale(loss).MustBackward()
+//		scaler.MustUnscale(opt) // optional, i.e. to clip unscaled gradients.
+//		opt.ClipGradValue(1.0)
+//		scaler.MustStep(opt)
+//		scaler.Update()
+//	}
+type GradScaler struct {
+	config        *GradScalerConfig
+	scale         float64
+	growthTracker int
+	states        map[*Optimizer]*scalerOptState
+}
+
+// NewGradScaler creates a new GradScaler.
+func NewGradScaler(c *GradScalerConfig) *GradScaler {
+	return &GradScaler{
+		config: c,
+		scale:  c.InitScale,
+		states: make(map[*Optimizer]*scalerOptState),
+	}
+}
+
+// IsEnabled returns whether the scaler is enabled.
+func (s *GradScaler) IsEnabled() bool {
+	return s.config.Enabled
+}
+
+// GetScale returns current scale factor. It returns 1.0 if the scaler is disabled.
+func (s *GradScaler) GetScale() float64 {
+	if !s.config.Enabled {
+		return 1.0
+	}
+
+	return s.scale
+}
+
+// Scale multiplies the loss by the current scale factor. Backward pass
+// should be run on the returned tensor.
+//
+// If the scaler is disabled, the input loss is returned.
+func (s *GradScaler) Scale(loss *ts.Tensor) *ts.Tensor {
+	if !s.config.Enabled {
+		return loss
+	}
+
+	return loss.MustMulScalar(ts.FloatScalar(s.scale), false)
+}
+
+func (s *GradScaler) state(opt *Optimizer) *scalerOptState {
+	st, ok := s.states[opt]
+	if !ok {
+		st = new(scalerOptState)
+		s.states[opt] = st
+	}
+
+	return st
+}
+
+// Unscale divides gradients of variables trained by the optimizer by the scale
+// factor in-place and records whether they contain inf or NaN values.
+//
+// Calling Unscale explicitly is only needed to inspect or modify gradients
+// (e.g. clipping) between backward pass and `GradScaler.Step()`. It can be
+// called once per optimizer between two `GradScaler.Update()` calls.
+func (s *GradScaler) Unscale(opt *Optimizer) error {
+	if !s.config.Enabled {
+		return nil
+	}
+
+	st := s.state(opt)
+	if st.unscaled {
+		err := fmt.Errorf("GradScaler.Unscale() failed: Unscale() has already been called on this optimizer since the last Update()")
+		return err
+	}
+
+	invScale := 1.0 / s.scale
+	foundInfs := make(map[gotch.Device]*ts.Tensor)
+	invScales := make(map[gotch.Device]*ts.Tensor)
+	defer func() {
+		for d := range foundInfs {
+			foundInfs[d].MustDrop()
+			invScales[d].MustDrop()
+		}
+	}()
+
+	for _, x := range opt.varstore.TrainableVariables() {
+		grad := x.MustGrad(false)
+		if !grad.MustDefined() {
+			grad.MustDrop()
+			continue
+		}
+
+		device := grad.MustDevice()
+		if !device.IsCuda() {
+			// libtorch fused unscale kernel is CUDA only.
+			finite := grad.MustIsfinite(false).MustAll(true).MustTotype(gotch.Float, true)
+			if finite.Float64Values(true)[0] == 0 {
+				st.foundInf = true
+			}
+			grad.MustMulScalar_(ts.FloatScalar(invScale))
+			grad.MustDrop()
+			continue
+		}
+
+		if _, ok := foundInfs[device]; !ok {
+			foundInfs[device] = ts.MustZeros([]int64{1}, gotch.Float, device)
+			invScales[device] = ts.MustFull([]int64{1}, ts.FloatScalar(invScale), gotch.Float, device)
+		}
+		err := grad.AmpNonFiniteCheckAndUnscale_(foundInfs[device], invScales[device])
+		grad.MustDrop()
+		if err != nil {
+			err = fmt.Errorf("GradScaler.Unscale() failed: %w", err)
+			return err
+		}
+	}
+
+	for _, foundInf := range foundInfs {
+		if foundInf.Float64Values()[0] != 0 {
+			st.foundInf = true
+		}
+	}
+	st.unscaled = true
+
+	return nil
+}
+
+// MustUnscale unscales gradients of variables trained by the optimizer. It panics if error.
+func (s *GradScaler) MustUnscale(opt *Optimizer) {
+	err := s.Unscale(opt)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Step unscales gradients if they haven't been unscaled yet and performs an
+// optimization step if gradients don't contain inf or NaN values.
+// It returns whether the optimization step was performed.
+func (s *GradScaler) Step(opt *Optimizer) (bool, error) {
+	if !s.config.Enabled {
+		if err := opt.Step(); err != nil {
+			err = fmt.Errorf("GradScaler.Step() failed: %w", err)
+			return false, err
+		}
+		return true, nil
+	}
+
+	st := s.state(opt)
+	if !st.unscaled {
+		if err := s.Unscale(opt); err != nil {
+			err = fmt.Errorf("GradScaler.Step() failed: %w", err)
+			return false, err
+		}
+	}
+
+	if st.foundInf {
+		return false, nil
+	}
+
+	if err := opt.Step(); err != nil {
+		err = fmt.Errorf("GradScaler.Step() failed: %w", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MustStep performs an optimization step if gradients are finite. It panics if error.
+func (s *GradScaler) MustStep(opt *Optimizer) bool {
+	stepped, err := s.Step(opt)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return stepped
+}
+
+// Update updates the scale factor. It should be called once per iteration
+// after all optimizers stepped.
+//
+// If inf or NaN gradients were found by any optimizer, the scale is multiplied
+// by BackoffFactor. Otherwise it's multiplied by GrowthFactor after GrowthInterval
+// consecutive iterations without inf/NaN gradients.
+func (s *GradScaler) Update() {
+	if !s.config.Enabled {
+		return
+	}
+
+	foundInf := false
+	for _, st := range s.states {
+		foundInf = foundInf || st.foundInf
+	}
+	s.states = make(map[*Optimizer]*scalerOptState)
+
+	if foundInf {
+		s.scale *= s.config.BackoffFactor
+		s.growthTracker = 0
+		return
+	}
+
+	s.growthTracker++
+	if s.growthTracker >= s.config.GrowthInterval {
+		// Keep scale finite in float32 precision.
+		if next := s.scale * s.config.GrowthFactor; next <= math.MaxFloat32 {
+			s.scale = next
+		}
+		s.growthTracker = 0
+	}
+}
+
+// StateDict returns state of the scaler to be saved in checkpoints.
+func (s *GradScaler) StateDict() map[string]float64 {
+	return map[string]float64{
+		"scale":           s.scale,
+		"growth_factor":   s.config.GrowthFactor,
+		"backoff_factor":  s.config.BackoffFactor,
+		"growth_interval": float64(s.config.GrowthInterval),
+		"_growth_tracker": float64(s.growthTracker),
+	}
+}
+
+// LoadStateDict loads state of the scaler saved by `GradScaler.StateDict()`.
+func (s *GradScaler) LoadStateDict(state map[string]float64) error {
+	scale, ok := state["scale"]
+	if !ok {
+		err := fmt.Errorf("GradScaler.LoadStateDict() failed: missing 'scale' in state")
+		return err
+	}
+	s.scale = scale
+	if v, ok := state["growth_factor"]; ok {
+		s.config.GrowthFactor = v
+	}
+	if v, ok := state["backoff_factor"]; ok {
+		s.config.BackoffFactor = v
+	}
+	if v, ok := state["growth_interval"]; ok {
+		s.config.GrowthInterval = int(v)
+	}
+	if v, ok := state["_growth_tracker"]; ok {
+		s.growthTracker = int(v)
+	}
+
+	return nil
+}
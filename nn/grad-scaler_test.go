@@ -0,0 +1,88 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestGradScaler(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	cfg := &nn.LinearConfig{
+		WsInit: nn.NewConstInit(1.0),
+		BsInit: nn.NewConstInit(0.0),
+		Bias:   true,
+	}
+	model := nn.NewLinear(vs.Root(), 2, 1, cfg)
+	opt, err := nn.DefaultSGDConfig().Build(vs, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scalerCfg := nn.DefaultGradScalerConfig()
+	scalerCfg.InitScale = 1024
+	scalerCfg.GrowthInterval = 2
+	scaler := nn.NewGradScaler(scalerCfg)
+
+	weight := vs.Variables()["weight"]
+	x := ts.MustOfSlice([]float32{1, 2}).MustView([]int64{1, 2}, true)
+
+	// Finite gradients: step is performed, gradients are unscaled.
+	loss := model.Forward(x).MustSum(gotch.Float, true)
+	stepped, err := opt.BackwardStepScaled(loss, scaler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stepped {
+		t.Errorf("Expected optimizer step with finite gradients")
+	}
+	// d(loss)/d(ws) = x
+	grad := weight.MustGrad(false).Float64Values(true)
+	if math.Abs(grad[0]-1) > 1e-6 || math.Abs(grad[1]-2) > 1e-6 {
+		t.Errorf("Expected unscaled grad [1 2], got %v", grad)
+	}
+	ws := weight.Float64Values()
+	if math.Abs(ws[0]-0.9) > 1e-6 || math.Abs(ws[1]-0.8) > 1e-6 {
+		t.Errorf("Expected weights [0.9 0.8], got %v", ws)
+	}
+	if got := scaler.GetScale(); got != 1024 {
+		t.Errorf("Expected scale 1024, got %v", got)
+	}
+
+	// Second finite step reaches growth interval.
+	loss = model.Forward(x).MustSum(gotch.Float, true)
+	opt.MustBackwardStepScaled(loss, scaler)
+	if got := scaler.GetScale(); got != 2048 {
+		t.Errorf("Expected scale 2048 after growth interval, got %v", got)
+	}
+
+	// Inf gradients: step is skipped and scale backs off.
+	wsBefore := weight.Float64Values()
+	inf := ts.MustOfSlice([]float32{float32(math.Inf(1)), 1}).MustView([]int64{1, 2}, true)
+	loss = model.Forward(inf).MustSum(gotch.Float, true)
+	stepped = opt.MustBackwardStepScaled(loss, scaler)
+	if stepped {
+		t.Errorf("Expected optimizer step skipped with inf gradients")
+	}
+	wsAfter := weight.Float64Values()
+	if wsBefore[0] != wsAfter[0] || wsBefore[1] != wsAfter[1] {
+		t.Errorf("Expected weights unchanged %v, got %v", wsBefore, wsAfter)
+	}
+	if got := scaler.GetScale(); got != 1024 {
+		t.Errorf("Expected scale 1024 after backoff, got %v", got)
+	}
+
+	// Unscale can only be called once per update.
+	loss = model.Forward(x).MustSum(gotch.Float, true)
+	opt.MustZeroGrad()
+	scaler.Scale(loss).MustBackward()
+	scaler.MustUnscale(opt)
+	if err := scaler.Unscale(opt); err == nil {
+		t.Errorf("Expected error calling Unscale() twice")
+	}
+	scaler.MustStep(opt)
+	scaler.Update()
+}
@@ -0,0 +1,76 @@
+package nn
+
+// A group-normalization layer.
+
+import (
+	"log"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// Group-normalization config.
+type GroupNormConfig struct {
+	CudnnEnable bool
+	Eps         float64
+	Affine      bool
+	WsInit      Init
+	BsInit      Init
+}
+
+func DefaultGroupNormConfig() *GroupNormConfig {
+	return &GroupNormConfig{
+		CudnnEnable: true,
+		Eps:         1e-5,
+		Affine:      true,
+		WsInit:      NewConstInit(1.0),
+		BsInit:      NewConstInit(0.0),
+	}
+}
+
+// A group-normalization layer.
+//
+// Channels of input of shape (N, C, *) are separated into `NumGroups` groups.
+// Mean and variance are computed separately over each group.
+type GroupNorm struct {
+	Config      *GroupNormConfig
+	Ws          *ts.Tensor // optional
+	Bs          *ts.Tensor // optional
+	NumGroups   int64
+	NumChannels int64
+}
+
+// NewGroupNorm creates a new GroupNorm layer.
+//
+// `numChannels` must be divisible by `numGroups`.
+func NewGroupNorm(vs *Path, numGroups, numChannels int64, config *GroupNormConfig) *GroupNorm {
+	if numGroups <= 0 || numChannels%numGroups != 0 {
+		log.Fatalf("NewGroupNorm() failed: numChannels (%v) must be divisible by numGroups (%v)\n", numChannels, numGroups)
+	}
+
+	var (
+		ws *ts.Tensor
+		bs *ts.Tensor
+	)
+	if config.Affine {
+		ws = vs.MustNewVar("weight", []int64{numChannels}, config.WsInit)
+		bs = vs.MustNewVar("bias", []int64{numChannels}, config.BsInit)
+	}
+
+	return &GroupNorm{config, ws, bs, numGroups, numChannels}
+}
+
+// Implement Module interface for GroupNorm:
+// =========================================
+
+func (gn *GroupNorm) Forward(xs *ts.Tensor) (retVal *ts.Tensor) {
+	if xs.Dim() < 2 {
+		log.Fatalf("Expected an input tensor with at least 2 dims, got %v\n", xs.MustSize())
+	}
+
+	ws, bs := gn.Ws, gn.Bs
+	if !gn.Config.Affine {
+		ws, bs = ts.None, ts.None
+	}
+
+	return ts.MustGroupNorm(xs, gn.NumGroups, ws, bs, gn.Config.Eps, gn.Config.CudnnEnable)
+}
@@ -0,0 +1,61 @@
+package nn_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// Helpers shared by tests of nn layers.
+
+// tensorOf creates a float64 tensor of given values and shape. Shape defaults to 1D.
+func tensorOf(vals []float64, shape ...int64) *ts.Tensor {
+	if len(shape) == 0 {
+		shape = []int64{int64(len(vals))}
+	}
+
+	return ts.MustOfSlice(vals).MustView(shape, true)
+}
+
+// float32TensorOf creates a float32 tensor of given values and shape.
+func float32TensorOf(vals []float32, shape ...int64) *ts.Tensor {
+	return ts.MustOfSlice(vals).MustView(shape, true)
+}
+
+// assertValues checks tensor values against want with a tolerance fitting float32 tensors.
+func assertValues(t *testing.T, name string, x *ts.Tensor, want []float64) {
+	got := x.Float64Values()
+	if len(got) != len(want) {
+		t.Errorf("%s: want %v, got %v", name, want, got)
+		return
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-4 {
+			t.Errorf("%s: want %v, got %v", name, want, got)
+			return
+		}
+	}
+}
+
+// assertShape checks tensor shape against want.
+func assertShape(t *testing.T, name string, x *ts.Tensor, want []int64) {
+	if got := x.MustSize(); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: want shape %v, got %v", name, want, got)
+	}
+}
+
+// valuesCase is a named tensor output with its expected values.
+type valuesCase struct {
+	name string
+	out  *ts.Tensor
+	want []float64
+}
+
+// assertAllValues checks values of all cases with `assertValues()`.
+func assertAllValues(t *testing.T, cases []valuesCase) {
+	for _, c := range cases {
+		assertValues(t, c.name, c.out, c.want)
+	}
+}
@@ -0,0 +1,126 @@
+package nn
+
+// An instance-normalization layer.
+
+import (
+	"log"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// Instance-normalization config.
+//
+// As in PyTorch, an instance-normalization layer has neither learnable
+// affine parameters nor running statistics by default.
+type InstanceNormConfig struct {
+	CudnnEnable       bool
+	Eps               float64
+	Momentum          float64
+	Affine            bool // Whether to learn per-channel weight and bias.
+	TrackRunningStats bool // Whether to track running mean and variance to be used in eval mode.
+	WsInit            Init
+	BsInit            Init
+}
+
+func DefaultInstanceNormConfig() *InstanceNormConfig {
+	return &InstanceNormConfig{
+		CudnnEnable:       true,
+		Eps:               1e-5,
+		Momentum:          0.1,
+		Affine:            false,
+		TrackRunningStats: false,
+		WsInit:            NewConstInit(1.0),
+		BsInit:            NewConstInit(0.0),
+	}
+}
+
+// An instance-normalization layer.
+type InstanceNorm struct {
+	config      *InstanceNormConfig
+	RunningMean *ts.Tensor // optional
+	RunningVar  *ts.Tensor // optional
+	Ws          *ts.Tensor // optional
+	Bs          *ts.Tensor // optional
+	Nd          uint
+}
+
+// NewInstanceNorm creates a new InstanceNorm layer.
+func NewInstanceNorm(vs *Path, nd uint, outDim int64, config *InstanceNormConfig) *InstanceNorm {
+	in := &InstanceNorm{
+		config:      config,
+		RunningMean: ts.None,
+		RunningVar:  ts.None,
+		Ws:          ts.None,
+		Bs:          ts.None,
+		Nd:          nd,
+	}
+
+	if config.TrackRunningStats {
+		in.RunningMean = vs.MustZerosNoTrain("running_mean", []int64{outDim})
+		in.RunningVar = vs.MustOnesNoTrain("running_var", []int64{outDim})
+	}
+	if config.Affine {
+		in.Ws = vs.MustNewVar("weight", []int64{outDim}, config.WsInit)
+		in.Bs = vs.MustNewVar("bias", []int64{outDim}, config.BsInit)
+	}
+
+	return in
+}
+
+// Applies Instance Normalization over a three dimension input.
+//
+// The input shape is assumed to be (N, C, L) or (C, L). Normalization
+// is performed over the last dimension L of each sample and channel.
+func InstanceNorm1D(vs *Path, outDim int64, config *InstanceNormConfig) *InstanceNorm {
+	return NewInstanceNorm(vs, 1, outDim, config)
+}
+
+// Applies Instance Normalization over a four dimension input.
+//
+// The input shape is assumed to be (N, C, H, W) or (C, H, W). Normalization
+// is performed over the spatial dimensions H, W of each sample and channel.
+func InstanceNorm2D(vs *Path, outDim int64, config *InstanceNormConfig) *InstanceNorm {
+	return NewInstanceNorm(vs, 2, outDim, config)
+}
+
+// Applies Instance Normalization over a five dimension input.
+//
+// The input shape is assumed to be (N, C, D, H, W) or (C, D, H, W). Normalization
+// is performed over the spatial dimensions D, H, W of each sample and channel.
+func InstanceNorm3D(vs *Path, outDim int64, config *InstanceNormConfig) *InstanceNorm {
+	return NewInstanceNorm(vs, 3, outDim, config)
+}
+
+// Implement ModuleT interface for InstanceNorm:
+// =============================================
+
+// ForwardT forwards inputs through the module.
+//
+// Statistics of the input are used in train mode or when running statistics
+// are not tracked. Otherwise, the running statistics are used.
+func (in *InstanceNorm) ForwardT(xs *ts.Tensor, train bool) (retVal *ts.Tensor) {
+	dim := int(xs.Dim())
+	nd := int(in.Nd)
+
+	if dim != nd+1 && dim != nd+2 {
+		log.Fatalf("Expected an input tensor with %v or %v dims, got %v\n", nd+1, nd+2, xs.MustSize())
+	}
+
+	useInputStats := train || !in.config.TrackRunningStats
+
+	// Unbatched input.
+	if dim == nd+1 {
+		x := xs.MustUnsqueeze(0, false)
+		out := ts.MustInstanceNorm(x, in.Ws, in.Bs, in.RunningMean, in.RunningVar, useInputStats, in.config.Momentum, in.config.Eps, in.config.CudnnEnable)
+		x.MustDrop()
+
+		return out.MustSqueezeDim(0, true)
+	}
+
+	return ts.MustInstanceNorm(xs, in.Ws, in.Bs, in.RunningMean, in.RunningVar, useInputStats, in.config.Momentum, in.config.Eps, in.config.CudnnEnable)
+}
+
+// Forward forwards inputs through the module in train mode.
+func (in *InstanceNorm) Forward(xs *ts.Tensor) (retVal *ts.Tensor) {
+	return in.ForwardT(xs, true)
+}
@@ -0,0 +1,85 @@
+package nn
+
+// A local-response-normalization layer.
+
+import (
+	"log"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// Local-response-normalization config.
+type LocalResponseNormConfig struct {
+	Alpha float64
+	Beta  float64
+	K     float64
+}
+
+func DefaultLocalResponseNormConfig() *LocalResponseNormConfig {
+	return &LocalResponseNormConfig{
+		Alpha: 1e-4,
+		Beta:  0.75,
+		K:     1.0,
+	}
+}
+
+// A local-response-normalization layer.
+//
+// Each element of input of shape (N, C, *) is normalized over `Size`
+// neighbouring channels:
+//
+//	y = x / (k + alpha/size * sum(x^2))^beta
+//
+// The layer has no learnable parameters.
+type LocalResponseNorm struct {
+	Config *LocalResponseNormConfig
+	Size   int64
+}
+
+// NewLocalResponseNorm creates a new LocalResponseNorm layer. `size` is
+// the number of neighbouring channels used for normalization.
+func NewLocalResponseNorm(size int64, config *LocalResponseNormConfig) *LocalResponseNorm {
+	if size <= 0 {
+		log.Fatalf("NewLocalResponseNorm() failed: expected size > 0, got %v\n", size)
+	}
+
+	return &LocalResponseNorm{config, size}
+}
+
+// Implement Module interface for LocalResponseNorm:
+// =================================================
+
+func (lrn *LocalResponseNorm) Forward(xs *ts.Tensor) (retVal *ts.Tensor) {
+	dim := xs.Dim()
+	if dim < 3 {
+		log.Fatalf("Expected an input tensor with at least 3 dims, got %v\n", xs.MustSize())
+	}
+
+	size := lrn.Size
+	sq := xs.MustMul(xs, false)
+
+	// Average of squares over neighbouring channels with zero padding.
+	var div *ts.Tensor
+	if dim == 3 {
+		div = sq.MustUnsqueeze(1, true).
+			MustConstantPadNd([]int64{0, 0, size / 2, (size - 1) / 2}, true).
+			MustAvgPool2d([]int64{size, 1}, []int64{1, 1}, []int64{0, 0}, false, true, nil, true).
+			MustSqueezeDim(1, true)
+	} else {
+		shape := xs.MustSize()
+		div = sq.MustView([]int64{shape[0], 1, shape[1], shape[2], -1}, true).
+			MustConstantPadNd([]int64{0, 0, 0, 0, size / 2, (size - 1) / 2}, true).
+			MustAvgPool3d([]int64{size, 1, 1}, []int64{1, 1, 1}, []int64{0, 0, 0}, false, true, nil, true).
+			MustSqueezeDim(1, true).
+			MustView(shape, true)
+	}
+
+	div = div.MustMulScalar(ts.FloatScalar(lrn.Config.Alpha), true).
+		MustAddScalar(ts.FloatScalar(lrn.Config.K), true).
+		MustPowTensorScalar(ts.FloatScalar(lrn.Config.Beta), true)
+
+	retVal = xs.MustDiv(div, false)
+	div.MustDrop()
+
+	return retVal
+}
@@ -1,14 +1,31 @@
 package nn
 
 import (
+	"math"
+
+	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
 type lossFnOptions struct {
-	ClassWeights []float64
-	Reduction    int64 // 0: "None", 1: "mean", 2: "sum"
-	IgnoreIndex  int64
-	PosWeight    int64 // index of the weight attributed to positive class. Used in BCELoss
+	ClassWeights   []float64
+	Reduction      int64 // 0: "None", 1: "mean", 2: "sum"
+	IgnoreIndex    int64
+	PosWeights     []float64 // weights of positive examples. Used in BCE losses
+	LabelSmoothing float64   // Used in CrossEntropyLoss
+	Beta           float64   // threshold to change between L1 and L2 loss. Used in SmoothL1Loss
+	Delta          float64   // threshold to change between L1 and L2 loss. Used in HuberLoss
+	LogTarget      bool      // whether target is in log space. Used in KLDivLoss
+	Alpha          float64   // weight of positive examples, negative value to ignore. Used in FocalLoss
+	Gamma          float64   // focusing parameter. Used in FocalLoss
+	Blank          int64     // blank label. Used in CTCLoss
+	ZeroInfinity   bool      // whether to zero infinite losses. Used in CTCLoss
+	Margin         float64   // Used in MarginRankingLoss, TripletMarginLoss, CosineEmbeddingLoss and HingeEmbeddingLoss
+	P              float64   // norm degree of pairwise distance. Used in TripletMarginLoss
+	Eps            float64   // small value to avoid numerical issues. Used in TripletMarginLoss, PoissonNLLLoss and GaussianNLLLoss
+	Swap           bool      // whether to use distance swap. Used in TripletMarginLoss
+	LogInput       bool      // whether input is in log space. Used in PoissonNLLLoss
+	Full           bool      // whether to add constant terms. Used in PoissonNLLLoss and GaussianNLLLoss
 }
 
 type LossFnOption func(*lossFnOptions)
@@ -29,46 +46,182 @@ func WithLossFnIgnoreIndex(val int64) LossFnOption {
 	}
 }
 
+// WithLossFnPosWeight sets a single weight of positive examples for all classes.
+//
+// Deprecated: use WithLossFnPosWeights instead.
 func WithLossFnPosWeight(val int64) LossFnOption {
 	return func(o *lossFnOptions) {
-		o.PosWeight = val
+		o.PosWeights = []float64{float64(val)}
+	}
+}
+
+// WithLossFnPosWeights sets weights of positive examples, one per class
+// (or a single value for all classes).
+func WithLossFnPosWeights(vals []float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.PosWeights = vals
+	}
+}
+
+func WithLossFnLabelSmoothing(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.LabelSmoothing = val
+	}
+}
+
+func WithLossFnBeta(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Beta = val
+	}
+}
+
+func WithLossFnDelta(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Delta = val
+	}
+}
+
+func WithLossFnLogTarget(val bool) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.LogTarget = val
+	}
+}
+
+func WithLossFnAlpha(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Alpha = val
+	}
+}
+
+func WithLossFnGamma(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Gamma = val
+	}
+}
+
+func WithLossFnBlank(val int64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Blank = val
+	}
+}
+
+func WithLossFnZeroInfinity(val bool) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.ZeroInfinity = val
+	}
+}
+
+// WithLossFnMargin sets margin. Default=1.0, except MarginRankingLoss and
+// CosineEmbeddingLoss with default=0.0.
+func WithLossFnMargin(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Margin = val
+	}
+}
+
+func WithLossFnP(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.P = val
+	}
+}
+
+// WithLossFnEps sets epsilon. Default=1e-6, except PoissonNLLLoss with default=1e-8.
+func WithLossFnEps(val float64) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Eps = val
+	}
+}
+
+func WithLossFnSwap(val bool) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Swap = val
+	}
+}
+
+func WithLossFnLogInput(val bool) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.LogInput = val
+	}
+}
+
+func WithLossFnFull(val bool) LossFnOption {
+	return func(o *lossFnOptions) {
+		o.Full = val
 	}
 }
 
 func defaultLossFnOptions() *lossFnOptions {
 	return &lossFnOptions{
-		ClassWeights: nil,
-		Reduction:    1, // "mean"
-		IgnoreIndex:  -100,
-		PosWeight:    -1,
+		ClassWeights:   nil,
+		Reduction:      1, // "mean"
+		IgnoreIndex:    -100,
+		PosWeights:     nil,
+		LabelSmoothing: 0.0,
+		Beta:           1.0,
+		Delta:          1.0,
+		LogTarget:      false,
+		Alpha:          0.25,
+		Gamma:          2.0,
+		Blank:          0,
+		ZeroInfinity:   false,
+		Margin:         1.0,
+		P:              2.0,
+		Eps:            1e-6,
+		Swap:           false,
+		LogInput:       true,
+		Full:           false,
 	}
 }
 
-// CrossEntropyLoss calculates cross entropy loss.
-// Ref. https://github.com/pytorch/pytorch/blob/15be189f0de4addf4f68d18022500f67617ab05d/torch/nn/functional.py#L2012
-// - logits: tensor of shape [B, C, H, W] corresponding the raw output of the model.
-// - target: ground truth tensor of shape [B, 1, H, W]
-// - posWeight: scalar representing the weight attributed to positive class.
-// This is especially useful for an imbalanced dataset
-func CrossEntropyLoss(logits, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+// newLossFnOptions creates loss function options with given default values
+// updated by user options.
+func newLossFnOptions(defaults []LossFnOption, opts []LossFnOption) *lossFnOptions {
 	options := defaultLossFnOptions()
+	for _, o := range defaults {
+		o(options)
+	}
 	for _, o := range opts {
 		o(options)
 	}
 
-	var ws *ts.Tensor
-	device := logits.MustDevice()
-	dtype := logits.DType()
-	if len(options.ClassWeights) > 0 {
-		ws = ts.MustOfSlice(options.ClassWeights).MustTotype(dtype, true).MustTo(device, true)
-	} else {
-		ws = ts.NewTensor()
+	return options
+}
+
+// optionalWeights creates a tensor from weight values or an undefined tensor if there's none.
+func optionalWeights(vals []float64, dtype gotch.DType, device gotch.Device) *ts.Tensor {
+	if len(vals) == 0 {
+		return ts.NewTensor()
+	}
+
+	return ts.MustOfSlice(vals).MustTotype(dtype, true).MustTo(device, true)
+}
+
+// reduceLoss applies reduction on element-wise losses.
+func reduceLoss(loss *ts.Tensor, reduction int64) *ts.Tensor {
+	switch reduction {
+	case ts.ReductionMean:
+		return loss.MustMean(loss.DType(), true)
+	case ts.ReductionSum:
+		return loss.MustSum(loss.DType(), true)
+	default:
+		return loss
 	}
-	reduction := options.Reduction
-	ignoreIndex := options.IgnoreIndex
+}
+
+// CrossEntropyLoss calculates cross entropy loss.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.CrossEntropyLoss.html
+//
+// - logits: tensor of shape [B, C] or [B, C, d1, ..., dK] corresponding the raw output of the model.
+// - target: class indices of shape [B] or [B, d1, ..., dK], or class probabilities (soft targets)
+// of the same shape as logits.
+//
+// Options: WithLossFnWeights, WithLossFnReduction, WithLossFnIgnoreIndex (class indices
+// target only) and WithLossFnLabelSmoothing.
+func CrossEntropyLoss(logits, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
 
-	logSm := logits.MustLogSoftmax(-1, dtype, false)
-	loss := logSm.MustNllLoss(target, ws, reduction, ignoreIndex, true)
+	ws := optionalWeights(options.ClassWeights, logits.DType(), logits.MustDevice())
+	loss := logits.MustCrossEntropyLoss(target, ws, options.Reduction, options.IgnoreIndex, options.LabelSmoothing, false)
 	ws.MustDrop()
 
 	return loss
@@ -77,33 +230,38 @@ func CrossEntropyLoss(logits, target *ts.Tensor, opts ...LossFnOption) *ts.Tenso
 // BCELoss calculates a binary cross entropy loss.
 //
 // - logits: tensor of shape [B, C, H, W] corresponding the raw output of the model.
-// - target: ground truth tensor of shape [B, 1, H, W]
-// - posWeight: scalar representing the weight attributed to positive class.
-// This is especially useful for an imbalanced dataset
+// - target: ground truth tensor of the same shape as squeezed logits.
+//
+// NOTE. logits are squeezed, use `BCEWithLogitsLoss()` to keep shape of logits.
 func BCELoss(logits, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
-	options := defaultLossFnOptions()
-	for _, o := range opts {
-		o(options)
-	}
+	x := logits.MustSqueeze(false)
+	loss := BCEWithLogitsLoss(x, target, opts...)
+	x.MustDrop()
+
+	return loss
+}
+
+// BCEWithLogitsLoss calculates a binary cross entropy loss on logits.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.BCEWithLogitsLoss.html
+//
+// - logits: raw output of the model.
+// - target: ground truth probabilities of the same shape as logits.
+//
+// Options: WithLossFnWeights (weight of each element, broadcast to target shape),
+// WithLossFnPosWeights (weight of positive examples of each class, broadcast to
+// target shape. This is especially useful for an imbalanced dataset) and WithLossFnReduction.
+func BCEWithLogitsLoss(logits, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
 
-	var ws *ts.Tensor
 	device := logits.MustDevice()
 	dtype := logits.DType()
-	if len(options.ClassWeights) > 0 {
-		ws = ts.MustOfSlice(options.ClassWeights).MustTotype(dtype, true).MustTo(device, true)
-	} else {
-		ws = ts.NewTensor()
-	}
-	reduction := options.Reduction
+	ws := optionalWeights(options.ClassWeights, dtype, device)
+	posWeight := optionalWeights(options.PosWeights, dtype, device)
 
-	var posWeight *ts.Tensor
-	if options.PosWeight >= 0 {
-		posWeight = ts.MustOfSlice([]int64{options.PosWeight})
-	} else {
-		posWeight = ts.NewTensor()
-	}
+	loss := logits.MustBinaryCrossEntropyWithLogits(target, ws, posWeight, options.Reduction, false)
+	ws.MustDrop()
+	posWeight.MustDrop()
 
-	loss := logits.MustSqueeze(false).MustBinaryCrossEntropyWithLogits(target, ws, posWeight, reduction, true)
 	return loss
 }
 
@@ -119,3 +277,202 @@ func MSELoss(logits, labels *ts.Tensor, reductionOpt ...int64) *ts.Tensor {
 
 	return out
 }
+
+// L1Loss calculates mean absolute error loss.
+//
+// Options: WithLossFnReduction.
+func L1Loss(input, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	return input.MustL1Loss(target, options.Reduction, false)
+}
+
+// SmoothL1Loss calculates a loss which is L2 loss divided by beta if absolute
+// element-wise error is below beta and L1 loss otherwise.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.SmoothL1Loss.html
+//
+// Options: WithLossFnReduction and WithLossFnBeta (default=1.0).
+func SmoothL1Loss(input, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	return input.MustSmoothL1Loss(target, options.Reduction, options.Beta, false)
+}
+
+// HuberLoss calculates a loss which is L2 loss if absolute element-wise error
+// is below delta and L1 loss scaled by delta otherwise.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.HuberLoss.html
+//
+// Options: WithLossFnReduction and WithLossFnDelta (default=1.0).
+func HuberLoss(input, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	return input.MustHuberLoss(target, options.Reduction, options.Delta, false)
+}
+
+// NLLLoss calculates negative log likelihood loss.
+//
+// - logProbs: log-probabilities of shape [B, C] or [B, C, d1, ..., dK].
+// - target: class indices of shape [B] or [B, d1, ..., dK].
+//
+// Options: WithLossFnWeights, WithLossFnReduction and WithLossFnIgnoreIndex.
+func NLLLoss(logProbs, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	ws := optionalWeights(options.ClassWeights, logProbs.DType(), logProbs.MustDevice())
+	loss := logProbs.MustNllLossNd(target, ws, options.Reduction, options.IgnoreIndex, false)
+	ws.MustDrop()
+
+	return loss
+}
+
+// KLDivLoss calculates Kullback-Leibler divergence loss.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.KLDivLoss.html
+//
+// - input: log-probabilities.
+// - target: probabilities, or log-probabilities if WithLossFnLogTarget(true).
+//
+// Options: WithLossFnReduction and WithLossFnLogTarget (default=false).
+//
+// NOTE. "mean" reduction averages over all elements. For the mathematically
+// correct KL divergence, use "sum" reduction and divide by batch size.
+func KLDivLoss(input, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	return input.MustKlDiv(target, options.Reduction, options.LogTarget, false)
+}
+
+// FocalLoss calculates sigmoid focal loss used in dense detection.
+// Ref. https://arxiv.org/abs/1708.02002
+//
+// - logits: raw output of the model.
+// - target: binary ground truth of the same shape as logits.
+//
+// Options: WithLossFnAlpha (default=0.25, negative to ignore),
+// WithLossFnGamma (default=2.0) and WithLossFnReduction.
+func FocalLoss(logits, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	p := logits.MustSigmoid(false)
+	ce := logits.MustBinaryCrossEntropyWithLogits(target, ts.None, ts.None, ts.ReductionNone, false)
+
+	// pt = p*target + (1-p)*(1-target)
+	q := p.MustRsubScalar(ts.FloatScalar(1.0), false)
+	negTarget := target.MustRsubScalar(ts.FloatScalar(1.0), false)
+	qNeg := q.MustMul(negTarget, true)
+	pt := p.MustMul(target, true).MustAdd(qNeg, true)
+	qNeg.MustDrop()
+	negTarget.MustDrop()
+
+	// loss = ce * (1-pt)^gamma
+	modulator := pt.MustRsubScalar(ts.FloatScalar(1.0), true).MustPowTensorScalar(ts.FloatScalar(options.Gamma), true)
+	loss := ce.MustMul(modulator, true)
+	modulator.MustDrop()
+
+	if options.Alpha >= 0 {
+		// alpha_t = alpha*target + (1-alpha)*(1-target)
+		alphaT := target.MustMulScalar(ts.FloatScalar(2*options.Alpha-1), false).MustAddScalar(ts.FloatScalar(1-options.Alpha), true)
+		loss = loss.MustMul(alphaT, true)
+		alphaT.MustDrop()
+	}
+
+	return reduceLoss(loss, options.Reduction)
+}
+
+// CTCLoss calculates connectionist temporal classification loss.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.CTCLoss.html
+//
+// - logProbs: log-probabilities of shape [T, B, C].
+// - targets: target sequences of shape [B, S] or concatenated targets of shape [sum(targetLengths)].
+// - inputLengths: lengths of inputs, one per batch element.
+// - targetLengths: lengths of targets, one per batch element.
+//
+// Options: WithLossFnBlank (default=0), WithLossFnReduction (mean divides losses
+// by target lengths then averages over the batch) and WithLossFnZeroInfinity (default=false).
+func CTCLoss(logProbs, targets *ts.Tensor, inputLengths, targetLengths []int64, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	return ts.MustCtcLoss(logProbs, targets, inputLengths, targetLengths, options.Blank, options.Reduction, options.ZeroInfinity)
+}
+
+// MarginRankingLoss calculates max(0, -y*(x1-x2) + margin) where y is 1 or -1.
+//
+// Options: WithLossFnMargin (default=0.0) and WithLossFnReduction.
+func MarginRankingLoss(x1, x2, y *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions([]LossFnOption{WithLossFnMargin(0.0)}, opts)
+
+	return ts.MustMarginRankingLoss(x1, x2, y, options.Margin, options.Reduction)
+}
+
+// TripletMarginLoss calculates max(d(a, p) - d(a, n) + margin, 0) where d is
+// pairwise distance.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.TripletMarginLoss.html
+//
+// Options: WithLossFnMargin (default=1.0), WithLossFnP (default=2.0),
+// WithLossFnEps (default=1e-6), WithLossFnSwap (default=false) and WithLossFnReduction.
+func TripletMarginLoss(anchor, positive, negative *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	return ts.MustTripletMarginLoss(anchor, positive, negative, options.Margin, options.P, options.Eps, options.Swap, options.Reduction)
+}
+
+// CosineEmbeddingLoss calculates 1 - cos(x1, x2) if y = 1 and
+// max(0, cos(x1, x2) - margin) if y = -1.
+//
+// Options: WithLossFnMargin (default=0.0) and WithLossFnReduction.
+func CosineEmbeddingLoss(x1, x2, y *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions([]LossFnOption{WithLossFnMargin(0.0)}, opts)
+
+	return ts.MustCosineEmbeddingLoss(x1, x2, y, options.Margin, options.Reduction)
+}
+
+// HingeEmbeddingLoss calculates x if y = 1 and max(0, margin - x) if y = -1.
+//
+// Options: WithLossFnMargin (default=1.0) and WithLossFnReduction.
+func HingeEmbeddingLoss(input, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	return input.MustHingeEmbeddingLoss(target, options.Margin, options.Reduction, false)
+}
+
+// PoissonNLLLoss calculates negative log likelihood loss with Poisson distribution of target.
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.PoissonNLLLoss.html
+//
+// Options: WithLossFnLogInput (default=true), WithLossFnFull (default=false, whether
+// to add Stirling approximation term), WithLossFnEps (default=1e-8) and WithLossFnReduction.
+func PoissonNLLLoss(input, target *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions([]LossFnOption{WithLossFnEps(1e-8)}, opts)
+
+	return ts.MustPoissonNllLoss(input, target, options.LogInput, options.Full, options.Eps, options.Reduction)
+}
+
+// GaussianNLLLoss calculates negative log likelihood loss with Gaussian distribution of target:
+// 0.5 * (log(max(variance, eps)) + (input - target)^2 / max(variance, eps)).
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.GaussianNLLLoss.html
+//
+// - input: expectations of the Gaussian distribution.
+// - target: samples from the Gaussian distribution.
+// - variance: variances of the Gaussian distribution, broadcastable to input.
+//
+// Options: WithLossFnFull (default=false, whether to add constant term 0.5*log(2*pi)),
+// WithLossFnEps (default=1e-6) and WithLossFnReduction.
+func GaussianNLLLoss(input, target, variance *ts.Tensor, opts ...LossFnOption) *ts.Tensor {
+	options := newLossFnOptions(nil, opts)
+
+	// NOTE. variance is clamped in-place without gradient tracking as in PyTorch,
+	// hence gradients flow to variance as if it were not clamped.
+	v := variance.MustMulScalar(ts.FloatScalar(1.0), false)
+	ts.NoGrad(func() {
+		v.MustClampMin_(ts.FloatScalar(options.Eps))
+	})
+
+	diff := input.MustSub(target, false)
+	logV := v.MustLog(false)
+	loss := diff.MustSquare(true).MustDiv(v, true).MustAdd(logV, true).MustMulScalar(ts.FloatScalar(0.5), true)
+	logV.MustDrop()
+	v.MustDrop()
+	if options.Full {
+		loss = loss.MustAddScalar(ts.FloatScalar(0.5*math.Log(2*math.Pi)), true)
+	}
+
+	return reduceLoss(loss, options.Reduction)
+}
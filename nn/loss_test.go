@@ -0,0 +1,81 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestLossFunctions(t *testing.T) {
+	logProbs := tensorOf([]float64{math.Log(0.2), math.Log(0.3), math.Log(0.5), math.Log(0.1), math.Log(0.6), math.Log(0.3)}, 2, 3)
+	nllTarget := ts.MustOfSlice([]int64{2, 1})
+
+	// CTC: 2 frames, 1 sample, classes {blank, 1}. Paths of label "1":
+	// (1, 1), (blank, 1) and (1, blank).
+	ctcLogProbs := tensorOf([]float64{math.Log(0.4), math.Log(0.6), math.Log(0.3), math.Log(0.7)}, 2, 1, 2)
+	ctcTarget := ts.MustOfSlice([]int64{1}).MustView([]int64{1, 1}, true)
+
+	tests := []struct {
+		name string
+		loss *ts.Tensor
+		want float64
+	}{
+		{"L1Loss", nn.L1Loss(tensorOf([]float64{1, 2, 3}), tensorOf([]float64{2, 2, 5})), 1.0},
+		{"L1Loss sum", nn.L1Loss(tensorOf([]float64{1, 2, 3}), tensorOf([]float64{2, 2, 5}), nn.WithLossFnReduction(ts.ReductionSum)), 3.0},
+		{"SmoothL1Loss", nn.SmoothL1Loss(tensorOf([]float64{0.5, 2}), tensorOf([]float64{0, 0})), 0.8125},
+		{"SmoothL1Loss beta", nn.SmoothL1Loss(tensorOf([]float64{0.5, 2}), tensorOf([]float64{0, 0}), nn.WithLossFnBeta(2)), 0.53125},
+		{"HuberLoss", nn.HuberLoss(tensorOf([]float64{0.5, 2}), tensorOf([]float64{0, 0})), 0.8125},
+		{"HuberLoss delta", nn.HuberLoss(tensorOf([]float64{0.5, 2}), tensorOf([]float64{0, 0}), nn.WithLossFnDelta(2)), 1.0625},
+		{"NLLLoss", nn.NLLLoss(logProbs, nllTarget), 0.601986402162968},
+		{"NLLLoss weights", nn.NLLLoss(logProbs, nllTarget, nn.WithLossFnWeights([]float64{1, 1, 2})), 0.6323733282952938},
+		{"CrossEntropyLoss", nn.CrossEntropyLoss(tensorOf([]float64{1, 2, 3}, 1, 3), ts.MustOfSlice([]int64{2})), 0.40760596444438013},
+		{"CrossEntropyLoss label smoothing", nn.CrossEntropyLoss(tensorOf([]float64{1, 2, 3}, 1, 3), ts.MustOfSlice([]int64{2}), nn.WithLossFnLabelSmoothing(0.1)), 0.5076059644443801},
+		{"CrossEntropyLoss soft targets", nn.CrossEntropyLoss(tensorOf([]float64{1, 2, 3}, 1, 3), tensorOf([]float64{0.2, 0.3, 0.5}, 1, 3)), 1.10760596444438},
+		{"BCEWithLogitsLoss", nn.BCEWithLogitsLoss(tensorOf([]float64{0, 1}), tensorOf([]float64{1, 0})), 1.003204434039084},
+		{"BCEWithLogitsLoss pos weights", nn.BCEWithLogitsLoss(tensorOf([]float64{0, 1}), tensorOf([]float64{1, 0}), nn.WithLossFnPosWeights([]float64{3})), 1.6963516145990294},
+		{"KLDivLoss", nn.KLDivLoss(tensorOf([]float64{math.Log(0.25), math.Log(0.75)}), tensorOf([]float64{0.5, 0.5}), nn.WithLossFnReduction(ts.ReductionSum)), 0.14384103622589042},
+		{"KLDivLoss log target", nn.KLDivLoss(tensorOf([]float64{math.Log(0.25), math.Log(0.75)}), tensorOf([]float64{math.Log(0.5), math.Log(0.5)}), nn.WithLossFnReduction(ts.ReductionSum), nn.WithLossFnLogTarget(true)), 0.14384103622589042},
+		{"FocalLoss", nn.FocalLoss(tensorOf([]float64{0, 2}), tensorOf([]float64{1, 0})), 0.6404401666755265},
+		{"FocalLoss no alpha", nn.FocalLoss(tensorOf([]float64{0, 2}), tensorOf([]float64{1, 0}), nn.WithLossFnAlpha(-1), nn.WithLossFnReduction(ts.ReductionSum)), 1.823364974561395},
+		{"CTCLoss", nn.CTCLoss(ctcLogProbs, ctcTarget, []int64{2}, []int64{1}), 0.12783337150988489},
+		{"MarginRankingLoss", nn.MarginRankingLoss(tensorOf([]float64{1, 2}), tensorOf([]float64{2, 1}), tensorOf([]float64{1, 1})), 0.5},
+		{"MarginRankingLoss margin", nn.MarginRankingLoss(tensorOf([]float64{1, 2}), tensorOf([]float64{2, 1}), tensorOf([]float64{1, 1}), nn.WithLossFnMargin(0.5)), 0.75},
+		{"TripletMarginLoss", nn.TripletMarginLoss(tensorOf([]float64{0, 0}, 1, 2), tensorOf([]float64{3, 4}, 1, 2), tensorOf([]float64{0, 1}, 1, 2)), 5.0},
+		{"CosineEmbeddingLoss", nn.CosineEmbeddingLoss(tensorOf([]float64{1, 0, 1, 0}, 2, 2), tensorOf([]float64{0, 1, 1, 1}, 2, 2), tensorOf([]float64{1, -1})), 0.8535533905932737},
+		{"HingeEmbeddingLoss", nn.HingeEmbeddingLoss(tensorOf([]float64{0.3, 2.0}), tensorOf([]float64{1, -1})), 0.15},
+		{"PoissonNLLLoss", nn.PoissonNLLLoss(tensorOf([]float64{0, 1}), tensorOf([]float64{1, 2})), 0.8591409142295225},
+		{"PoissonNLLLoss full", nn.PoissonNLLLoss(tensorOf([]float64{0, 1}), tensorOf([]float64{1, 2}), nn.WithLossFnFull(true)), 1.1850441565317904},
+		{"GaussianNLLLoss", nn.GaussianNLLLoss(tensorOf([]float64{0, 1}), tensorOf([]float64{1, 1}), tensorOf([]float64{1, 2})), 0.42328679513998635},
+		{"GaussianNLLLoss full", nn.GaussianNLLLoss(tensorOf([]float64{0, 1}), tensorOf([]float64{1, 1}), tensorOf([]float64{1, 2}), nn.WithLossFnFull(true)), 1.342225328344659},
+	}
+
+	for _, tt := range tests {
+		got := tt.loss.Float64Values()[0]
+		if math.Abs(got-tt.want) > 1e-5 {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestLossFunctions_ReductionNone(t *testing.T) {
+	loss := nn.L1Loss(tensorOf([]float64{1, 2, 3}), tensorOf([]float64{2, 2, 5}), nn.WithLossFnReduction(ts.ReductionNone))
+	got := loss.Float64Values()
+	want := []float64{1, 0, 2}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-6 {
+			t.Errorf("want %v, got %v", want, got)
+			break
+		}
+	}
+
+	focal := nn.FocalLoss(tensorOf([]float64{0, 2}), tensorOf([]float64{1, 0}), nn.WithLossFnReduction(ts.ReductionNone))
+	if shape := focal.MustSize(); len(shape) != 1 || shape[0] != 2 {
+		t.Errorf("want shape [2], got %v", shape)
+	}
+	if dtype := focal.DType(); dtype != gotch.Double {
+		t.Errorf("want dtype Double, got %v", dtype)
+	}
+}
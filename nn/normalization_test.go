@@ -0,0 +1,95 @@
+package nn_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+)
+
+func TestGroupNorm(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	gn := nn.NewGroupNorm(vs.Root(), 2, 4, nn.DefaultGroupNormConfig())
+
+	x := float32TensorOf([]float32{1, 2, 3, 4}, 1, 4, 1)
+	assertValues(t, "GroupNorm", gn.Forward(x), []float64{-1, 1, -1, 1})
+
+	vars := vs.Variables()
+	if _, ok := vars["weight"]; !ok {
+		t.Errorf("Expected 'weight' variable")
+	}
+	if _, ok := vars["bias"]; !ok {
+		t.Errorf("Expected 'bias' variable")
+	}
+}
+
+func TestInstanceNorm(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	in := nn.InstanceNorm1D(vs.Root(), 2, nn.DefaultInstanceNormConfig())
+	if n := len(vs.Variables()); n != 0 {
+		t.Errorf("Expected no variables by default, got %v", n)
+	}
+
+	x := float32TensorOf([]float32{1, 3, 2, 6}, 1, 2, 2)
+	assertValues(t, "InstanceNorm1D", in.ForwardT(x, false), []float64{-1, 1, -1, 1})
+
+	// Unbatched input.
+	out := in.ForwardT(float32TensorOf([]float32{1, 3, 2, 6}, 2, 2), false)
+	assertShape(t, "InstanceNorm1D unbatched", out, []int64{2, 2})
+	assertValues(t, "InstanceNorm1D unbatched", out, []float64{-1, 1, -1, 1})
+}
+
+func TestInstanceNorm_RunningStats(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	config := nn.DefaultInstanceNormConfig()
+	config.Affine = true
+	config.TrackRunningStats = true
+	in := nn.InstanceNorm1D(vs.Root(), 2, config)
+
+	vars := vs.Variables()
+	for _, name := range []string{"weight", "bias", "running_mean", "running_var"} {
+		if _, ok := vars[name]; !ok {
+			t.Errorf("Expected %q variable", name)
+		}
+	}
+
+	x := float32TensorOf([]float32{1, 3, 2, 6}, 1, 2, 2)
+	in.ForwardT(x, true)
+	assertValues(t, "running_mean", in.RunningMean, []float64{0.2, 0.4})
+	assertValues(t, "running_var", in.RunningVar, []float64{1.1, 1.7})
+
+	// Eval mode uses running statistics.
+	want := []float64{
+		(1 - 0.2) / math.Sqrt(1.1+1e-5), (3 - 0.2) / math.Sqrt(1.1+1e-5),
+		(2 - 0.4) / math.Sqrt(1.7+1e-5), (6 - 0.4) / math.Sqrt(1.7+1e-5),
+	}
+	assertValues(t, "InstanceNorm1D eval", in.ForwardT(x, false), want)
+}
+
+func TestRMSNorm(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	rn := nn.NewRMSNorm(vs.Root(), []int64{2}, nn.DefaultRMSNormConfig())
+
+	x := float32TensorOf([]float32{3, 4, 1, 1}, 2, 2)
+	rms := math.Sqrt(12.5 + 1e-6)
+	want := []float64{3 / rms, 4 / rms, 1, 1}
+	assertValues(t, "RMSNorm", rn.Forward(x), want)
+
+	if _, ok := vs.Variables()["weight"]; !ok {
+		t.Errorf("Expected 'weight' variable")
+	}
+}
+
+func TestLocalResponseNorm(t *testing.T) {
+	config := &nn.LocalResponseNormConfig{Alpha: 1, Beta: 1, K: 1}
+	lrn := nn.NewLocalResponseNorm(2, config)
+
+	// Channel windows (with zero padding in front): [0, 1] and [1, 2].
+	want := []float64{1 / 1.5, 2 / 3.5}
+	assertValues(t, "LocalResponseNorm 3D", lrn.Forward(float32TensorOf([]float32{1, 2}, 1, 2, 1)), want)
+
+	out := lrn.Forward(float32TensorOf([]float32{1, 2}, 1, 2, 1, 1))
+	assertShape(t, "LocalResponseNorm 4D", out, []int64{1, 2, 1, 1})
+	assertValues(t, "LocalResponseNorm 4D", out, want)
+}
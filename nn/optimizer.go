@@ -13,16 +13,38 @@ import (
 // Optimizer is a struct object to run gradient descent.
 type Optimizer struct {
 	varstore *VarStore
-	opt      *ts.COptimizer
+	opt      optimizerImpl
 	// variablesInOptimizer uint8
 	variablesInOptimizer map[string]struct{}
 	config               interface{}
 	stepCount            int
-}
+	momentum             *float64 // last momentum set by `SetMomentum()` if any
+}
+
+// optimizerImpl is an optimizer backend. It is implemented by `ts.COptimizer`
+// (libtorch optimizers) and `GoOptimizer` (optimizers written in Go).
+type optimizerImpl interface {
+	AddParameter(param *ts.Tensor, group uint) error
+	SetLearningRate(lr float64) error
+	GetLearningRates() ([]float64, error)
+	SetLearningRates(lrs []float64) error
+	ParamGroupNum() (int64, error)
+	AddParamGroup(tensors []*ts.Tensor) error
+	SetMomentum(m float64) error
+	ZeroGrad() error
+	Step() error
+	GetState(param *ts.Tensor) ([]*ts.Tensor, int64, error)
+	SetState(param *ts.Tensor, states []*ts.Tensor, step int64) error
+}
+
+var (
+	_ optimizerImpl = &ts.COptimizer{}
+	_ optimizerImpl = &GoOptimizer{}
+)
 
 // OptimizerConfig defines Optimizer configurations. These configs can be used to build optimizer.
 type OptimizerConfig interface {
-	buildCOpt(lr float64) (*ts.COptimizer, error)
+	buildCOpt(lr float64) (optimizerImpl, error)
 
 	// Build builds an optimizer with the specified learning rate handling variables stored in `vs`.
 	//
@@ -95,7 +117,7 @@ func NewSGDConfig(momentum, dampening, wd float64, nesterov bool) *SGDConfig {
 }
 
 // Implement OptimizerConfig interface for SGDConfig
-func (c *SGDConfig) buildCOpt(lr float64) (*ts.COptimizer, error) {
+func (c *SGDConfig) buildCOpt(lr float64) (optimizerImpl, error) {
 	return ts.Sgd(lr, c.Momentum, c.Dampening, c.Wd, c.Nesterov)
 }
 
@@ -131,7 +153,7 @@ func NewAdamConfig(beta1, beta2, wd float64) *AdamConfig {
 }
 
 // Implement OptimizerConfig interface for AdamConfig
-func (c *AdamConfig) buildCOpt(lr float64) (*ts.COptimizer, error) {
+func (c *AdamConfig) buildCOpt(lr float64) (optimizerImpl, error) {
 	return ts.Adam(lr, c.Beta1, c.Beta2, c.Wd)
 }
 
@@ -167,7 +189,7 @@ func NewAdamWConfig(beta1, beta2, wd float64) *AdamWConfig {
 }
 
 // Implement OptimizerConfig interface for AdamWConfig
-func (c *AdamWConfig) buildCOpt(lr float64) (*ts.COptimizer, error) {
+func (c *AdamWConfig) buildCOpt(lr float64) (optimizerImpl, error) {
 	return ts.AdamW(lr, c.Beta1, c.Beta2, c.Wd)
 }
 
@@ -210,7 +232,7 @@ func NewRMSPropConfig(alpha, eps, wd, momentum float64, centered bool) *RMSPropC
 }
 
 // Implement OptimizerConfig interface for RMSPropConfig
-func (c *RMSPropConfig) buildCOpt(lr float64) (*ts.COptimizer, error) {
+func (c *RMSPropConfig) buildCOpt(lr float64) (optimizerImpl, error) {
 	return ts.RmsProp(lr, c.Alpha, c.Eps, c.Wd, c.Momentum, c.Centered)
 }
 
@@ -314,7 +336,7 @@ func (opt *Optimizer) BackwardStep(loss *ts.Tensor) error {
 	}
 
 	loss.MustBackward()
-	err = opt.opt.Step()
+	err = opt.Step()
 	if err != nil {
 		err = fmt.Errorf("Optimizer.BackwardStep() failed: %w\n", err)
 		return err
@@ -342,7 +364,7 @@ func (opt *Optimizer) BackwardStepClip(loss *ts.Tensor, max float64) error {
 	}
 	loss.MustBackward()
 	opt.ClipGradValue(max)
-	err = opt.opt.Step()
+	err = opt.Step()
 	if err != nil {
 		err = fmt.Errorf("Optimizer.BackwardStepClip() failed: %w\n", err)
 		return err
@@ -360,6 +382,52 @@ func (opt *Optimizer) MustBackwardStepClip(loss *ts.Tensor, max float64) {
 	}
 }
 
+// BackwardStepScaled applies a backward step pass on the loss scaled by the grad scaler,
+// performs an optimization step if gradients are finite and updates the scale factor.
+// It returns whether the optimization step was performed.
+//
+// Like `BackwardStep()`, step count (see `StepCount()`) is only advanced
+// when the optimization step is performed.
+//
+// It is used for mixed precision training, i.e. with loss computed inside `ts.Autocast()`.
+func (opt *Optimizer) BackwardStepScaled(loss *ts.Tensor, scaler *GradScaler) (bool, error) {
+	err := opt.opt.ZeroGrad()
+	if err != nil {
+		err = fmt.Errorf("Optimizer.BackwardStepScaled() failed: %w\n", err)
+		return false, err
+	}
+
+	scaled := scaler.Scale(loss)
+	err = scaled.Backward()
+	if scaled != loss {
+		scaled.MustDrop()
+	}
+	if err != nil {
+		err = fmt.Errorf("Optimizer.BackwardStepScaled() failed: %w\n", err)
+		return false, err
+	}
+
+	stepped, err := scaler.Step(opt)
+	if err != nil {
+		err = fmt.Errorf("Optimizer.BackwardStepScaled() failed: %w\n", err)
+		return false, err
+	}
+	scaler.Update()
+
+	return stepped, nil
+}
+
+// MustBackwardStepScaled applies a backward step pass on the scaled loss and performs
+// an optimization step if gradients are finite. It panics if error.
+func (opt *Optimizer) MustBackwardStepScaled(loss *ts.Tensor, scaler *GradScaler) bool {
+	stepped, err := opt.BackwardStepScaled(loss, scaler)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return stepped
+}
+
 type ClipOpts struct {
 	NormType         float64
 	ErrorIfNonFinite bool
@@ -548,6 +616,7 @@ func (opt *Optimizer) SetMomentum(m float64) {
 	if err != nil {
 		log.Fatalf("Optimizer - SetMomentum  method call error: %v\n", err)
 	}
+	opt.momentum = &m
 }
 
 func (opt *Optimizer) ParamGroupNum() int {
@@ -565,3 +634,314 @@ func (opt *Optimizer) AddParamGroup(tensors []*ts.Tensor) {
 		log.Fatalf("Optimizer - ParamGroupNum  method call error: %v\n", err)
 	}
 }
+
+// Optimizer state:
+// ================
+
+// OptimizerState holds state of an optimizer similar to Pytorch `optimizer.state_dict()`.
+type OptimizerState struct {
+	// Number of optimization steps performed.
+	StepCount int
+
+	// Hyperparameters of each parameter group. E.g. "lr", "momentum", "weight_decay".
+	ParamGroups []map[string]float64
+
+	// Per-parameter state tensors keyed by "<parameter name>.<state name>". E.g.
+	// "layer1.weight.exp_avg", "layer1.weight.step".
+	State map[string]*ts.Tensor
+}
+
+// Drop frees up memory of all state tensors.
+func (s *OptimizerState) Drop() {
+	for _, x := range s.State {
+		x.MustDrop()
+	}
+}
+
+// optimizerStateNames returns names of per-parameter state tensors of an optimizer
+// in the order of `ts.COptimizer.GetState()`. Step is stored separately as "step".
+func optimizerStateNames(config interface{}) []string {
+	switch config.(type) {
+	case *SGDConfig:
+		return []string{"momentum_buffer"}
+	case *AdamConfig, *AdamWConfig:
+		return []string{"exp_avg", "exp_avg_sq", "max_exp_avg_sq"}
+	case *RMSPropConfig:
+		return []string{"square_avg", "momentum_buffer", "grad_avg"}
+	case *GoOptimizerConfig:
+		return config.(*GoOptimizerConfig).Algorithm.StateNames()
+	case GoOptimizerAlgorithm:
+		return config.(GoOptimizerAlgorithm).StateNames()
+	default:
+		return nil
+	}
+}
+
+func boolFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// hyperParams returns hyperparameters of optimizer (except learning rates).
+func (opt *Optimizer) hyperParams() map[string]float64 {
+	var (
+		params      map[string]float64
+		momentumKey string
+	)
+	switch c := opt.config.(type) {
+	case *SGDConfig:
+		params = map[string]float64{
+			"momentum":     c.Momentum,
+			"dampening":    c.Dampening,
+			"weight_decay": c.Wd,
+			"nesterov":     boolFloat(c.Nesterov),
+		}
+		momentumKey = "momentum"
+	case *AdamConfig:
+		params = map[string]float64{
+			"beta1":        c.Beta1,
+			"beta2":        c.Beta2,
+			"weight_decay": c.Wd,
+		}
+		momentumKey = "beta1"
+	case *AdamWConfig:
+		params = map[string]float64{
+			"beta1":        c.Beta1,
+			"beta2":        c.Beta2,
+			"weight_decay": c.Wd,
+		}
+		momentumKey = "beta1"
+	case *RMSPropConfig:
+		params = map[string]float64{
+			"alpha":        c.Alpha,
+			"eps":          c.Eps,
+			"weight_decay": c.Wd,
+			"momentum":     c.Momentum,
+			"centered":     boolFloat(c.Centered),
+		}
+		momentumKey = "momentum"
+	default:
+		params = make(map[string]float64)
+	}
+
+	// Momentum might be updated by schedulers.
+	if opt.momentum != nil && momentumKey != "" {
+		params[momentumKey] = *opt.momentum
+	}
+
+	return params
+}
+
+// StateDict returns a copy of optimizer state including per-parameter state tensors
+// (e.g. Adam moments) and hyperparameters of parameter groups.
+//
+// NOTE. state tensors are copied and should be freed with `OptimizerState.Drop()`
+// when no longer needed.
+func (opt *Optimizer) StateDict() (*OptimizerState, error) {
+	lrs, err := opt.opt.GetLearningRates()
+	if err != nil {
+		err = fmt.Errorf("Optimizer.StateDict() failed: %w", err)
+		return nil, err
+	}
+
+	var groups []map[string]float64
+	if gopt, ok := opt.opt.(*GoOptimizer); ok {
+		groups = gopt.ParamGroups()
+	} else {
+		hyperParams := opt.hyperParams()
+		groups = make([]map[string]float64, len(lrs))
+		for i, lr := range lrs {
+			group := map[string]float64{"lr": lr}
+			for k, v := range hyperParams {
+				group[k] = v
+			}
+			groups[i] = group
+		}
+	}
+
+	stateNames := optimizerStateNames(opt.config)
+	state := make(map[string]*ts.Tensor)
+
+	opt.varstore.Lock()
+	defer opt.varstore.Unlock()
+	for name, v := range opt.varstore.vars {
+		if _, ok := opt.variablesInOptimizer[name]; !ok || !v.Trainable {
+			continue
+		}
+		tensors, step, err := opt.opt.GetState(v.Tensor)
+		if err != nil {
+			for _, x := range state {
+				x.MustDrop()
+			}
+			err = fmt.Errorf("Optimizer.StateDict() failed: %w", err)
+			return nil, err
+		}
+		if step < 0 {
+			continue // no state yet
+		}
+		for i, x := range tensors {
+			if x == nil {
+				continue
+			}
+			if i < len(stateNames) {
+				state[fmt.Sprintf("%s.%s", name, stateNames[i])] = x
+			} else {
+				x.MustDrop()
+			}
+		}
+		if _, ok := opt.config.(*SGDConfig); !ok {
+			state[fmt.Sprintf("%s.step", name)] = ts.MustOfSlice([]int64{step})
+		}
+	}
+
+	return &OptimizerState{
+		StepCount:   opt.stepCount,
+		ParamGroups: groups,
+		State:       state,
+	}, nil
+}
+
+// MustStateDict returns optimizer state. It panics if error occurred.
+func (opt *Optimizer) MustStateDict() *OptimizerState {
+	s, err := opt.StateDict()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return s
+}
+
+// LoadStateDict loads optimizer state returned by `StateDict()`. The optimizer
+// should be built with the same config on a VarStore with the same variables.
+//
+// Learning rates and momentum (all hyperparameters for `GoOptimizer`) are restored
+// from param groups. State tensors are copied to the device of corresponding parameters.
+func (opt *Optimizer) LoadStateDict(s *OptimizerState) error {
+	ngroup, err := opt.opt.ParamGroupNum()
+	if err != nil {
+		err = fmt.Errorf("Optimizer.LoadStateDict() failed: %w", err)
+		return err
+	}
+	if int(ngroup) != len(s.ParamGroups) {
+		err := fmt.Errorf("Optimizer.LoadStateDict() failed: loaded state dict has %v param groups, optimizer has %v", len(s.ParamGroups), ngroup)
+		return err
+	}
+
+	if gopt, ok := opt.opt.(*GoOptimizer); ok {
+		if err := gopt.SetParamGroups(s.ParamGroups); err != nil {
+			err = fmt.Errorf("Optimizer.LoadStateDict() failed: %w", err)
+			return err
+		}
+	} else if err := opt.loadHyperParams(s.ParamGroups); err != nil {
+		err = fmt.Errorf("Optimizer.LoadStateDict() failed: %w", err)
+		return err
+	}
+
+	stateNames := optimizerStateNames(opt.config)
+	_, isSGD := opt.config.(*SGDConfig)
+
+	opt.varstore.Lock()
+	defer opt.varstore.Unlock()
+	for name, v := range opt.varstore.vars {
+		if _, ok := opt.variablesInOptimizer[name]; !ok || !v.Trainable {
+			continue
+		}
+
+		device, err := v.Tensor.Device()
+		if err != nil {
+			err = fmt.Errorf("Optimizer.LoadStateDict() failed: %w", err)
+			return err
+		}
+
+		var (
+			found   bool
+			tensors = make([]*ts.Tensor, len(stateNames))
+			step    int64
+		)
+		dropTensors := func() {
+			for _, x := range tensors {
+				if x != nil {
+					x.MustDrop()
+				}
+			}
+		}
+		for i, stateName := range stateNames {
+			x, ok := s.State[fmt.Sprintf("%s.%s", name, stateName)]
+			if !ok {
+				continue
+			}
+			found = true
+			tensors[i], err = x.To(device, false)
+			if err != nil {
+				dropTensors()
+				err = fmt.Errorf("Optimizer.LoadStateDict() failed: %w", err)
+				return err
+			}
+		}
+		if x, ok := s.State[fmt.Sprintf("%s.step", name)]; ok {
+			found = true
+			step = x.Int64Values()[0]
+		}
+		if !found {
+			continue
+		}
+		if isSGD {
+			step = 0
+		}
+
+		err = opt.opt.SetState(v.Tensor, tensors, step)
+		dropTensors()
+		if err != nil {
+			err = fmt.Errorf("Optimizer.LoadStateDict() failed: %q: %w", name, err)
+			return err
+		}
+	}
+
+	opt.stepCount = s.StepCount
+
+	return nil
+}
+
+// loadHyperParams restores learning rates and momentum of libtorch optimizers.
+//
+// NOTE. libtorch optimizers support setting of learning rates per group but
+// a single momentum for all groups.
+func (opt *Optimizer) loadHyperParams(groups []map[string]float64) error {
+	var lrs []float64
+	for i, group := range groups {
+		lr, ok := group["lr"]
+		if !ok {
+			err := fmt.Errorf("missing 'lr' in param group %v", i)
+			return err
+		}
+		lrs = append(lrs, lr)
+	}
+	if len(lrs) > 0 {
+		if err := opt.opt.SetLearningRates(lrs); err != nil {
+			return err
+		}
+
+		current := opt.hyperParams()
+		for _, key := range []string{"momentum", "beta1"} {
+			m, ok := groups[0][key]
+			if _, has := current[key]; !ok || !has || m == current[key] {
+				continue
+			}
+			if err := opt.opt.SetMomentum(m); err != nil {
+				return err
+			}
+			opt.momentum = &m
+		}
+	}
+
+	return nil
+}
+
+// MustLoadStateDict loads optimizer state. It panics if error occurred.
+func (opt *Optimizer) MustLoadStateDict(s *OptimizerState) {
+	if err := opt.LoadStateDict(s); err != nil {
+		log.Fatal(err)
+	}
+}
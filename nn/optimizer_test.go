@@ -2,6 +2,7 @@ package nn_test
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/sugarme/gotch"
@@ -74,3 +75,111 @@ func TestClipGradNorm(t *testing.T) {
 func TestClipGradValue(t *testing.T) {
 	// TODO
 }
+
+func TestOptimizer_StateDict(t *testing.T) {
+	x := ts.MustArangeStart(ts.IntScalar(1), ts.IntScalar(15), gotch.Float, gotch.CPU).MustView([]int64{-1, 1}, true)
+	y := x.MustMulScalar(ts.FloatScalar(0.42), false).MustAddScalar(ts.FloatScalar(1.337), false)
+
+	build := func() (*nn.VarStore, *nn.Linear, *nn.Optimizer) {
+		vs := nn.NewVarStore(gotch.CPU)
+		model := nn.NewLinear(vs.Root(), 1, 1, nn.DefaultLinearConfig())
+		opt, err := nn.DefaultAdamConfig().Build(vs, 1e-2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vs, model, opt
+	}
+
+	vs1, model1, opt1 := build()
+	for i := 0; i < 5; i++ {
+		loss := model1.Forward(x).MustMseLoss(y, 1, true)
+		opt1.MustZeroGrad()
+		loss.MustBackward()
+		opt1.MustStep()
+		loss.MustDrop()
+	}
+	opt1.SetLRs([]float64{0.005})
+
+	sd, err := opt1.StateDict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sd.Drop()
+
+	if sd.StepCount != 5 {
+		t.Errorf("want step count 5, got %v", sd.StepCount)
+	}
+	if len(sd.ParamGroups) != 1 || sd.ParamGroups[0]["lr"] != 0.005 || sd.ParamGroups[0]["beta1"] != 0.9 {
+		t.Errorf("unexpected param groups: %v", sd.ParamGroups)
+	}
+	for _, name := range []string{"weight.exp_avg", "weight.exp_avg_sq", "weight.step", "bias.exp_avg"} {
+		if _, ok := sd.State[name]; !ok {
+			t.Errorf("missing state %q", name)
+		}
+	}
+	if step := sd.State["weight.step"].Int64Values()[0]; step != 5 {
+		t.Errorf("want Adam step 5, got %v", step)
+	}
+
+	vs2, model2, opt2 := build()
+	if err := vs2.Copy(vs1); err != nil {
+		t.Fatal(err)
+	}
+	if err := opt2.LoadStateDict(sd); err != nil {
+		t.Fatal(err)
+	}
+	if got := opt2.GetLRs()[0]; got != 0.005 {
+		t.Errorf("want lr 0.005, got %v", got)
+	}
+	if got := opt2.StepCount(); got != 5 {
+		t.Errorf("want step count 5, got %v", got)
+	}
+
+	// Both optimizers should produce the same update from the same state.
+	for _, m := range []struct {
+		model *nn.Linear
+		opt   *nn.Optimizer
+	}{{model1, opt1}, {model2, opt2}} {
+		loss := m.model.Forward(x).MustMseLoss(y, 1, true)
+		m.opt.BackwardStep(loss)
+		loss.MustDrop()
+	}
+	w1 := model1.Ws.Float64Values()
+	w2 := model2.Ws.Float64Values()
+	if math.Abs(w1[0]-w2[0]) > 1e-6 {
+		t.Errorf("want same weights after step, got %v and %v", w1, w2)
+	}
+}
+
+func TestOptimizer_Scope(t *testing.T) {
+	x := ts.MustArangeStart(ts.IntScalar(1), ts.IntScalar(15), gotch.Float, gotch.CPU).MustView([]int64{-1, 1}, true)
+	y := x.MustMulScalar(ts.FloatScalar(0.42), false).MustAddScalar(ts.FloatScalar(1.337), false)
+
+	for _, config := range []nn.OptimizerConfig{nn.DefaultAdamConfig(), nn.DefaultLionConfig()} {
+		vs := nn.NewVarStore(gotch.CPU)
+		model := nn.NewLinear(vs.Root(), 1, 1, nn.DefaultLinearConfig())
+		opt, err := config.Build(vs, 1e-2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Optimizer states created in training loop are not freed by scope.
+		var counts []int
+		for i := 0; i < 20; i++ {
+			ts.WithScope(func(s *ts.Scope) {
+				loss := model.Forward(x).MustMseLoss(y, 1, true)
+				opt.MustBackwardStep(loss)
+			})
+			counts = append(counts, len(ts.ExistingTensors))
+		}
+
+		if counts[1] != counts[len(counts)-1] {
+			t.Errorf("%T: want stable number of tensors in training loop, got %v\n", config, counts)
+		}
+
+		loss := model.Forward(x).MustMseLoss(y, 1, true).Float64Values(true)[0]
+		if math.IsNaN(loss) {
+			t.Errorf("%T: want finite loss after training, got %v\n", config, loss)
+		}
+	}
+}
@@ -136,6 +136,9 @@ func NewMaxPool2D(kernelSize []int64, opts ...MaxPool2DOpt) *MaxPool2D {
 	for _, opt := range opts {
 		opt(o)
 	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
 
 	return &MaxPool2D{
 		Kernel:   kernelSize,
@@ -149,3 +152,8 @@ func NewMaxPool2D(kernelSize []int64, opts ...MaxPool2DOpt) *MaxPool2D {
 func (m *MaxPool2D) Forward(x *ts.Tensor) *ts.Tensor {
 	return x.MustMaxPool2d(m.Kernel, m.Stride, m.Padding, m.Dilation, m.CeilMode, false)
 }
+
+// ForwardWithIndices returns max values and their indices which can be used by `MaxUnpool2D`.
+func (m *MaxPool2D) ForwardWithIndices(x *ts.Tensor) (*ts.Tensor, *ts.Tensor) {
+	return x.MustMaxPool2dWithIndices(m.Kernel, m.Stride, m.Padding, m.Dilation, m.CeilMode, false)
+}
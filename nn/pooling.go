@@ -0,0 +1,892 @@
+package nn
+
+// Pooling layers.
+
+import (
+	"log"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// poolParam returns value of a pooling parameter at spatial dimension i.
+// A single-element parameter applies to all dimensions.
+func poolParam(v []int64, i int) int64 {
+	if len(v) == 1 {
+		return v[0]
+	}
+
+	return v[i]
+}
+
+// MaxPool1D:
+// ==========
+
+type MaxPool1D struct {
+	Kernel   []int64
+	Stride   []int64
+	Padding  []int64
+	Dilation []int64
+	CeilMode bool
+}
+
+type MaxPool1DOpts struct {
+	Stride   []int64
+	Padding  []int64
+	Dilation []int64
+	CeilMode bool
+}
+
+type MaxPool1DOpt func(*MaxPool1DOpts)
+
+func OptStrideMp1D(v []int64) MaxPool1DOpt {
+	return func(o *MaxPool1DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingMp1D(v []int64) MaxPool1DOpt {
+	return func(o *MaxPool1DOpts) {
+		o.Padding = v
+	}
+}
+
+func OptDilationMp1D(v []int64) MaxPool1DOpt {
+	return func(o *MaxPool1DOpts) {
+		o.Dilation = v
+	}
+}
+
+func OptCeilModeMp1D(v bool) MaxPool1DOpt {
+	return func(o *MaxPool1DOpts) {
+		o.CeilMode = v
+	}
+}
+
+func DefaultMaxPool1DOpts() *MaxPool1DOpts {
+	return &MaxPool1DOpts{
+		Stride:   nil,
+		Padding:  []int64{0},
+		Dilation: []int64{1},
+	}
+}
+
+// NewMaxPool1D creates a max-pooling layer over input of shape (N, C, L) or (C, L).
+// Stride defaults to kernel size.
+func NewMaxPool1D(kernelSize []int64, opts ...MaxPool1DOpt) *MaxPool1D {
+	o := DefaultMaxPool1DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &MaxPool1D{
+		Kernel:   kernelSize,
+		Stride:   o.Stride,
+		Padding:  o.Padding,
+		Dilation: o.Dilation,
+		CeilMode: o.CeilMode,
+	}
+}
+
+func (m *MaxPool1D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustMaxPool1d(m.Kernel, m.Stride, m.Padding, m.Dilation, m.CeilMode, false)
+}
+
+// ForwardWithIndices returns max values and their indices which can be used by `MaxUnpool1D`.
+func (m *MaxPool1D) ForwardWithIndices(x *ts.Tensor) (*ts.Tensor, *ts.Tensor) {
+	return x.MustMaxPool1dWithIndices(m.Kernel, m.Stride, m.Padding, m.Dilation, m.CeilMode, false)
+}
+
+// MaxPool3D:
+// ==========
+
+type MaxPool3D struct {
+	Kernel   []int64
+	Stride   []int64
+	Padding  []int64
+	Dilation []int64
+	CeilMode bool
+}
+
+type MaxPool3DOpts struct {
+	Stride   []int64
+	Padding  []int64
+	Dilation []int64
+	CeilMode bool
+}
+
+type MaxPool3DOpt func(*MaxPool3DOpts)
+
+func OptStrideMp3D(v []int64) MaxPool3DOpt {
+	return func(o *MaxPool3DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingMp3D(v []int64) MaxPool3DOpt {
+	return func(o *MaxPool3DOpts) {
+		o.Padding = v
+	}
+}
+
+func OptDilationMp3D(v []int64) MaxPool3DOpt {
+	return func(o *MaxPool3DOpts) {
+		o.Dilation = v
+	}
+}
+
+func OptCeilModeMp3D(v bool) MaxPool3DOpt {
+	return func(o *MaxPool3DOpts) {
+		o.CeilMode = v
+	}
+}
+
+func DefaultMaxPool3DOpts() *MaxPool3DOpts {
+	return &MaxPool3DOpts{
+		Stride:   nil,
+		Padding:  []int64{0, 0, 0},
+		Dilation: []int64{1, 1, 1},
+	}
+}
+
+// NewMaxPool3D creates a max-pooling layer over input of shape (N, C, D, H, W) or (C, D, H, W).
+// Stride defaults to kernel size.
+func NewMaxPool3D(kernelSize []int64, opts ...MaxPool3DOpt) *MaxPool3D {
+	o := DefaultMaxPool3DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &MaxPool3D{
+		Kernel:   kernelSize,
+		Stride:   o.Stride,
+		Padding:  o.Padding,
+		Dilation: o.Dilation,
+		CeilMode: o.CeilMode,
+	}
+}
+
+func (m *MaxPool3D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustMaxPool3d(m.Kernel, m.Stride, m.Padding, m.Dilation, m.CeilMode, false)
+}
+
+// ForwardWithIndices returns max values and their indices which can be used by `MaxUnpool3D`.
+func (m *MaxPool3D) ForwardWithIndices(x *ts.Tensor) (*ts.Tensor, *ts.Tensor) {
+	return x.MustMaxPool3dWithIndices(m.Kernel, m.Stride, m.Padding, m.Dilation, m.CeilMode, false)
+}
+
+// AvgPool1D:
+// ==========
+
+type AvgPool1D struct {
+	Kernel          []int64
+	Stride          []int64
+	Padding         []int64
+	CeilMode        bool
+	CountIncludePad bool
+}
+
+type AvgPool1DOpts struct {
+	Stride          []int64
+	Padding         []int64
+	CeilMode        bool
+	CountIncludePad bool
+}
+
+type AvgPool1DOpt func(*AvgPool1DOpts)
+
+func OptStrideAp1D(v []int64) AvgPool1DOpt {
+	return func(o *AvgPool1DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingAp1D(v []int64) AvgPool1DOpt {
+	return func(o *AvgPool1DOpts) {
+		o.Padding = v
+	}
+}
+
+func OptCeilModeAp1D(v bool) AvgPool1DOpt {
+	return func(o *AvgPool1DOpts) {
+		o.CeilMode = v
+	}
+}
+
+func OptCountIncludePadAp1D(v bool) AvgPool1DOpt {
+	return func(o *AvgPool1DOpts) {
+		o.CountIncludePad = v
+	}
+}
+
+func DefaultAvgPool1DOpts() *AvgPool1DOpts {
+	return &AvgPool1DOpts{
+		Stride:          nil,
+		Padding:         []int64{0},
+		CountIncludePad: true,
+	}
+}
+
+// NewAvgPool1D creates an average-pooling layer over input of shape (N, C, L) or (C, L).
+// Stride defaults to kernel size.
+func NewAvgPool1D(kernelSize []int64, opts ...AvgPool1DOpt) *AvgPool1D {
+	o := DefaultAvgPool1DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &AvgPool1D{
+		Kernel:          kernelSize,
+		Stride:          o.Stride,
+		Padding:         o.Padding,
+		CeilMode:        o.CeilMode,
+		CountIncludePad: o.CountIncludePad,
+	}
+}
+
+func (m *AvgPool1D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustAvgPool1d(m.Kernel, m.Stride, m.Padding, m.CeilMode, m.CountIncludePad, false)
+}
+
+// AvgPool2D:
+// ==========
+
+type AvgPool2D struct {
+	Kernel          []int64
+	Stride          []int64
+	Padding         []int64
+	CeilMode        bool
+	CountIncludePad bool
+	DivisorOverride []int64 // optional. If set, it's used as divisor instead of pooling region size.
+}
+
+type AvgPool2DOpts struct {
+	Stride          []int64
+	Padding         []int64
+	CeilMode        bool
+	CountIncludePad bool
+	DivisorOverride []int64
+}
+
+type AvgPool2DOpt func(*AvgPool2DOpts)
+
+func OptStrideAp2D(v []int64) AvgPool2DOpt {
+	return func(o *AvgPool2DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingAp2D(v []int64) AvgPool2DOpt {
+	return func(o *AvgPool2DOpts) {
+		o.Padding = v
+	}
+}
+
+func OptCeilModeAp2D(v bool) AvgPool2DOpt {
+	return func(o *AvgPool2DOpts) {
+		o.CeilMode = v
+	}
+}
+
+func OptCountIncludePadAp2D(v bool) AvgPool2DOpt {
+	return func(o *AvgPool2DOpts) {
+		o.CountIncludePad = v
+	}
+}
+
+func OptDivisorOverrideAp2D(v int64) AvgPool2DOpt {
+	return func(o *AvgPool2DOpts) {
+		o.DivisorOverride = []int64{v}
+	}
+}
+
+func DefaultAvgPool2DOpts() *AvgPool2DOpts {
+	return &AvgPool2DOpts{
+		Stride:          nil,
+		Padding:         []int64{0, 0},
+		CountIncludePad: true,
+	}
+}
+
+// NewAvgPool2D creates an average-pooling layer over input of shape (N, C, H, W) or (C, H, W).
+// Stride defaults to kernel size.
+func NewAvgPool2D(kernelSize []int64, opts ...AvgPool2DOpt) *AvgPool2D {
+	o := DefaultAvgPool2DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &AvgPool2D{
+		Kernel:          kernelSize,
+		Stride:          o.Stride,
+		Padding:         o.Padding,
+		CeilMode:        o.CeilMode,
+		CountIncludePad: o.CountIncludePad,
+		DivisorOverride: o.DivisorOverride,
+	}
+}
+
+func (m *AvgPool2D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustAvgPool2d(m.Kernel, m.Stride, m.Padding, m.CeilMode, m.CountIncludePad, m.DivisorOverride, false)
+}
+
+// AvgPool3D:
+// ==========
+
+type AvgPool3D struct {
+	Kernel          []int64
+	Stride          []int64
+	Padding         []int64
+	CeilMode        bool
+	CountIncludePad bool
+	DivisorOverride []int64 // optional. If set, it's used as divisor instead of pooling region size.
+}
+
+type AvgPool3DOpts struct {
+	Stride          []int64
+	Padding         []int64
+	CeilMode        bool
+	CountIncludePad bool
+	DivisorOverride []int64
+}
+
+type AvgPool3DOpt func(*AvgPool3DOpts)
+
+func OptStrideAp3D(v []int64) AvgPool3DOpt {
+	return func(o *AvgPool3DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingAp3D(v []int64) AvgPool3DOpt {
+	return func(o *AvgPool3DOpts) {
+		o.Padding = v
+	}
+}
+
+func OptCeilModeAp3D(v bool) AvgPool3DOpt {
+	return func(o *AvgPool3DOpts) {
+		o.CeilMode = v
+	}
+}
+
+func OptCountIncludePadAp3D(v bool) AvgPool3DOpt {
+	return func(o *AvgPool3DOpts) {
+		o.CountIncludePad = v
+	}
+}
+
+func OptDivisorOverrideAp3D(v int64) AvgPool3DOpt {
+	return func(o *AvgPool3DOpts) {
+		o.DivisorOverride = []int64{v}
+	}
+}
+
+func DefaultAvgPool3DOpts() *AvgPool3DOpts {
+	return &AvgPool3DOpts{
+		Stride:          nil,
+		Padding:         []int64{0, 0, 0},
+		CountIncludePad: true,
+	}
+}
+
+// NewAvgPool3D creates an average-pooling layer over input of shape (N, C, D, H, W) or (C, D, H, W).
+// Stride defaults to kernel size.
+func NewAvgPool3D(kernelSize []int64, opts ...AvgPool3DOpt) *AvgPool3D {
+	o := DefaultAvgPool3DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &AvgPool3D{
+		Kernel:          kernelSize,
+		Stride:          o.Stride,
+		Padding:         o.Padding,
+		CeilMode:        o.CeilMode,
+		CountIncludePad: o.CountIncludePad,
+		DivisorOverride: o.DivisorOverride,
+	}
+}
+
+func (m *AvgPool3D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustAvgPool3d(m.Kernel, m.Stride, m.Padding, m.CeilMode, m.CountIncludePad, m.DivisorOverride, false)
+}
+
+// Adaptive pooling:
+// =================
+
+// AdaptiveAvgPool1D applies average pooling with output of shape (N, C, OutputSize[0]).
+type AdaptiveAvgPool1D struct {
+	OutputSize []int64
+}
+
+func NewAdaptiveAvgPool1D(outputSize []int64) *AdaptiveAvgPool1D {
+	return &AdaptiveAvgPool1D{outputSize}
+}
+
+func (m *AdaptiveAvgPool1D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustAdaptiveAvgPool1d(m.OutputSize, false)
+}
+
+// AdaptiveAvgPool2D applies average pooling with output of shape (N, C, OutputSize[0], OutputSize[1]).
+type AdaptiveAvgPool2D struct {
+	OutputSize []int64
+}
+
+func NewAdaptiveAvgPool2D(outputSize []int64) *AdaptiveAvgPool2D {
+	return &AdaptiveAvgPool2D{outputSize}
+}
+
+func (m *AdaptiveAvgPool2D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustAdaptiveAvgPool2d(m.OutputSize, false)
+}
+
+// AdaptiveAvgPool3D applies average pooling with output of shape (N, C, OutputSize[0], OutputSize[1], OutputSize[2]).
+type AdaptiveAvgPool3D struct {
+	OutputSize []int64
+}
+
+func NewAdaptiveAvgPool3D(outputSize []int64) *AdaptiveAvgPool3D {
+	return &AdaptiveAvgPool3D{outputSize}
+}
+
+func (m *AdaptiveAvgPool3D) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustAdaptiveAvgPool3d(m.OutputSize, false)
+}
+
+// AdaptiveMaxPool1D applies max pooling with output of shape (N, C, OutputSize[0]).
+type AdaptiveMaxPool1D struct {
+	OutputSize []int64
+}
+
+func NewAdaptiveMaxPool1D(outputSize []int64) *AdaptiveMaxPool1D {
+	return &AdaptiveMaxPool1D{outputSize}
+}
+
+func (m *AdaptiveMaxPool1D) Forward(x *ts.Tensor) *ts.Tensor {
+	out, indices := m.ForwardWithIndices(x)
+	indices.MustDrop()
+
+	return out
+}
+
+// ForwardWithIndices returns max values and their indices.
+func (m *AdaptiveMaxPool1D) ForwardWithIndices(x *ts.Tensor) (*ts.Tensor, *ts.Tensor) {
+	return x.MustAdaptiveMaxPool1d(m.OutputSize, false)
+}
+
+// AdaptiveMaxPool2D applies max pooling with output of shape (N, C, OutputSize[0], OutputSize[1]).
+type AdaptiveMaxPool2D struct {
+	OutputSize []int64
+}
+
+func NewAdaptiveMaxPool2D(outputSize []int64) *AdaptiveMaxPool2D {
+	return &AdaptiveMaxPool2D{outputSize}
+}
+
+func (m *AdaptiveMaxPool2D) Forward(x *ts.Tensor) *ts.Tensor {
+	out, indices := m.ForwardWithIndices(x)
+	indices.MustDrop()
+
+	return out
+}
+
+// ForwardWithIndices returns max values and their indices which can be used by `MaxUnpool2D`.
+func (m *AdaptiveMaxPool2D) ForwardWithIndices(x *ts.Tensor) (*ts.Tensor, *ts.Tensor) {
+	return x.MustAdaptiveMaxPool2d(m.OutputSize, false)
+}
+
+// AdaptiveMaxPool3D applies max pooling with output of shape (N, C, OutputSize[0], OutputSize[1], OutputSize[2]).
+type AdaptiveMaxPool3D struct {
+	OutputSize []int64
+}
+
+func NewAdaptiveMaxPool3D(outputSize []int64) *AdaptiveMaxPool3D {
+	return &AdaptiveMaxPool3D{outputSize}
+}
+
+func (m *AdaptiveMaxPool3D) Forward(x *ts.Tensor) *ts.Tensor {
+	out, indices := m.ForwardWithIndices(x)
+	indices.MustDrop()
+
+	return out
+}
+
+// ForwardWithIndices returns max values and their indices which can be used by `MaxUnpool3D`.
+func (m *AdaptiveMaxPool3D) ForwardWithIndices(x *ts.Tensor) (*ts.Tensor, *ts.Tensor) {
+	return x.MustAdaptiveMaxPool3d(m.OutputSize, false)
+}
+
+// LPPool1D, LPPool2D:
+// ===================
+
+// LPPool1D applies power-average pooling over input of shape (N, C, L) or (C, L):
+//
+//	y = (sum(x^p))^(1/p)
+//
+// over each pooling window.
+type LPPool1D struct {
+	NormType float64
+	Kernel   []int64
+	Stride   []int64
+	CeilMode bool
+}
+
+type LPPool1DOpts struct {
+	Stride   []int64
+	CeilMode bool
+}
+
+type LPPool1DOpt func(*LPPool1DOpts)
+
+func OptStrideLp1D(v []int64) LPPool1DOpt {
+	return func(o *LPPool1DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptCeilModeLp1D(v bool) LPPool1DOpt {
+	return func(o *LPPool1DOpts) {
+		o.CeilMode = v
+	}
+}
+
+func DefaultLPPool1DOpts() *LPPool1DOpts {
+	return &LPPool1DOpts{
+		Stride: nil,
+	}
+}
+
+// NewLPPool1D creates a power-average pooling layer with norm type `p`.
+// Stride defaults to kernel size.
+func NewLPPool1D(p float64, kernelSize []int64, opts ...LPPool1DOpt) *LPPool1D {
+	o := DefaultLPPool1DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &LPPool1D{
+		NormType: p,
+		Kernel:   kernelSize,
+		Stride:   o.Stride,
+		CeilMode: o.CeilMode,
+	}
+}
+
+func (m *LPPool1D) Forward(x *ts.Tensor) *ts.Tensor {
+	out := x.MustPowTensorScalar(ts.FloatScalar(m.NormType), false).
+		MustAvgPool1d(m.Kernel, m.Stride, []int64{0}, m.CeilMode, true, true)
+
+	return lpPoolOutput(out, m.NormType, m.Kernel[0])
+}
+
+// LPPool2D applies power-average pooling over input of shape (N, C, H, W) or (C, H, W):
+//
+//	y = (sum(x^p))^(1/p)
+//
+// over each pooling window.
+type LPPool2D struct {
+	NormType float64
+	Kernel   []int64
+	Stride   []int64
+	CeilMode bool
+}
+
+type LPPool2DOpts struct {
+	Stride   []int64
+	CeilMode bool
+}
+
+type LPPool2DOpt func(*LPPool2DOpts)
+
+func OptStrideLp2D(v []int64) LPPool2DOpt {
+	return func(o *LPPool2DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptCeilModeLp2D(v bool) LPPool2DOpt {
+	return func(o *LPPool2DOpts) {
+		o.CeilMode = v
+	}
+}
+
+func DefaultLPPool2DOpts() *LPPool2DOpts {
+	return &LPPool2DOpts{
+		Stride: nil,
+	}
+}
+
+// NewLPPool2D creates a power-average pooling layer with norm type `p`.
+// Stride defaults to kernel size.
+func NewLPPool2D(p float64, kernelSize []int64, opts ...LPPool2DOpt) *LPPool2D {
+	o := DefaultLPPool2DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &LPPool2D{
+		NormType: p,
+		Kernel:   kernelSize,
+		Stride:   o.Stride,
+		CeilMode: o.CeilMode,
+	}
+}
+
+func (m *LPPool2D) Forward(x *ts.Tensor) *ts.Tensor {
+	out := x.MustPowTensorScalar(ts.FloatScalar(m.NormType), false).
+		MustAvgPool2d(m.Kernel, m.Stride, []int64{0, 0}, m.CeilMode, true, nil, true)
+
+	return lpPoolOutput(out, m.NormType, poolParam(m.Kernel, 0)*poolParam(m.Kernel, 1))
+}
+
+// lpPoolOutput converts average of x^p over windows of `size` elements to (sum(x^p))^(1/p).
+func lpPoolOutput(avg *ts.Tensor, p float64, size int64) *ts.Tensor {
+	// sign(avg) * relu(abs(avg)) as in PyTorch to keep gradient well-defined at 0.
+	sign := avg.MustSign(false)
+	out := avg.MustAbs(true).MustRelu(true).MustMul(sign, true)
+	sign.MustDrop()
+
+	return out.MustMulScalar(ts.IntScalar(size), true).
+		MustPowTensorScalar(ts.FloatScalar(1.0/p), true)
+}
+
+// MaxUnpool1D, MaxUnpool2D, MaxUnpool3D:
+// ======================================
+
+// MaxUnpool1D computes a partial inverse of `MaxPool1D` where non-maximal values are set to zero.
+type MaxUnpool1D struct {
+	Kernel  []int64
+	Stride  []int64
+	Padding []int64
+}
+
+type MaxUnpool1DOpts struct {
+	Stride  []int64
+	Padding []int64
+}
+
+type MaxUnpool1DOpt func(*MaxUnpool1DOpts)
+
+func OptStrideMu1D(v []int64) MaxUnpool1DOpt {
+	return func(o *MaxUnpool1DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingMu1D(v []int64) MaxUnpool1DOpt {
+	return func(o *MaxUnpool1DOpts) {
+		o.Padding = v
+	}
+}
+
+func DefaultMaxUnpool1DOpts() *MaxUnpool1DOpts {
+	return &MaxUnpool1DOpts{
+		Stride:  nil,
+		Padding: []int64{0},
+	}
+}
+
+// NewMaxUnpool1D creates a max-unpooling layer. Stride defaults to kernel size.
+func NewMaxUnpool1D(kernelSize []int64, opts ...MaxUnpool1DOpt) *MaxUnpool1D {
+	o := DefaultMaxUnpool1DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &MaxUnpool1D{
+		Kernel:  kernelSize,
+		Stride:  o.Stride,
+		Padding: o.Padding,
+	}
+}
+
+// ForwardWithIndices unpools input `x` with `indices` returned by `MaxPool1D.ForwardWithIndices()`.
+//
+// Optional `outputSize` ([L] or full shape) resolves the ambiguity of output size
+// when pooling stride is greater than 1. Otherwise, it's inferred from kernel size, stride and padding.
+func (m *MaxUnpool1D) ForwardWithIndices(x, indices *ts.Tensor, outputSize ...int64) *ts.Tensor {
+	size := unpoolOutputSize(x, 1, m.Kernel, m.Stride, m.Padding, outputSize)
+
+	// Unpool as a 2D input of width 1.
+	x2 := x.MustUnsqueeze(-1, false)
+	indices2 := indices.MustUnsqueeze(-1, false)
+	out := x2.MustMaxUnpool2d(indices2, append(size, 1), true)
+	indices2.MustDrop()
+
+	return out.MustSqueezeDim(-1, true)
+}
+
+// MaxUnpool2D computes a partial inverse of `MaxPool2D` where non-maximal values are set to zero.
+type MaxUnpool2D struct {
+	Kernel  []int64
+	Stride  []int64
+	Padding []int64
+}
+
+type MaxUnpool2DOpts struct {
+	Stride  []int64
+	Padding []int64
+}
+
+type MaxUnpool2DOpt func(*MaxUnpool2DOpts)
+
+func OptStrideMu2D(v []int64) MaxUnpool2DOpt {
+	return func(o *MaxUnpool2DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingMu2D(v []int64) MaxUnpool2DOpt {
+	return func(o *MaxUnpool2DOpts) {
+		o.Padding = v
+	}
+}
+
+func DefaultMaxUnpool2DOpts() *MaxUnpool2DOpts {
+	return &MaxUnpool2DOpts{
+		Stride:  nil,
+		Padding: []int64{0, 0},
+	}
+}
+
+// NewMaxUnpool2D creates a max-unpooling layer. Stride defaults to kernel size.
+func NewMaxUnpool2D(kernelSize []int64, opts ...MaxUnpool2DOpt) *MaxUnpool2D {
+	o := DefaultMaxUnpool2DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &MaxUnpool2D{
+		Kernel:  kernelSize,
+		Stride:  o.Stride,
+		Padding: o.Padding,
+	}
+}
+
+// ForwardWithIndices unpools input `x` with `indices` returned by `MaxPool2D.ForwardWithIndices()`.
+//
+// Optional `outputSize` ([H, W] or full shape) resolves the ambiguity of output size
+// when pooling stride is greater than 1. Otherwise, it's inferred from kernel size, stride and padding.
+func (m *MaxUnpool2D) ForwardWithIndices(x, indices *ts.Tensor, outputSize ...int64) *ts.Tensor {
+	size := unpoolOutputSize(x, 2, m.Kernel, m.Stride, m.Padding, outputSize)
+
+	return x.MustMaxUnpool2d(indices, size, false)
+}
+
+// MaxUnpool3D computes a partial inverse of `MaxPool3D` where non-maximal values are set to zero.
+type MaxUnpool3D struct {
+	Kernel  []int64
+	Stride  []int64
+	Padding []int64
+}
+
+type MaxUnpool3DOpts struct {
+	Stride  []int64
+	Padding []int64
+}
+
+type MaxUnpool3DOpt func(*MaxUnpool3DOpts)
+
+func OptStrideMu3D(v []int64) MaxUnpool3DOpt {
+	return func(o *MaxUnpool3DOpts) {
+		o.Stride = v
+	}
+}
+
+func OptPaddingMu3D(v []int64) MaxUnpool3DOpt {
+	return func(o *MaxUnpool3DOpts) {
+		o.Padding = v
+	}
+}
+
+func DefaultMaxUnpool3DOpts() *MaxUnpool3DOpts {
+	return &MaxUnpool3DOpts{
+		Stride:  nil,
+		Padding: []int64{0, 0, 0},
+	}
+}
+
+// NewMaxUnpool3D creates a max-unpooling layer. Stride defaults to kernel size.
+func NewMaxUnpool3D(kernelSize []int64, opts ...MaxUnpool3DOpt) *MaxUnpool3D {
+	o := DefaultMaxUnpool3DOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Stride == nil {
+		o.Stride = kernelSize
+	}
+
+	return &MaxUnpool3D{
+		Kernel:  kernelSize,
+		Stride:  o.Stride,
+		Padding: o.Padding,
+	}
+}
+
+// ForwardWithIndices unpools input `x` with `indices` returned by `MaxPool3D.ForwardWithIndices()`.
+//
+// Optional `outputSize` ([D, H, W] or full shape) resolves the ambiguity of output size
+// when pooling stride is greater than 1. Otherwise, it's inferred from kernel size, stride and padding.
+func (m *MaxUnpool3D) ForwardWithIndices(x, indices *ts.Tensor, outputSize ...int64) *ts.Tensor {
+	size := unpoolOutputSize(x, 3, m.Kernel, m.Stride, m.Padding, outputSize)
+	stride := make([]int64, 3)
+	padding := make([]int64, 3)
+	for i := 0; i < 3; i++ {
+		stride[i] = poolParam(m.Stride, i)
+		padding[i] = poolParam(m.Padding, i)
+	}
+
+	return x.MustMaxUnpool3d(indices, size, stride, padding, false)
+}
+
+// unpoolOutputSize returns spatial output size of a max-unpooling layer of `nd` dimensions.
+func unpoolOutputSize(x *ts.Tensor, nd int, kernel, stride, padding, outputSize []int64) []int64 {
+	if len(outputSize) > 0 {
+		if len(outputSize) < nd {
+			log.Fatalf("Expected output size with at least %v elements, got %v\n", nd, outputSize)
+		}
+		size := make([]int64, nd)
+		copy(size, outputSize[len(outputSize)-nd:])
+
+		return size
+	}
+
+	shape := x.MustSize()
+	if len(shape) < nd+1 {
+		log.Fatalf("Expected an input tensor with %v or %v dims, got %v\n", nd+1, nd+2, shape)
+	}
+	spatial := shape[len(shape)-nd:]
+	size := make([]int64, nd)
+	for i := 0; i < nd; i++ {
+		size[i] = (spatial[i]-1)*poolParam(stride, i) - 2*poolParam(padding, i) + poolParam(kernel, i)
+	}
+
+	return size
+}
@@ -0,0 +1,110 @@
+package nn_test
+
+import (
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestPooling(t *testing.T) {
+	x1 := float32TensorOf([]float32{1, 3, 2, 4}, 1, 1, 4)
+	x2 := float32TensorOf([]float32{1, 2, 3, 4, 5, 6, 7, 8}, 1, 1, 2, 4)
+
+	assertAllValues(t, []valuesCase{
+		{"MaxPool1D", nn.NewMaxPool1D([]int64{2}).Forward(x1), []float64{3, 4}},
+		{"MaxPool1D stride", nn.NewMaxPool1D([]int64{2}, nn.OptStrideMp1D([]int64{1})).Forward(x1), []float64{3, 3, 4}},
+		{"MaxPool3D", nn.NewMaxPool3D([]int64{1, 2, 2}).Forward(x2.MustUnsqueeze(2, false)), []float64{6, 8}},
+		{"AvgPool1D", nn.NewAvgPool1D([]int64{2}).Forward(x1), []float64{2, 3}},
+		{"AvgPool2D", nn.NewAvgPool2D([]int64{2, 2}).Forward(x2), []float64{3.5, 5.5}},
+		{"AvgPool2D divisor override", nn.NewAvgPool2D([]int64{2, 2}, nn.OptDivisorOverrideAp2D(2)).Forward(x2), []float64{7, 11}},
+		{"AvgPool3D", nn.NewAvgPool3D([]int64{1, 2, 2}).Forward(x2.MustUnsqueeze(2, false)), []float64{3.5, 5.5}},
+		{"AdaptiveAvgPool1D", nn.NewAdaptiveAvgPool1D([]int64{1}).Forward(x1), []float64{2.5}},
+		{"AdaptiveAvgPool2D", nn.NewAdaptiveAvgPool2D([]int64{1, 2}).Forward(x2), []float64{3.5, 5.5}},
+		{"AdaptiveAvgPool3D", nn.NewAdaptiveAvgPool3D([]int64{1, 1, 1}).Forward(x2.MustUnsqueeze(2, false)), []float64{4.5}},
+		{"AdaptiveMaxPool1D", nn.NewAdaptiveMaxPool1D([]int64{1}).Forward(x1), []float64{4}},
+		{"AdaptiveMaxPool2D", nn.NewAdaptiveMaxPool2D([]int64{1, 1}).Forward(x2), []float64{8}},
+		{"AdaptiveMaxPool3D", nn.NewAdaptiveMaxPool3D([]int64{1, 1, 2}).Forward(x2.MustUnsqueeze(2, false)), []float64{6, 8}},
+		{"LPPool1D", nn.NewLPPool1D(2, []int64{2}).Forward(float32TensorOf([]float32{3, 4, 1, 0}, 1, 1, 4)), []float64{5, 1}},
+		{"LPPool2D", nn.NewLPPool2D(1, []int64{2, 2}).Forward(x2), []float64{14, 22}},
+	})
+}
+
+func TestMaxUnpool(t *testing.T) {
+	x1 := float32TensorOf([]float32{1, 3, 2, 4}, 1, 1, 4)
+	out1, idx1 := nn.NewMaxPool1D([]int64{2}).ForwardWithIndices(x1)
+	assertValues(t, "MaxPool1D indices", idx1, []float64{1, 3})
+	assertValues(t, "MaxUnpool1D", nn.NewMaxUnpool1D([]int64{2}).ForwardWithIndices(out1, idx1), []float64{0, 3, 0, 4})
+
+	x2 := float32TensorOf([]float32{1, 2, 3, 4, 5, 6, 7, 8}, 1, 1, 2, 4)
+	out2, idx2 := nn.NewMaxPool2D([]int64{2, 2}).ForwardWithIndices(x2)
+	unpooled := nn.NewMaxUnpool2D([]int64{2, 2}).ForwardWithIndices(out2, idx2)
+	assertShape(t, "MaxUnpool2D", unpooled, []int64{1, 1, 2, 4})
+	assertValues(t, "MaxUnpool2D", unpooled, []float64{0, 0, 0, 0, 0, 6, 0, 8})
+
+	// Explicit output size.
+	padded := nn.NewMaxUnpool2D([]int64{2, 2}).ForwardWithIndices(out2, idx2, 3, 5)
+	assertShape(t, "MaxUnpool2D output size", padded, []int64{1, 1, 3, 5})
+
+	x3 := x2.MustUnsqueeze(2, false)
+	out3, idx3 := nn.NewMaxPool3D([]int64{1, 2, 2}).ForwardWithIndices(x3)
+	unpooled3 := nn.NewMaxUnpool3D([]int64{1, 2, 2}).ForwardWithIndices(out3, idx3)
+	assertShape(t, "MaxUnpool3D", unpooled3, []int64{1, 1, 1, 2, 4})
+	assertValues(t, "MaxUnpool3D", unpooled3, []float64{0, 0, 0, 0, 0, 6, 0, 8})
+}
+
+func TestUpsample(t *testing.T) {
+	x1 := float32TensorOf([]float32{1, 2}, 1, 1, 2)
+
+	nearest := nn.NewUpsample(nn.OptScaleFactorUp([]float64{2})).Forward(x1)
+	assertValues(t, "Upsample nearest scale", nearest, []float64{1, 1, 2, 2})
+
+	sized := nn.NewUpsample(nn.OptSizeUp([]int64{3})).Forward(x1)
+	assertValues(t, "Upsample nearest size", sized, []float64{1, 1, 2})
+
+	linear := nn.NewUpsample(nn.OptSizeUp([]int64{3}), nn.OptModeUp("linear"), nn.OptAlignCornersUp(true)).Forward(x1)
+	assertValues(t, "Upsample linear", linear, []float64{1, 1.5, 2})
+
+	x2 := float32TensorOf([]float32{1, 2, 3, 4}, 1, 1, 2, 2)
+	for _, mode := range []string{"nearest", "bilinear", "bicubic"} {
+		out := nn.NewUpsample(nn.OptScaleFactorUp([]float64{2}), nn.OptModeUp(mode)).Forward(x2)
+		assertShape(t, "Upsample "+mode, out, []int64{1, 1, 4, 4})
+	}
+
+	out := nn.NewUpsample(nn.OptScaleFactorUp([]float64{1, 2, 3}), nn.OptModeUp("trilinear")).Forward(x2.MustUnsqueeze(2, false))
+	assertShape(t, "Upsample trilinear", out, []int64{1, 1, 1, 4, 6})
+}
+
+func TestReshapeLayers(t *testing.T) {
+	x := float32TensorOf([]float32{1, 2, 3, 4}, 1, 4, 1, 1)
+	shuffled := nn.NewPixelShuffle(2).Forward(x)
+	assertShape(t, "PixelShuffle", shuffled, []int64{1, 1, 2, 2})
+	assertValues(t, "PixelShuffle", shuffled, []float64{1, 2, 3, 4})
+	assertShape(t, "PixelUnshuffle", nn.NewPixelUnshuffle(2).Forward(shuffled), []int64{1, 4, 1, 1})
+
+	y := ts.MustOnes([]int64{2, 3, 4}, gotch.Float, gotch.CPU)
+	flat := nn.NewFlatten().Forward(y)
+	assertShape(t, "Flatten", flat, []int64{2, 12})
+	assertShape(t, "Flatten start dim", nn.NewFlatten(nn.OptStartDimFlatten(0)).Forward(y), []int64{24})
+	assertShape(t, "Unflatten", nn.NewUnflatten(1, []int64{3, 4}).Forward(flat), []int64{2, 3, 4})
+
+	z := ts.MustOnes([]int64{1, 1, 3, 3}, gotch.Float, gotch.CPU)
+	blocks := nn.NewUnfold([]int64{2, 2}).Forward(z)
+	assertShape(t, "Unfold", blocks, []int64{1, 4, 4})
+
+	// Overlapping values are summed.
+	folded := nn.NewFold([]int64{3, 3}, []int64{2, 2}).Forward(blocks)
+	assertValues(t, "Fold", folded, []float64{1, 2, 1, 2, 4, 2, 1, 2, 1})
+}
+
+func TestPooling_Seq(t *testing.T) {
+	seq := nn.Seq()
+	seq.Add(nn.NewMaxPool2D([]int64{2, 2}))
+	seq.Add(nn.NewUpsample(nn.OptScaleFactorUp([]float64{4})))
+	seq.Add(nn.NewAdaptiveAvgPool2D([]int64{2, 2}))
+	seq.Add(nn.NewFlatten())
+
+	x := ts.MustRand([]int64{3, 2, 8, 8}, gotch.Float, gotch.CPU)
+	assertShape(t, "Seq", seq.Forward(x), []int64{3, 8})
+}
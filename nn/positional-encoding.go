@@ -0,0 +1,114 @@
+package nn
+
+// Positional encodings for transformer models.
+
+import (
+	"math"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+// PositionalEncodingConfig is configuration for positional encodings.
+type PositionalEncodingConfig struct {
+	MaxLen     int64   // maximum sequence length. Default=5000
+	Dropout    float64 // dropout probability applied to output. Default=0.1
+	BatchFirst bool    // input shape is (batch, seq, feature) if true, (seq, batch, feature) otherwise. Default=false
+}
+
+// DefaultPositionalEncodingConfig creates default PositionalEncodingConfig.
+func DefaultPositionalEncodingConfig() *PositionalEncodingConfig {
+	return &PositionalEncodingConfig{
+		MaxLen:     5000,
+		Dropout:    0.1,
+		BatchFirst: false,
+	}
+}
+
+// addPositions adds position embeddings of shape (maxLen, dModel) to input
+// and applies dropout.
+func addPositions(xs, positions *ts.Tensor, c *PositionalEncodingConfig, train bool) *ts.Tensor {
+	var seqDim, posDim int64 = 0, 1
+	if c.BatchFirst {
+		seqDim, posDim = 1, 0
+	}
+	seqLen := xs.MustSize()[seqDim]
+
+	pos := positions.MustNarrow(0, 0, seqLen, false).MustUnsqueeze(posDim, true)
+	h := xs.MustAdd(pos, false)
+	pos.MustDrop()
+	out := ts.MustDropout(h, c.Dropout, train)
+	h.MustDrop()
+
+	return out
+}
+
+// SinusoidalPositionalEncoding adds fixed sine/cosine position encodings
+// as described in "Attention Is All You Need" to input.
+//
+// Encodings are stored in a non-persistent buffer "pe" of shape (maxLen, dModel).
+type SinusoidalPositionalEncoding struct {
+	PE     *ts.Tensor
+	config *PositionalEncodingConfig
+}
+
+// NewSinusoidalPositionalEncoding creates a new SinusoidalPositionalEncoding.
+func NewSinusoidalPositionalEncoding(vs *Path, dModel int64, c *PositionalEncodingConfig) *SinusoidalPositionalEncoding {
+	data := make([]float64, c.MaxLen*dModel)
+	for pos := int64(0); pos < c.MaxLen; pos++ {
+		for i := int64(0); i < dModel; i += 2 {
+			angle := float64(pos) * math.Exp(-float64(i)*math.Log(10000.0)/float64(dModel))
+			data[pos*dModel+i] = math.Sin(angle)
+			if i+1 < dModel {
+				data[pos*dModel+i+1] = math.Cos(angle)
+			}
+		}
+	}
+
+	x := ts.MustOfSlice(data).MustTotype(gotch.DefaultDType, true).MustTo(vs.Device(), true).MustView([]int64{c.MaxLen, dModel}, true)
+	pe := NewBuffer(vs, "pe", x, false)
+	x.MustDrop()
+
+	return &SinusoidalPositionalEncoding{
+		PE:     pe,
+		config: c,
+	}
+}
+
+// Forward implements Module interface for SinusoidalPositionalEncoding.
+func (pe *SinusoidalPositionalEncoding) Forward(xs *ts.Tensor) *ts.Tensor {
+	return addPositions(xs, pe.PE, pe.config, false)
+}
+
+// ForwardT implements ModuleT interface for SinusoidalPositionalEncoding.
+func (pe *SinusoidalPositionalEncoding) ForwardT(xs *ts.Tensor, train bool) *ts.Tensor {
+	return addPositions(xs, pe.PE, pe.config, train)
+}
+
+// LearnedPositionalEncoding adds trainable position embeddings to input.
+//
+// Embeddings are stored in variable "weight" of shape (maxLen, dModel) the same
+// as an `Embedding` layer, e.g. "position_embeddings.weight" of HuggingFace models.
+type LearnedPositionalEncoding struct {
+	Ws     *ts.Tensor
+	config *PositionalEncodingConfig
+}
+
+// NewLearnedPositionalEncoding creates a new LearnedPositionalEncoding with
+// weights initialized from normal distribution N(0, 0.02).
+func NewLearnedPositionalEncoding(vs *Path, dModel int64, c *PositionalEncodingConfig) *LearnedPositionalEncoding {
+	return &LearnedPositionalEncoding{
+		Ws:     vs.MustNewVar("weight", []int64{c.MaxLen, dModel}, NewRandnInit(0.0, 0.02)),
+		config: c,
+	}
+}
+
+// Forward implements Module interface for LearnedPositionalEncoding.
+func (pe *LearnedPositionalEncoding) Forward(xs *ts.Tensor) *ts.Tensor {
+	return addPositions(xs, pe.Ws, pe.config, false)
+}
+
+// ForwardT implements ModuleT interface for LearnedPositionalEncoding.
+func (pe *LearnedPositionalEncoding) ForwardT(xs *ts.Tensor, train bool) *ts.Tensor {
+	return addPositions(xs, pe.Ws, pe.config, train)
+}
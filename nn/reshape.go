@@ -0,0 +1,240 @@
+package nn
+
+// Reshaping layers.
+
+import (
+	"github.com/sugarme/gotch/ts"
+)
+
+// PixelShuffle:
+// =============
+
+// PixelShuffle rearranges input of shape (*, C*r^2, H, W) to (*, C, H*r, W*r)
+// where r is the upscale factor.
+type PixelShuffle struct {
+	UpscaleFactor int64
+}
+
+func NewPixelShuffle(upscaleFactor int64) *PixelShuffle {
+	return &PixelShuffle{upscaleFactor}
+}
+
+func (m *PixelShuffle) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustPixelShuffle(m.UpscaleFactor, false)
+}
+
+// PixelUnshuffle:
+// ===============
+
+// PixelUnshuffle reverses `PixelShuffle`. It rearranges input of shape
+// (*, C, H*r, W*r) to (*, C*r^2, H, W) where r is the downscale factor.
+type PixelUnshuffle struct {
+	DownscaleFactor int64
+}
+
+func NewPixelUnshuffle(downscaleFactor int64) *PixelUnshuffle {
+	return &PixelUnshuffle{downscaleFactor}
+}
+
+func (m *PixelUnshuffle) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustPixelUnshuffle(m.DownscaleFactor, false)
+}
+
+// Flatten:
+// ========
+
+// Flatten flattens a contiguous range of dims [StartDim, EndDim] into one dim.
+type Flatten struct {
+	StartDim int64
+	EndDim   int64
+}
+
+type FlattenOpts struct {
+	StartDim int64
+	EndDim   int64
+}
+
+type FlattenOpt func(*FlattenOpts)
+
+func OptStartDimFlatten(v int64) FlattenOpt {
+	return func(o *FlattenOpts) {
+		o.StartDim = v
+	}
+}
+
+func OptEndDimFlatten(v int64) FlattenOpt {
+	return func(o *FlattenOpts) {
+		o.EndDim = v
+	}
+}
+
+// DefaultFlattenOpts flattens all dims but the first (batch) dim.
+func DefaultFlattenOpts() *FlattenOpts {
+	return &FlattenOpts{
+		StartDim: 1,
+		EndDim:   -1,
+	}
+}
+
+func NewFlatten(opts ...FlattenOpt) *Flatten {
+	o := DefaultFlattenOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Flatten{
+		StartDim: o.StartDim,
+		EndDim:   o.EndDim,
+	}
+}
+
+func (m *Flatten) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustFlatten(m.StartDim, m.EndDim, false)
+}
+
+// Unflatten:
+// ==========
+
+// Unflatten expands dim `Dim` of input to shape `Sizes`.
+type Unflatten struct {
+	Dim   int64
+	Sizes []int64
+}
+
+func NewUnflatten(dim int64, sizes []int64) *Unflatten {
+	return &Unflatten{dim, sizes}
+}
+
+func (m *Unflatten) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustUnflatten(m.Dim, m.Sizes, false)
+}
+
+// Fold:
+// =====
+
+// Fold combines an array of sliding local blocks of shape (N, C*prod(Kernel), L)
+// into a tensor of shape (N, C, OutputSize[0], OutputSize[1]). Overlapping values are summed.
+type Fold struct {
+	OutputSize []int64
+	Kernel     []int64
+	Dilation   []int64
+	Padding    []int64
+	Stride     []int64
+}
+
+type FoldOpts struct {
+	Dilation []int64
+	Padding  []int64
+	Stride   []int64
+}
+
+type FoldOpt func(*FoldOpts)
+
+func OptDilationFold(v []int64) FoldOpt {
+	return func(o *FoldOpts) {
+		o.Dilation = v
+	}
+}
+
+func OptPaddingFold(v []int64) FoldOpt {
+	return func(o *FoldOpts) {
+		o.Padding = v
+	}
+}
+
+func OptStrideFold(v []int64) FoldOpt {
+	return func(o *FoldOpts) {
+		o.Stride = v
+	}
+}
+
+func DefaultFoldOpts() *FoldOpts {
+	return &FoldOpts{
+		Dilation: []int64{1, 1},
+		Padding:  []int64{0, 0},
+		Stride:   []int64{1, 1},
+	}
+}
+
+func NewFold(outputSize, kernelSize []int64, opts ...FoldOpt) *Fold {
+	o := DefaultFoldOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Fold{
+		OutputSize: outputSize,
+		Kernel:     kernelSize,
+		Dilation:   o.Dilation,
+		Padding:    o.Padding,
+		Stride:     o.Stride,
+	}
+}
+
+func (m *Fold) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustCol2im(m.OutputSize, m.Kernel, m.Dilation, m.Padding, m.Stride, false)
+}
+
+// Unfold:
+// =======
+
+// Unfold extracts sliding local blocks from input of shape (N, C, H, W)
+// into a tensor of shape (N, C*prod(Kernel), L) where L is the number of blocks.
+type Unfold struct {
+	Kernel   []int64
+	Dilation []int64
+	Padding  []int64
+	Stride   []int64
+}
+
+type UnfoldOpts struct {
+	Dilation []int64
+	Padding  []int64
+	Stride   []int64
+}
+
+type UnfoldOpt func(*UnfoldOpts)
+
+func OptDilationUnfold(v []int64) UnfoldOpt {
+	return func(o *UnfoldOpts) {
+		o.Dilation = v
+	}
+}
+
+func OptPaddingUnfold(v []int64) UnfoldOpt {
+	return func(o *UnfoldOpts) {
+		o.Padding = v
+	}
+}
+
+func OptStrideUnfold(v []int64) UnfoldOpt {
+	return func(o *UnfoldOpts) {
+		o.Stride = v
+	}
+}
+
+func DefaultUnfoldOpts() *UnfoldOpts {
+	return &UnfoldOpts{
+		Dilation: []int64{1, 1},
+		Padding:  []int64{0, 0},
+		Stride:   []int64{1, 1},
+	}
+}
+
+func NewUnfold(kernelSize []int64, opts ...UnfoldOpt) *Unfold {
+	o := DefaultUnfoldOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Unfold{
+		Kernel:   kernelSize,
+		Dilation: o.Dilation,
+		Padding:  o.Padding,
+		Stride:   o.Stride,
+	}
+}
+
+func (m *Unfold) Forward(x *ts.Tensor) *ts.Tensor {
+	return x.MustIm2col(m.Kernel, m.Dilation, m.Padding, m.Stride, false)
+}
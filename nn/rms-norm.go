@@ -0,0 +1,74 @@
+package nn
+
+// A root-mean-square normalization layer.
+
+import (
+	"log"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// RMS-normalization config.
+type RMSNormConfig struct {
+	Eps               float64
+	ElementwiseAffine bool
+	WsInit            Init
+	WsName            string // Default="weight", can change to e.g., "scale"
+}
+
+func DefaultRMSNormConfig() *RMSNormConfig {
+	return &RMSNormConfig{
+		Eps:               1e-6,
+		ElementwiseAffine: true,
+		WsInit:            NewConstInit(1.0),
+		WsName:            "weight",
+	}
+}
+
+// A root-mean-square normalization layer.
+//
+// It's computed as: y = x / sqrt(mean(x^2) + eps) * weight
+// where the mean is taken over the last len(NormalizedShape) dimensions.
+// See https://arxiv.org/abs/1910.07467
+type RMSNorm struct {
+	Config          *RMSNormConfig
+	Ws              *ts.Tensor // optional
+	NormalizedShape []int64
+}
+
+func NewRMSNorm(vs *Path, normalizedShape []int64, config *RMSNormConfig) *RMSNorm {
+	var ws *ts.Tensor
+	if config.ElementwiseAffine {
+		ws = vs.MustNewVar(config.WsName, normalizedShape, config.WsInit)
+	}
+
+	return &RMSNorm{config, ws, normalizedShape}
+}
+
+// Implement Module interface for RMSNorm:
+// =======================================
+
+func (rn *RMSNorm) Forward(xs *ts.Tensor) (retVal *ts.Tensor) {
+	n := len(rn.NormalizedShape)
+	if int(xs.Dim()) < n {
+		log.Fatalf("Expected an input tensor with at least %v dims, got %v\n", n, xs.MustSize())
+	}
+
+	dims := make([]int64, n)
+	for i := range dims {
+		dims[i] = int64(-n + i)
+	}
+
+	rrms := xs.MustPowTensorScalar(ts.FloatScalar(2), false).
+		MustMeanDim(dims, true, xs.DType(), true).
+		MustAddScalar(ts.FloatScalar(rn.Config.Eps), true).
+		MustRsqrt(true)
+	out := xs.MustMul(rrms, false)
+	rrms.MustDrop()
+
+	if rn.Config.ElementwiseAffine {
+		return out.MustMul(rn.Ws, true)
+	}
+
+	return out
+}
@@ -57,6 +57,151 @@ func (s *LRScheduler) Step(opts ...SchedulerOption) {
 	s.scheduler.SetLRs(opts...)
 }
 
+// statefulScheduler is a scheduler that can save and restore its state.
+type statefulScheduler interface {
+	StateDict() map[string]interface{}
+	LoadStateDict(state map[string]interface{}) error
+}
+
+// StateDict returns scheduler state similar to Pytorch `scheduler.state_dict()`.
+// It contains counters (e.g. "last_epoch") and learning rates the scheduler
+// keeps track of. Values are of type int, float64 or []float64.
+func (s *LRScheduler) StateDict() (map[string]interface{}, error) {
+	ss, ok := s.scheduler.(statefulScheduler)
+	if !ok {
+		err := fmt.Errorf("LRScheduler.StateDict() failed: scheduler %T does not support state dict", s.scheduler)
+		return nil, err
+	}
+
+	return ss.StateDict(), nil
+}
+
+// LoadStateDict loads scheduler state returned by `StateDict()`.
+func (s *LRScheduler) LoadStateDict(state map[string]interface{}) error {
+	ss, ok := s.scheduler.(statefulScheduler)
+	if !ok {
+		err := fmt.Errorf("LRScheduler.LoadStateDict() failed: scheduler %T does not support state dict", s.scheduler)
+		return err
+	}
+
+	if err := ss.LoadStateDict(state); err != nil {
+		err = fmt.Errorf("LRScheduler.LoadStateDict() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// stateInt gets an integer value from state dict. It accepts value of numeric
+// types so that state dict can be restored from its JSON form.
+func stateInt(state map[string]interface{}, key string) (int, error) {
+	v, ok := state[key]
+	if !ok {
+		err := fmt.Errorf("missing %q in state dict", key)
+		return 0, err
+	}
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	default:
+		err := fmt.Errorf("invalid type %T of %q in state dict", v, key)
+		return 0, err
+	}
+}
+
+// stateFloat gets a float value from state dict. Non-finite values can be
+// given as string "inf", "-inf" or "nan".
+func stateFloat(state map[string]interface{}, key string) (float64, error) {
+	v, ok := state[key]
+	if !ok {
+		err := fmt.Errorf("missing %q in state dict", key)
+		return 0, err
+	}
+
+	return toFloat(v, key)
+}
+
+func toFloat(v interface{}, key string) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		switch v {
+		case "inf":
+			return math.Inf(1), nil
+		case "-inf":
+			return math.Inf(-1), nil
+		case "nan":
+			return math.NaN(), nil
+		}
+	}
+
+	err := fmt.Errorf("invalid value %v (%T) of %q in state dict", v, v, key)
+	return 0, err
+}
+
+// stateFloats gets a float slice from state dict.
+func stateFloats(state map[string]interface{}, key string) ([]float64, error) {
+	v, ok := state[key]
+	if !ok {
+		err := fmt.Errorf("missing %q in state dict", key)
+		return nil, err
+	}
+	switch v := v.(type) {
+	case []float64:
+		out := make([]float64, len(v))
+		copy(out, v)
+		return out, nil
+	case []interface{}:
+		out := make([]float64, len(v))
+		for i, x := range v {
+			f, err := toFloat(x, key)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = f
+		}
+		return out, nil
+	default:
+		err := fmt.Errorf("invalid type %T of %q in state dict", v, key)
+		return nil, err
+	}
+}
+
+// epochState is state of schedulers that compute learning rates from initial
+// learning rates and epoch counters.
+func epochState(initialLRs []float64, lastEpoch, stepCount int) map[string]interface{} {
+	baseLRs := make([]float64, len(initialLRs))
+	copy(baseLRs, initialLRs)
+	return map[string]interface{}{
+		"base_lrs":   baseLRs,
+		"last_epoch": lastEpoch,
+		"step_count": stepCount,
+	}
+}
+
+func loadEpochState(state map[string]interface{}) (initialLRs []float64, lastEpoch, stepCount int, err error) {
+	if initialLRs, err = stateFloats(state, "base_lrs"); err != nil {
+		return nil, 0, 0, err
+	}
+	if lastEpoch, err = stateInt(state, "last_epoch"); err != nil {
+		return nil, 0, 0, err
+	}
+	if stepCount, err = stateInt(state, "step_count"); err != nil {
+		return nil, 0, 0, err
+	}
+
+	return initialLRs, lastEpoch, stepCount, nil
+}
+
 type LambdaFn func(in interface{}) float64
 
 // LamdaLR calculates new learning rate for each parameter group by applying
@@ -102,6 +247,22 @@ func (l *LambdaLR) Build() *LRScheduler {
 	return s
 }
 
+// StateDict implements scheduler state.
+func (l *LambdaLR) StateDict() map[string]interface{} {
+	return epochState(l.initialLRs, l.lastEpoch, l.stepCount)
+}
+
+// LoadStateDict implements scheduler state.
+func (l *LambdaLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, lastEpoch, stepCount, err := loadEpochState(state)
+	if err != nil {
+		return err
+	}
+	l.initialLRs, l.lastEpoch, l.stepCount = initialLRs, lastEpoch, stepCount
+
+	return nil
+}
+
 // SetLRs implements scheduler interface.
 func (l *LambdaLR) SetLRs(opts ...SchedulerOption) {
 	options := defaultSchedulerOptions()
@@ -174,6 +335,22 @@ func (m *MultiplicativeLR) Build() *LRScheduler {
 	return s
 }
 
+// StateDict implements scheduler state.
+func (m *MultiplicativeLR) StateDict() map[string]interface{} {
+	return epochState(m.initialLRs, m.lastEpoch, m.stepCount)
+}
+
+// LoadStateDict implements scheduler state.
+func (m *MultiplicativeLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, lastEpoch, stepCount, err := loadEpochState(state)
+	if err != nil {
+		return err
+	}
+	m.initialLRs, m.lastEpoch, m.stepCount = initialLRs, lastEpoch, stepCount
+
+	return nil
+}
+
 // SetLRs implements scheduler interface.
 func (m *MultiplicativeLR) SetLRs(opts ...SchedulerOption) {
 	options := defaultSchedulerOptions()
@@ -241,6 +418,22 @@ func (s *StepLR) Build() *LRScheduler {
 	return sc
 }
 
+// StateDict implements scheduler state.
+func (s *StepLR) StateDict() map[string]interface{} {
+	return epochState(s.initialLRs, s.lastEpoch, s.stepCount)
+}
+
+// LoadStateDict implements scheduler state.
+func (s *StepLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, lastEpoch, stepCount, err := loadEpochState(state)
+	if err != nil {
+		return err
+	}
+	s.initialLRs, s.lastEpoch, s.stepCount = initialLRs, lastEpoch, stepCount
+
+	return nil
+}
+
 // SetLRs implements scheduler interface.
 func (s *StepLR) SetLRs(opts ...SchedulerOption) {
 	options := defaultSchedulerOptions()
@@ -317,6 +510,22 @@ func (ms *MultiStepLR) Build() *LRScheduler {
 	return s
 }
 
+// StateDict implements scheduler state.
+func (ms *MultiStepLR) StateDict() map[string]interface{} {
+	return epochState(ms.initialLRs, ms.lastEpoch, ms.stepCount)
+}
+
+// LoadStateDict implements scheduler state.
+func (ms *MultiStepLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, lastEpoch, stepCount, err := loadEpochState(state)
+	if err != nil {
+		return err
+	}
+	ms.initialLRs, ms.lastEpoch, ms.stepCount = initialLRs, lastEpoch, stepCount
+
+	return nil
+}
+
 // SetLRs implements scheduler interface.
 func (ms *MultiStepLR) SetLRs(opts ...SchedulerOption) {
 	options := defaultSchedulerOptions()
@@ -388,6 +597,22 @@ func (e *ExponentialLR) Build() *LRScheduler {
 	return s
 }
 
+// StateDict implements scheduler state.
+func (e *ExponentialLR) StateDict() map[string]interface{} {
+	return epochState(e.initialLRs, e.lastEpoch, e.stepCount)
+}
+
+// LoadStateDict implements scheduler state.
+func (e *ExponentialLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, lastEpoch, stepCount, err := loadEpochState(state)
+	if err != nil {
+		return err
+	}
+	e.initialLRs, e.lastEpoch, e.stepCount = initialLRs, lastEpoch, stepCount
+
+	return nil
+}
+
 // SetLRs implements scheduler interface.
 func (e *ExponentialLR) SetLRs(opts ...SchedulerOption) {
 	options := defaultSchedulerOptions()
@@ -458,6 +683,22 @@ func (ca *CosineAnnealingLR) Build() *LRScheduler {
 	return s
 }
 
+// StateDict implements scheduler state.
+func (ca *CosineAnnealingLR) StateDict() map[string]interface{} {
+	return epochState(ca.initialLRs, ca.lastEpoch, ca.stepCount)
+}
+
+// LoadStateDict implements scheduler state.
+func (ca *CosineAnnealingLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, lastEpoch, stepCount, err := loadEpochState(state)
+	if err != nil {
+		return err
+	}
+	ca.initialLRs, ca.lastEpoch, ca.stepCount = initialLRs, lastEpoch, stepCount
+
+	return nil
+}
+
 // SetLRs implements scheduler interface.
 func (ca *CosineAnnealingLR) SetLRs(opts ...SchedulerOption) {
 	options := defaultSchedulerOptions()
@@ -803,6 +1044,41 @@ func (s *ReduceLROnPlateau) Build() *LRScheduler {
 	return &LRScheduler{s}
 }
 
+// StateDict implements scheduler state.
+//
+// NOTE. "best" can be infinite and is not JSON serializable as is.
+func (s *ReduceLROnPlateau) StateDict() map[string]interface{} {
+	return map[string]interface{}{
+		"best":             s.best,
+		"num_bad_epochs":   s.numBadEpochs,
+		"cooldown_counter": s.cooldownCounter,
+		"last_epoch":       s.lastEpoch,
+	}
+}
+
+// LoadStateDict implements scheduler state.
+func (s *ReduceLROnPlateau) LoadStateDict(state map[string]interface{}) error {
+	best, err := stateFloat(state, "best")
+	if err != nil {
+		return err
+	}
+	numBadEpochs, err := stateInt(state, "num_bad_epochs")
+	if err != nil {
+		return err
+	}
+	cooldownCounter, err := stateInt(state, "cooldown_counter")
+	if err != nil {
+		return err
+	}
+	lastEpoch, err := stateInt(state, "last_epoch")
+	if err != nil {
+		return err
+	}
+	s.best, s.numBadEpochs, s.cooldownCounter, s.lastEpoch = best, numBadEpochs, cooldownCounter, lastEpoch
+
+	return nil
+}
+
 func floatMax(v1, v2 float64) float64 {
 	if v1 >= v2 {
 		return v1
@@ -1182,6 +1458,36 @@ func (cyc *CyclicLR) Build() *LRScheduler {
 	return &LRScheduler{cyc}
 }
 
+// StateDict implements scheduler state.
+func (cyc *CyclicLR) StateDict() map[string]interface{} {
+	state := epochState(cyc.initialLRs, cyc.lastEpoch, 0)
+	delete(state, "step_count")
+	maxLRs := make([]float64, len(cyc.maxLRs))
+	copy(maxLRs, cyc.maxLRs)
+	state["max_lrs"] = maxLRs
+
+	return state
+}
+
+// LoadStateDict implements scheduler state.
+func (cyc *CyclicLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, err := stateFloats(state, "base_lrs")
+	if err != nil {
+		return err
+	}
+	maxLRs, err := stateFloats(state, "max_lrs")
+	if err != nil {
+		return err
+	}
+	lastEpoch, err := stateInt(state, "last_epoch")
+	if err != nil {
+		return err
+	}
+	cyc.initialLRs, cyc.maxLRs, cyc.lastEpoch = initialLRs, maxLRs, lastEpoch
+
+	return nil
+}
+
 // CosineAnnealingWarmRestart sets the learning rate of each parameter group
 /// using a cosine annealing schedule.
 //
@@ -1340,6 +1646,35 @@ func (s *CosineAnnealingWarmRestarts) Build() *LRScheduler {
 	return scheduler
 }
 
+// StateDict implements scheduler state.
+func (s *CosineAnnealingWarmRestarts) StateDict() map[string]interface{} {
+	state := epochState(s.initialLRs, s.lastEpoch, s.stepCount)
+	state["t_cur"] = s.tcur
+	state["t_i"] = s.ti
+
+	return state
+}
+
+// LoadStateDict implements scheduler state.
+func (s *CosineAnnealingWarmRestarts) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, lastEpoch, stepCount, err := loadEpochState(state)
+	if err != nil {
+		return err
+	}
+	tcur, err := stateInt(state, "t_cur")
+	if err != nil {
+		return err
+	}
+	ti, err := stateInt(state, "t_i")
+	if err != nil {
+		return err
+	}
+	s.initialLRs, s.lastEpoch, s.stepCount = initialLRs, lastEpoch, stepCount
+	s.tcur, s.ti = tcur, ti
+
+	return nil
+}
+
 // OneCycleLR sets the learning rate of each parameter group according to the
 // 1cycle learning rate policy. The 1cycle policy anneals the learning
 // rate from an initial learning rate to some maximum learning rate and then
@@ -1715,3 +2050,40 @@ func (oc *OneCycleLR) Build() *LRScheduler {
 	s.Step()
 	return s
 }
+
+// StateDict implements scheduler state.
+func (oc *OneCycleLR) StateDict() map[string]interface{} {
+	state := epochState(oc.initialLRs, oc.lastEpoch, 0)
+	delete(state, "step_count")
+	maxLRs := make([]float64, len(oc.maxLRs))
+	copy(maxLRs, oc.maxLRs)
+	minLRs := make([]float64, len(oc.minLRs))
+	copy(minLRs, oc.minLRs)
+	state["max_lrs"] = maxLRs
+	state["min_lrs"] = minLRs
+
+	return state
+}
+
+// LoadStateDict implements scheduler state.
+func (oc *OneCycleLR) LoadStateDict(state map[string]interface{}) error {
+	initialLRs, err := stateFloats(state, "base_lrs")
+	if err != nil {
+		return err
+	}
+	maxLRs, err := stateFloats(state, "max_lrs")
+	if err != nil {
+		return err
+	}
+	minLRs, err := stateFloats(state, "min_lrs")
+	if err != nil {
+		return err
+	}
+	lastEpoch, err := stateInt(state, "last_epoch")
+	if err != nil {
+		return err
+	}
+	oc.initialLRs, oc.maxLRs, oc.minLRs, oc.lastEpoch = initialLRs, maxLRs, minLRs, lastEpoch
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/ts"
 )
 
 func TestLambdaLR(t *testing.T) {
@@ -279,3 +280,92 @@ func TestOneCycleLR(t *testing.T) {
 	// t.Logf("Lrs: %+v\n", lrs)
 	t.Log(model)
 }
+
+func TestLRScheduler_StateDict(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	opt, err := nn.DefaultSGDConfig().Build(vs, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := nn.NewStepLR(opt, 2, 0.5).Build()
+	for i := 0; i < 3; i++ {
+		s.Step()
+	}
+	state, err := s.StateDict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state["last_epoch"] != 3 {
+		t.Errorf("want last_epoch 3, got %v", state["last_epoch"])
+	}
+
+	// Resume on a new optimizer and scheduler.
+	opt2, err := nn.DefaultSGDConfig().Build(nn.NewVarStore(gotch.CPU), 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2 := nn.NewStepLR(opt2, 2, 0.5).Build()
+	if err := s2.LoadStateDict(state); err != nil {
+		t.Fatal(err)
+	}
+	opt2.SetLRs(opt.GetLRs())
+	for i := 0; i < 4; i++ {
+		s.Step()
+		s2.Step()
+		if want, got := opt.GetLRs()[0], opt2.GetLRs()[0]; want != got {
+			t.Errorf("step %v: want lr %v, got %v", i, want, got)
+		}
+	}
+
+	// Non-finite values.
+	plateau := nn.NewReduceLROnPlateau(opt).Build()
+	state, err = plateau.StateDict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state["best"] = "inf"
+	if err := plateau.LoadStateDict(state); err != nil {
+		t.Fatal(err)
+	}
+	state, _ = plateau.StateDict()
+	if !math.IsInf(state["best"].(float64), 1) {
+		t.Errorf("want best +inf, got %v", state["best"])
+	}
+}
+
+func TestLRScheduler_StepCountScaled(t *testing.T) {
+	train := func(scaled bool) *nn.Optimizer {
+		vs := nn.NewVarStore(gotch.CPU)
+		model := nn.NewLinear(vs.Root(), 2, 1, nn.DefaultLinearConfig())
+		opt, err := nn.DefaultSGDConfig().Build(vs, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := nn.NewStepLR(opt, 2, 0.5).Build()
+		scaler := nn.NewGradScaler(nn.DefaultGradScalerConfig())
+
+		x := ts.MustOnes([]int64{1, 2}, gotch.Float, gotch.CPU)
+		for i := 0; i < 4; i++ {
+			loss := model.Forward(x).MustSum(gotch.Float, true)
+			if scaled {
+				opt.MustBackwardStepScaled(loss, scaler)
+			} else {
+				opt.MustBackwardStep(loss)
+			}
+			loss.MustDrop()
+			s.Step()
+		}
+
+		return opt
+	}
+
+	opt := train(false)
+	scaledOpt := train(true)
+	if opt.StepCount() != 4 || scaledOpt.StepCount() != 4 {
+		t.Errorf("want step count 4 with and without grad scaling, got %v and %v", opt.StepCount(), scaledOpt.StepCount())
+	}
+	if got, want := scaledOpt.GetLRs()[0], opt.GetLRs()[0]; got != want {
+		t.Errorf("want LR %v with grad scaling, got %v", want, got)
+	}
+}
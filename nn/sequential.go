@@ -224,9 +224,9 @@ func (fw ForwardTWith) ForwardT(xs *ts.Tensor, train bool) *ts.Tensor {
 // it sets a global flag that is checked by the backend whenever an op is done on a variable.
 // The guard itself saved the current status and set it to false in the constructor.
 // And restore the saved status in it’s destructor. That way it is similar to a with torch.no_grad(): block in python.
-// This seems not working in Go.
-// There 2 ways to get around. One is freeze VarStore, the other is
-// set manually set AutoGrad at `loss` tensor. I.e., `loss = loss.MustSetRequiresGrad(true)`
+// Goroutines can migrate between OS threads, so the thread local flag only works if the
+// goroutine is locked to its OS thread as `ts.NoGrad()` does.
+// Here VarStore is frozen instead so that variables don't require grad.
 func BatchAccuracyForLogits(vs *VarStore, m ts.ModuleT, xs, ys *ts.Tensor, d gotch.Device, batchSize int) (retVal float64) {
 
 	var (
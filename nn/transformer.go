@@ -0,0 +1,431 @@
+package nn
+
+// Transformer layers.
+
+import (
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+// TransformerLayerConfig is configuration for TransformerEncoderLayer and TransformerDecoderLayer.
+type TransformerLayerConfig struct {
+	DimFeedforward int64   // dimension of feedforward network. Default=2048
+	Dropout        float64 // dropout probability. Default=0.1
+	Activation     string  // activation of feedforward network, either "relu" or "gelu". Default="relu"
+	LayerNormEps   float64 // eps of layer normalization. Default=1e-5
+	BatchFirst     bool    // input/output shape is (batch, seq, feature) if true, (seq, batch, feature) otherwise. Default=false
+	NormFirst      bool    // apply layer normalization before attention and feedforward (pre-norm) if true. Default=false
+}
+
+// DefaultTransformerLayerConfig creates default TransformerLayerConfig.
+func DefaultTransformerLayerConfig() *TransformerLayerConfig {
+	return &TransformerLayerConfig{
+		DimFeedforward: 2048,
+		Dropout:        0.1,
+		Activation:     "relu",
+		LayerNormEps:   1e-5,
+		BatchFirst:     false,
+		NormFirst:      false,
+	}
+}
+
+// TransformerOptions are masks for Transformer forward pass.
+//
+// Masks have the same semantics as options of `MultiheadAttention.ForwardAttn()`.
+type TransformerOptions struct {
+	SrcMask              *ts.Tensor
+	SrcKeyPaddingMask    *ts.Tensor
+	SrcIsCausal          bool
+	TgtMask              *ts.Tensor
+	TgtKeyPaddingMask    *ts.Tensor
+	TgtIsCausal          bool
+	MemoryMask           *ts.Tensor
+	MemoryKeyPaddingMask *ts.Tensor
+}
+
+type TransformerOption func(*TransformerOptions)
+
+func defaultTransformerOptions() *TransformerOptions {
+	return &TransformerOptions{}
+}
+
+func WithSrcMask(mask *ts.Tensor) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.SrcMask = mask
+	}
+}
+
+func WithSrcKeyPaddingMask(mask *ts.Tensor) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.SrcKeyPaddingMask = mask
+	}
+}
+
+func WithSrcIsCausal(v bool) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.SrcIsCausal = v
+	}
+}
+
+func WithTgtMask(mask *ts.Tensor) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.TgtMask = mask
+	}
+}
+
+func WithTgtKeyPaddingMask(mask *ts.Tensor) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.TgtKeyPaddingMask = mask
+	}
+}
+
+func WithTgtIsCausal(v bool) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.TgtIsCausal = v
+	}
+}
+
+func WithMemoryMask(mask *ts.Tensor) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.MemoryMask = mask
+	}
+}
+
+func WithMemoryKeyPaddingMask(mask *ts.Tensor) TransformerOption {
+	return func(o *TransformerOptions) {
+		o.MemoryKeyPaddingMask = mask
+	}
+}
+
+// GenerateSquareSubsequentMask creates a float causal mask of shape (size, size)
+// with -inf above the diagonal and 0 elsewhere.
+func GenerateSquareSubsequentMask(size int64, device gotch.Device) *ts.Tensor {
+	return ts.MustFull([]int64{size, size}, ts.FloatScalar(math.Inf(-1)), gotch.DefaultDType, device).MustTriu(1, true)
+}
+
+func checkActivation(fn, activation string) {
+	switch activation {
+	case "relu", "gelu":
+	default:
+		log.Fatalf("%v() failed: unsupported activation %q. Expected 'relu' or 'gelu'\n", fn, activation)
+	}
+}
+
+// feedForward applies linear2(dropout(activation(linear1(x)))) followed by dropout.
+func feedForward(xs *ts.Tensor, linear1, linear2 *Linear, c *TransformerLayerConfig, train bool) *ts.Tensor {
+	h := linear1.Forward(xs)
+	switch c.Activation {
+	case "gelu":
+		h = h.MustGelu("none", true)
+	default:
+		h = h.MustRelu(true)
+	}
+	d := ts.MustDropout(h, c.Dropout, train)
+	h.MustDrop()
+	h = linear2.Forward(d)
+	d.MustDrop()
+	out := ts.MustDropout(h, c.Dropout, train)
+	h.MustDrop()
+
+	return out
+}
+
+// attnBlock applies attention followed by dropout.
+func attnBlock(attn *MultiheadAttention, query, kv *ts.Tensor, c *TransformerLayerConfig, train bool, opts ...AttentionOption) *ts.Tensor {
+	h, _ := attn.ForwardAttn(query, kv, kv, train, opts...)
+	out := ts.MustDropout(h, c.Dropout, train)
+	h.MustDrop()
+
+	return out
+}
+
+// residual applies x + block(norm(x)) if NormFirst is set, norm(x + block(x)) otherwise.
+// If del is true, x is deleted.
+func residual(xs *ts.Tensor, norm *LayerNorm, normFirst, del bool, block func(*ts.Tensor) *ts.Tensor) *ts.Tensor {
+	if normFirst {
+		h := norm.Forward(xs)
+		b := block(h)
+		h.MustDrop()
+		out := xs.MustAdd(b, del)
+		b.MustDrop()
+		return out
+	}
+
+	b := block(xs)
+	h := xs.MustAdd(b, del)
+	b.MustDrop()
+	out := norm.Forward(h)
+	h.MustDrop()
+
+	return out
+}
+
+func newTransformerLayerNorm(vs *Path, dModel int64, c *TransformerLayerConfig) *LayerNorm {
+	cfg := DefaultLayerNormConfig()
+	cfg.Eps = c.LayerNormEps
+	return NewLayerNorm(vs, []int64{dModel}, cfg)
+}
+
+// TransformerEncoderLayer is made up of self-attention and feedforward network.
+//
+// Variables are named the same as PyTorch `nn.TransformerEncoderLayer`:
+// "self_attn", "linear1", "linear2", "norm1", "norm2".
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.TransformerEncoderLayer.html
+type TransformerEncoderLayer struct {
+	SelfAttn *MultiheadAttention
+	Linear1  *Linear
+	Linear2  *Linear
+	Norm1    *LayerNorm
+	Norm2    *LayerNorm
+	config   *TransformerLayerConfig
+}
+
+// NewTransformerEncoderLayer creates a new TransformerEncoderLayer.
+//
+// dModel - number of expected features in the input.
+// nhead - number of attention heads.
+func NewTransformerEncoderLayer(vs *Path, dModel, nhead int64, c *TransformerLayerConfig) *TransformerEncoderLayer {
+	checkActivation("NewTransformerEncoderLayer", c.Activation)
+
+	attnCfg := DefaultMultiheadAttentionConfig()
+	attnCfg.Dropout = c.Dropout
+	attnCfg.BatchFirst = c.BatchFirst
+
+	return &TransformerEncoderLayer{
+		SelfAttn: NewMultiheadAttention(vs.Sub("self_attn"), dModel, nhead, attnCfg),
+		Linear1:  NewLinear(vs.Sub("linear1"), dModel, c.DimFeedforward, DefaultLinearConfig()),
+		Linear2:  NewLinear(vs.Sub("linear2"), c.DimFeedforward, dModel, DefaultLinearConfig()),
+		Norm1:    newTransformerLayerNorm(vs.Sub("norm1"), dModel, c),
+		Norm2:    newTransformerLayerNorm(vs.Sub("norm2"), dModel, c),
+		config:   c,
+	}
+}
+
+// ForwardMasked passes input through the encoder layer with masks
+// given by options `WithSrcMask()`, `WithSrcKeyPaddingMask()` and `WithSrcIsCausal()`.
+func (l *TransformerEncoderLayer) ForwardMasked(src *ts.Tensor, train bool, opts ...TransformerOption) *ts.Tensor {
+	o := defaultTransformerOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c := l.config
+	x := residual(src, l.Norm1, c.NormFirst, false, func(h *ts.Tensor) *ts.Tensor {
+		return attnBlock(l.SelfAttn, h, h, c, train, WithAttnMask(o.SrcMask), WithKeyPaddingMask(o.SrcKeyPaddingMask), WithIsCausal(o.SrcIsCausal))
+	})
+	x = residual(x, l.Norm2, c.NormFirst, true, func(h *ts.Tensor) *ts.Tensor {
+		return feedForward(h, l.Linear1, l.Linear2, c, train)
+	})
+
+	return x
+}
+
+// Forward implements Module interface for TransformerEncoderLayer.
+func (l *TransformerEncoderLayer) Forward(src *ts.Tensor) *ts.Tensor {
+	return l.ForwardMasked(src, false)
+}
+
+// ForwardT implements ModuleT interface for TransformerEncoderLayer.
+func (l *TransformerEncoderLayer) ForwardT(src *ts.Tensor, train bool) *ts.Tensor {
+	return l.ForwardMasked(src, train)
+}
+
+// TransformerDecoderLayer is made up of self-attention, cross-attention over
+// encoder output (memory) and feedforward network.
+//
+// Variables are named the same as PyTorch `nn.TransformerDecoderLayer`:
+// "self_attn", "multihead_attn", "linear1", "linear2", "norm1", "norm2", "norm3".
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.TransformerDecoderLayer.html
+type TransformerDecoderLayer struct {
+	SelfAttn      *MultiheadAttention
+	MultiheadAttn *MultiheadAttention
+	Linear1       *Linear
+	Linear2       *Linear
+	Norm1         *LayerNorm
+	Norm2         *LayerNorm
+	Norm3         *LayerNorm
+	config        *TransformerLayerConfig
+}
+
+// NewTransformerDecoderLayer creates a new TransformerDecoderLayer.
+//
+// dModel - number of expected features in the input.
+// nhead - number of attention heads.
+func NewTransformerDecoderLayer(vs *Path, dModel, nhead int64, c *TransformerLayerConfig) *TransformerDecoderLayer {
+	checkActivation("NewTransformerDecoderLayer", c.Activation)
+
+	attnCfg := DefaultMultiheadAttentionConfig()
+	attnCfg.Dropout = c.Dropout
+	attnCfg.BatchFirst = c.BatchFirst
+
+	return &TransformerDecoderLayer{
+		SelfAttn:      NewMultiheadAttention(vs.Sub("self_attn"), dModel, nhead, attnCfg),
+		MultiheadAttn: NewMultiheadAttention(vs.Sub("multihead_attn"), dModel, nhead, attnCfg),
+		Linear1:       NewLinear(vs.Sub("linear1"), dModel, c.DimFeedforward, DefaultLinearConfig()),
+		Linear2:       NewLinear(vs.Sub("linear2"), c.DimFeedforward, dModel, DefaultLinearConfig()),
+		Norm1:         newTransformerLayerNorm(vs.Sub("norm1"), dModel, c),
+		Norm2:         newTransformerLayerNorm(vs.Sub("norm2"), dModel, c),
+		Norm3:         newTransformerLayerNorm(vs.Sub("norm3"), dModel, c),
+		config:        c,
+	}
+}
+
+// ForwardT passes target and encoder output (memory) through the decoder layer.
+//
+// Target masks are given by options `WithTgtMask()`, `WithTgtKeyPaddingMask()`
+// and `WithTgtIsCausal()`, memory masks by `WithMemoryMask()` and `WithMemoryKeyPaddingMask()`.
+func (l *TransformerDecoderLayer) ForwardT(tgt, memory *ts.Tensor, train bool, opts ...TransformerOption) *ts.Tensor {
+	o := defaultTransformerOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c := l.config
+	x := residual(tgt, l.Norm1, c.NormFirst, false, func(h *ts.Tensor) *ts.Tensor {
+		return attnBlock(l.SelfAttn, h, h, c, train, WithAttnMask(o.TgtMask), WithKeyPaddingMask(o.TgtKeyPaddingMask), WithIsCausal(o.TgtIsCausal))
+	})
+	x = residual(x, l.Norm2, c.NormFirst, true, func(h *ts.Tensor) *ts.Tensor {
+		return attnBlock(l.MultiheadAttn, h, memory, c, train, WithAttnMask(o.MemoryMask), WithKeyPaddingMask(o.MemoryKeyPaddingMask))
+	})
+	x = residual(x, l.Norm3, c.NormFirst, true, func(h *ts.Tensor) *ts.Tensor {
+		return feedForward(h, l.Linear1, l.Linear2, c, train)
+	})
+
+	return x
+}
+
+// TransformerEncoder is a stack of TransformerEncoderLayer with optional final layer normalization.
+//
+// Variables are named "layers.<i>.*" and "norm" as PyTorch `nn.TransformerEncoder`.
+type TransformerEncoder struct {
+	Layers []*TransformerEncoderLayer
+	Norm   *LayerNorm // optional
+}
+
+// NewTransformerEncoder creates a new TransformerEncoder of numLayers layers.
+// Final layer normalization "norm" is added if withNorm is true.
+func NewTransformerEncoder(vs *Path, dModel, nhead, numLayers int64, c *TransformerLayerConfig, withNorm bool) *TransformerEncoder {
+	layersPath := vs.Sub("layers")
+	layers := make([]*TransformerEncoderLayer, numLayers)
+	for i := 0; i < int(numLayers); i++ {
+		layers[i] = NewTransformerEncoderLayer(layersPath.Sub(strconv.Itoa(i)), dModel, nhead, c)
+	}
+
+	var norm *LayerNorm
+	if withNorm {
+		norm = newTransformerLayerNorm(vs.Sub("norm"), dModel, c)
+	}
+
+	return &TransformerEncoder{
+		Layers: layers,
+		Norm:   norm,
+	}
+}
+
+// ForwardMasked passes input through the encoder layers in turn.
+func (e *TransformerEncoder) ForwardMasked(src *ts.Tensor, train bool, opts ...TransformerOption) *ts.Tensor {
+	x := src.MustShallowClone()
+	for _, l := range e.Layers {
+		h := l.ForwardMasked(x, train, opts...)
+		x.MustDrop()
+		x = h
+	}
+
+	if e.Norm != nil {
+		h := e.Norm.Forward(x)
+		x.MustDrop()
+		x = h
+	}
+
+	return x
+}
+
+// Forward implements Module interface for TransformerEncoder.
+func (e *TransformerEncoder) Forward(src *ts.Tensor) *ts.Tensor {
+	return e.ForwardMasked(src, false)
+}
+
+// ForwardT implements ModuleT interface for TransformerEncoder.
+func (e *TransformerEncoder) ForwardT(src *ts.Tensor, train bool) *ts.Tensor {
+	return e.ForwardMasked(src, train)
+}
+
+// TransformerDecoder is a stack of TransformerDecoderLayer with optional final layer normalization.
+//
+// Variables are named "layers.<i>.*" and "norm" as PyTorch `nn.TransformerDecoder`.
+type TransformerDecoder struct {
+	Layers []*TransformerDecoderLayer
+	Norm   *LayerNorm // optional
+}
+
+// NewTransformerDecoder creates a new TransformerDecoder of numLayers layers.
+// Final layer normalization "norm" is added if withNorm is true.
+func NewTransformerDecoder(vs *Path, dModel, nhead, numLayers int64, c *TransformerLayerConfig, withNorm bool) *TransformerDecoder {
+	layersPath := vs.Sub("layers")
+	layers := make([]*TransformerDecoderLayer, numLayers)
+	for i := 0; i < int(numLayers); i++ {
+		layers[i] = NewTransformerDecoderLayer(layersPath.Sub(strconv.Itoa(i)), dModel, nhead, c)
+	}
+
+	var norm *LayerNorm
+	if withNorm {
+		norm = newTransformerLayerNorm(vs.Sub("norm"), dModel, c)
+	}
+
+	return &TransformerDecoder{
+		Layers: layers,
+		Norm:   norm,
+	}
+}
+
+// ForwardT passes target and encoder output (memory) through the decoder layers in turn.
+func (d *TransformerDecoder) ForwardT(tgt, memory *ts.Tensor, train bool, opts ...TransformerOption) *ts.Tensor {
+	x := tgt.MustShallowClone()
+	for _, l := range d.Layers {
+		h := l.ForwardT(x, memory, train, opts...)
+		x.MustDrop()
+		x = h
+	}
+
+	if d.Norm != nil {
+		h := d.Norm.Forward(x)
+		x.MustDrop()
+		x = h
+	}
+
+	return x
+}
+
+// Transformer is an encoder-decoder transformer model.
+//
+// Variables are named "encoder.*" and "decoder.*" as PyTorch `nn.Transformer`.
+//
+// Ref. https://pytorch.org/docs/stable/generated/torch.nn.Transformer.html
+type Transformer struct {
+	Encoder *TransformerEncoder
+	Decoder *TransformerDecoder
+}
+
+// NewTransformer creates a new Transformer with final layer normalization of
+// both encoder and decoder.
+func NewTransformer(vs *Path, dModel, nhead, numEncoderLayers, numDecoderLayers int64, c *TransformerLayerConfig) *Transformer {
+	return &Transformer{
+		Encoder: NewTransformerEncoder(vs.Sub("encoder"), dModel, nhead, numEncoderLayers, c, true),
+		Decoder: NewTransformerDecoder(vs.Sub("decoder"), dModel, nhead, numDecoderLayers, c, true),
+	}
+}
+
+// ForwardT encodes source and decodes target with the encoded source.
+func (t *Transformer) ForwardT(src, tgt *ts.Tensor, train bool, opts ...TransformerOption) *ts.Tensor {
+	memory := t.Encoder.ForwardMasked(src, train, opts...)
+	out := t.Decoder.ForwardT(tgt, memory, train, opts...)
+	memory.MustDrop()
+
+	return out
+}
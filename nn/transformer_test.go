@@ -0,0 +1,172 @@
+package nn_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestMultiheadAttention(t *testing.T) {
+	var (
+		embedDim int64 = 8
+		numHeads int64 = 2
+		batch    int64 = 3
+		tgtLen   int64 = 4
+		srcLen   int64 = 5
+	)
+
+	vs := nn.NewVarStore(gotch.CPU)
+	cfg := nn.DefaultMultiheadAttentionConfig()
+	cfg.BatchFirst = true
+	mha := nn.NewMultiheadAttention(vs.Root(), embedDim, numHeads, cfg)
+
+	query := ts.MustRandn([]int64{batch, tgtLen, embedDim}, gotch.Float, gotch.CPU)
+	key := ts.MustRandn([]int64{batch, srcLen, embedDim}, gotch.Float, gotch.CPU)
+
+	// Fused attention and explicit attention with weights should be the same.
+	out, weights := mha.ForwardAttn(query, key, key, false)
+	if weights != nil {
+		t.Errorf("Want nil attention weights, got %v\n", weights.MustSize())
+	}
+	outW, weights := mha.ForwardAttn(query, key, key, false, nn.WithNeedWeights(true))
+
+	if want, got := []int64{batch, tgtLen, embedDim}, out.MustSize(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Want output shape %v, got %v\n", want, got)
+	}
+	if want, got := []int64{batch, tgtLen, srcLen}, weights.MustSize(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Want weights shape %v, got %v\n", want, got)
+	}
+	if !out.MustAllclose(outW, 1e-4, 1e-5, false, false) {
+		t.Errorf("Want same output with and without attention weights\n")
+	}
+
+	// Padded keys get zero attention weights.
+	padding := ts.MustZeros([]int64{batch, srcLen}, gotch.Bool, gotch.CPU)
+	padding.MustNarrow(1, srcLen-2, 2, false).MustFill_(ts.IntScalar(1))
+	_, weights = mha.ForwardAttn(query, key, key, false, nn.WithNeedWeights(true), nn.WithKeyPaddingMask(padding))
+	padded := weights.MustNarrow(2, srcLen-2, 2, false).MustAbs(true).MustSum(gotch.Float, true).Float64Values()[0]
+	if padded != 0 {
+		t.Errorf("Want zero attention weights on padded keys, got %v\n", padded)
+	}
+}
+
+func TestMultiheadAttention_Causal(t *testing.T) {
+	var (
+		embedDim int64 = 8
+		seqLen   int64 = 4
+	)
+
+	vs := nn.NewVarStore(gotch.CPU)
+	mha := nn.NewMultiheadAttention(vs.Root(), embedDim, 2, nn.DefaultMultiheadAttentionConfig())
+
+	// (seq, batch, feature)
+	x := ts.MustRandn([]int64{seqLen, 2, embedDim}, gotch.Float, gotch.CPU)
+	out, _ := mha.ForwardAttn(x, x, x, false, nn.WithIsCausal(true))
+	outW, _ := mha.ForwardAttn(x, x, x, false, nn.WithIsCausal(true), nn.WithNeedWeights(true))
+	if !out.MustAllclose(outW, 1e-4, 1e-5, false, false) {
+		t.Errorf("Want same causal output with and without attention weights\n")
+	}
+
+	// Changing the last position should not change outputs at earlier positions.
+	x1 := x.MustMulScalar(ts.FloatScalar(1.0), false)
+	x1.MustNarrow(0, seqLen-1, 1, false).MustFill_(ts.FloatScalar(10.0))
+	out1, _ := mha.ForwardAttn(x1, x1, x1, false, nn.WithIsCausal(true))
+
+	want := out.MustNarrow(0, 0, seqLen-1, false)
+	got := out1.MustNarrow(0, 0, seqLen-1, false)
+	if !want.MustAllclose(got, 1e-4, 1e-5, false, false) {
+		t.Errorf("Want causal attention to ignore future positions\n")
+	}
+}
+
+func TestTransformer_VariableNames(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	cfg := nn.DefaultTransformerLayerConfig()
+	cfg.DimFeedforward = 16
+	nn.NewTransformer(vs.Root(), 8, 2, 2, 1, cfg)
+
+	vars := vs.Variables()
+	want := map[string][]int64{
+		"encoder.layers.0.self_attn.in_proj_weight":      {24, 8},
+		"encoder.layers.0.self_attn.in_proj_bias":        {24},
+		"encoder.layers.0.self_attn.out_proj.weight":     {8, 8},
+		"encoder.layers.0.self_attn.out_proj.bias":       {8},
+		"encoder.layers.1.linear1.weight":                {16, 8},
+		"encoder.layers.1.linear2.weight":                {8, 16},
+		"encoder.layers.1.norm2.bias":                    {8},
+		"encoder.norm.weight":                            {8},
+		"decoder.layers.0.multihead_attn.in_proj_weight": {24, 8},
+		"decoder.layers.0.norm3.weight":                  {8},
+		"decoder.norm.bias":                              {8},
+	}
+	for name, shape := range want {
+		x, ok := vars[name]
+		if !ok {
+			t.Errorf("Missing variable %q\n", name)
+			continue
+		}
+		if got := x.MustSize(); !reflect.DeepEqual(shape, got) {
+			t.Errorf("Variable %q: want shape %v, got %v\n", name, shape, got)
+		}
+	}
+
+	if want, got := 2*12+2+18+2, len(vars); want != got {
+		t.Errorf("Want %v variables, got %v\n", want, got)
+	}
+}
+
+func TestTransformer_Forward(t *testing.T) {
+	var (
+		dModel int64 = 8
+		batch  int64 = 2
+		srcLen int64 = 5
+		tgtLen int64 = 3
+	)
+
+	vs := nn.NewVarStore(gotch.CPU)
+	cfg := nn.DefaultTransformerLayerConfig()
+	cfg.DimFeedforward = 16
+	cfg.BatchFirst = true
+	cfg.NormFirst = true
+	cfg.Activation = "gelu"
+	model := nn.NewTransformer(vs.Root(), dModel, 2, 2, 2, cfg)
+
+	peCfg := nn.DefaultPositionalEncodingConfig()
+	peCfg.MaxLen = 10
+	peCfg.BatchFirst = true
+	pe := nn.NewSinusoidalPositionalEncoding(vs.Root().Sub("pos_encoder"), dModel, peCfg)
+	if _, ok := vs.Variables()["pos_encoder.pe"]; !ok {
+		t.Errorf("Missing buffer pos_encoder.pe\n")
+	}
+
+	src := pe.ForwardT(ts.MustRandn([]int64{batch, srcLen, dModel}, gotch.Float, gotch.CPU), true)
+	tgt := ts.MustRandn([]int64{batch, tgtLen, dModel}, gotch.Float, gotch.CPU)
+	padding := ts.MustZeros([]int64{batch, srcLen}, gotch.Bool, gotch.CPU)
+
+	out := model.ForwardT(src, tgt, true,
+		nn.WithSrcKeyPaddingMask(padding),
+		nn.WithMemoryKeyPaddingMask(padding),
+		nn.WithTgtMask(nn.GenerateSquareSubsequentMask(tgtLen, gotch.CPU)),
+	)
+
+	if want, got := []int64{batch, tgtLen, dModel}, out.MustSize(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Want output shape %v, got %v\n", want, got)
+	}
+	if out.MustIsnan(false).MustSum(gotch.Int64, true).Int64Values()[0] != 0 {
+		t.Errorf("Want finite output\n")
+	}
+
+	// Learned positional encoding, (seq, batch, feature) input.
+	lpeCfg := nn.DefaultPositionalEncodingConfig()
+	lpeCfg.MaxLen = 10
+	lpe := nn.NewLearnedPositionalEncoding(vs.Root().Sub("pos_embedding"), dModel, lpeCfg)
+	x := ts.MustZeros([]int64{srcLen, batch, dModel}, gotch.Float, gotch.CPU)
+	y := lpe.Forward(x)
+	want := lpe.Ws.MustNarrow(0, 0, srcLen, false)
+	if !y.MustNarrow(1, 1, 1, false).MustSqueezeDim(1, true).MustAllclose(want, 1e-6, 1e-6, false, true) {
+		t.Errorf("Want learned positions added to input\n")
+	}
+}
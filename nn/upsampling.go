@@ -0,0 +1,160 @@
+package nn
+
+// Upsampling layer.
+
+import (
+	"log"
+	"math"
+
+	"github.com/sugarme/gotch/ts"
+)
+
+// Upsample:
+// =========
+
+// Upsample upsamples input of shape (N, C, L), (N, C, H, W) or (N, C, D, H, W)
+// to a given output size or by a given scale factor.
+//
+// Supported modes:
+//   - "nearest": 1D, 2D and 3D input.
+//   - "linear": 1D input.
+//   - "bilinear": 2D input.
+//   - "bicubic": 2D input.
+//   - "trilinear": 3D input.
+type Upsample struct {
+	Size         []int64   // output spatial size. A single value applies to all spatial dimensions.
+	ScaleFactor  []float64 // multiplier of spatial size. A single value applies to all spatial dimensions.
+	Mode         string
+	AlignCorners bool // only for "linear", "bilinear", "bicubic" and "trilinear" modes.
+}
+
+type UpsampleOpts struct {
+	Size         []int64
+	ScaleFactor  []float64
+	Mode         string
+	AlignCorners bool
+}
+
+type UpsampleOpt func(*UpsampleOpts)
+
+func OptSizeUp(v []int64) UpsampleOpt {
+	return func(o *UpsampleOpts) {
+		o.Size = v
+	}
+}
+
+func OptScaleFactorUp(v []float64) UpsampleOpt {
+	return func(o *UpsampleOpts) {
+		o.ScaleFactor = v
+	}
+}
+
+func OptModeUp(v string) UpsampleOpt {
+	return func(o *UpsampleOpts) {
+		o.Mode = v
+	}
+}
+
+func OptAlignCornersUp(v bool) UpsampleOpt {
+	return func(o *UpsampleOpts) {
+		o.AlignCorners = v
+	}
+}
+
+func DefaultUpsampleOpts() *UpsampleOpts {
+	return &UpsampleOpts{
+		Mode: "nearest",
+	}
+}
+
+// NewUpsample creates a new Upsample layer. Exactly one of size or scale factor
+// options should be specified.
+//
+// Example:
+//
+//	up := nn.NewUpsample(nn.OptScaleFactorUp([]float64{2}), nn.OptModeUp("bilinear"))
+func NewUpsample(opts ...UpsampleOpt) *Upsample {
+	o := DefaultUpsampleOpts()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if (len(o.Size) == 0) == (len(o.ScaleFactor) == 0) {
+		log.Fatalf("NewUpsample() failed: expected either size or scale factor to be specified, got size %v and scale factor %v\n", o.Size, o.ScaleFactor)
+	}
+
+	switch o.Mode {
+	case "nearest", "linear", "bilinear", "bicubic", "trilinear":
+	default:
+		log.Fatalf("NewUpsample() failed: unsupported mode %q\n", o.Mode)
+	}
+
+	return &Upsample{
+		Size:         o.Size,
+		ScaleFactor:  o.ScaleFactor,
+		Mode:         o.Mode,
+		AlignCorners: o.AlignCorners,
+	}
+}
+
+// scale returns scale factor at spatial dimension i if specified.
+func (m *Upsample) scale(i int) []float64 {
+	switch len(m.ScaleFactor) {
+	case 0:
+		return nil
+	case 1:
+		return m.ScaleFactor
+	default:
+		return []float64{m.ScaleFactor[i]}
+	}
+}
+
+// outputSize returns output spatial size of input of spatial size `spatial`.
+func (m *Upsample) outputSize(spatial []int64) []int64 {
+	size := make([]int64, len(spatial))
+	for i, s := range spatial {
+		if len(m.Size) > 0 {
+			size[i] = poolParam(m.Size, i)
+			continue
+		}
+		size[i] = int64(math.Floor(float64(s) * m.scale(i)[0]))
+	}
+
+	return size
+}
+
+func (m *Upsample) Forward(x *ts.Tensor) *ts.Tensor {
+	shape := x.MustSize()
+	nd := len(shape) - 2
+	if nd < 1 || nd > 3 {
+		log.Fatalf("Expected an input tensor with 3, 4 or 5 dims, got %v\n", shape)
+	}
+	if len(m.Size) > 1 && len(m.Size) != nd {
+		log.Fatalf("Expected size with 1 or %v elements, got %v\n", nd, m.Size)
+	}
+	if len(m.ScaleFactor) > 1 && len(m.ScaleFactor) != nd {
+		log.Fatalf("Expected scale factor with 1 or %v elements, got %v\n", nd, m.ScaleFactor)
+	}
+
+	size := m.outputSize(shape[2:])
+
+	switch {
+	case m.Mode == "nearest" && nd == 1:
+		return x.MustUpsampleNearest1d(size, m.scale(0), false)
+	case m.Mode == "nearest" && nd == 2:
+		return x.MustUpsampleNearest2d(size, m.scale(0), m.scale(1), false)
+	case m.Mode == "nearest" && nd == 3:
+		return x.MustUpsampleNearest3d(size, m.scale(0), m.scale(1), m.scale(2), false)
+	case m.Mode == "linear" && nd == 1:
+		return x.MustUpsampleLinear1d(size, m.AlignCorners, m.scale(0), false)
+	case m.Mode == "bilinear" && nd == 2:
+		return x.MustUpsampleBilinear2d(size, m.AlignCorners, m.scale(0), m.scale(1), false)
+	case m.Mode == "bicubic" && nd == 2:
+		return x.MustUpsampleBicubic2d(size, m.AlignCorners, m.scale(0), m.scale(1), false)
+	case m.Mode == "trilinear" && nd == 3:
+		return x.MustUpsampleTrilinear3d(size, m.AlignCorners, m.scale(0), m.scale(1), m.scale(2), false)
+	default:
+		log.Fatalf("Upsample mode %q does not support %vD input\n", m.Mode, nd)
+		return nil
+	}
+}
@@ -348,6 +348,104 @@ func (vs *VarStore) LoadWeightsPartial(namedTensors []ts.NamedTensor) ([]string,
 	return missingVariables, nil
 }
 
+// LoadSafetensors loads VarStore variable values from a safetensors file.
+//
+// Like `VarStore.Load()`, it will throw error if name of a variable in the
+// current VarStore can not be found in the file. Tensor data is memory-mapped
+// and only copied when a variable is loaded.
+func (vs *VarStore) LoadSafetensors(filepath string) error {
+	missingVariables, err := vs.loadSafetensors(filepath, false)
+	if err != nil {
+		err = fmt.Errorf("VarStore.LoadSafetensors() failed: %w", err)
+		return err
+	}
+	if len(missingVariables) > 0 {
+		err = fmt.Errorf("VarStore.LoadSafetensors() failed: there's a tensor with name %q in VarStore, but not found in the loaded weights.\n", missingVariables[0])
+		return err
+	}
+
+	return nil
+}
+
+// LoadSafetensorsPartial loads the VarStore variable values from a safetensors file.
+//
+// Like `VarStore.LoadPartial()`, variables not present in the file or with
+// mismatched shape are skipped. Returns names of missing variables.
+func (vs *VarStore) LoadSafetensorsPartial(filepath string) ([]string, error) {
+	missingVariables, err := vs.loadSafetensors(filepath, true)
+	if err != nil {
+		err = fmt.Errorf("VarStore.LoadSafetensorsPartial() failed: %w", err)
+		return nil, err
+	}
+
+	return missingVariables, nil
+}
+
+func (vs *VarStore) loadSafetensors(filepath string, partial bool) ([]string, error) {
+	st, err := ts.OpenSafetensors(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	var missingVariables []string
+
+	vs.Lock()
+	defer vs.Unlock()
+
+	for name, v := range vs.vars {
+		if !st.Has(name) {
+			missingVariables = append(missingVariables, name)
+			continue
+		}
+
+		// mismatched shape
+		sourceShape, err := st.Shape(name)
+		if err != nil {
+			return nil, err
+		}
+		destShape := v.Tensor.MustSize()
+		if !reflect.DeepEqual(destShape, sourceShape) {
+			if !partial {
+				err = fmt.Errorf("Mismatched shape error for variable name: %v - At store: %v - At source %v\n", name, destShape, sourceShape)
+				return nil, err
+			}
+			fmt.Printf("WARNING: Mismatched shape error for variable name: %v - At store: %v - At source %v. Skip loading this weight...\n", name, destShape, sourceShape)
+			missingVariables = append(missingVariables, name)
+			continue
+		}
+
+		x, err := st.Tensor(name)
+		if err != nil {
+			return nil, err
+		}
+		ts.NoGrad(func() {
+			v.Tensor.Copy_(x)
+		})
+		x.MustDrop()
+	}
+
+	return missingVariables, nil
+}
+
+// SaveSafetensors saves the VarStore variable values to a safetensors file.
+func (vs *VarStore) SaveSafetensors(filepath string, metadataOpt ...map[string]string) error {
+	vs.Lock()
+	defer vs.Unlock()
+
+	var namedTensors []ts.NamedTensor
+	for k, v := range vs.vars {
+		if v.Type == "parameter" || (v.Type == "buffer" && v.Persitent) {
+			namedTensors = append(namedTensors, ts.NamedTensor{
+				Name:   k,
+				Tensor: v.Tensor,
+			})
+		}
+	}
+
+	return ts.SaveSafetensors(namedTensors, filepath, metadataOpt...)
+}
+
 // Freeze freezes this VarStore.
 //
 // Gradients for the variables in this store are not tracked anymore.
@@ -568,13 +666,16 @@ func (p *Path) add(name string, newTs *ts.Tensor, trainable bool, varType string
 		err    error
 	)
 
-	if trainable {
-		tensor, err = newTs.SetRequiresGrad(true, false)
-		if err != nil {
-			return nil, err
+	// Variables are owned by VarStore so they're not freed by any `ts.Scope`.
+	ts.WithoutScope(func() {
+		if trainable {
+			tensor, err = newTs.SetRequiresGrad(true, false)
+		} else {
+			tensor = newTs.MustShallowClone()
 		}
-	} else {
-		tensor = newTs.MustShallowClone()
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	v := Var{
@@ -175,3 +175,49 @@ func TestVarstore_Memcheck(t *testing.T) {
 	time.Sleep(time.Second * 10)
 	gotch.PrintMemStats("Final")
 }
+
+func TestSaveLoadSafetensors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "vsload.safetensors")
+
+	vs1 := nn.NewVarStore(gotch.CPU)
+	u1 := vs1.Root().MustZeros("t1", []int64{4})
+	vs1.Root().Sub("a").MustOnes("t2", []int64{3})
+	ts.NoGrad(func() {
+		u1.AddScalar_(ts.FloatScalar(42.0))
+	})
+
+	if err := vs1.SaveSafetensors(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	vs2 := nn.NewVarStore(gotch.CPU)
+	u2 := vs2.Root().MustZeros("t1", []int64{4})
+	vs2.Root().Sub("a").MustZeros("t2", []int64{3})
+	if err := vs2.LoadSafetensors(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	want := float64(42.0)
+	got := u2.MustMean(gotch.Float, false).Float64Values()[0]
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected u2: %v\n", want)
+		t.Errorf("Got u2: %v\n", got)
+	}
+
+	vs3 := nn.NewVarStore(gotch.CPU)
+	vs3.Root().MustZeros("t1", []int64{4})
+	vs3.Root().MustZeros("t3", []int64{2})
+	missing, err := vs3.LoadSafetensorsPartial(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMissing := []string{"t3"}
+	if !reflect.DeepEqual(wantMissing, missing) {
+		t.Errorf("Expected missing: %v\n", wantMissing)
+		t.Errorf("Got missing: %v\n", missing)
+	}
+
+	if err := vs3.LoadSafetensors(filename); err == nil {
+		t.Errorf("Expected missing variable error, got nil\n")
+	}
+}
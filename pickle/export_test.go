@@ -0,0 +1,4 @@
+package pickle
+
+// DecodeEager exposes the eager loader to external tests and benchmarks.
+var DecodeEager = decodeEager
@@ -1944,3 +1944,289 @@ func Loads(s string) (interface{}, error) {
 func GetFunctionName(i interface{}) string {
 	return runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
 }
+
+// Pickling Machinery:
+// ===================
+
+// PyReducer is implemented by any value that can be pickled with a Python-like
+// "__reduce__" method. It returns a callable (usually a `*GenericClass` which
+// is written as a GLOBAL) and the arguments to be applied to it on unpickling.
+//
+// See: https://docs.python.org/3/library/pickle.html#object.__reduce__
+type PyReducer interface {
+	PyReduce() (callable interface{}, args *Tuple, err error)
+}
+
+// Pickler serializes Go values into Python pickle binary format.
+//
+// NOTE. Only a subset of Python types needed to save Pytorch models is supported:
+// None, bool, int, float, str, bytes, tuple, list, dict, OrderedDict, global
+// (`*GenericClass`) and any value implementing `PyReducer`.
+type Pickler struct {
+	proto  byte      // protocol version of the pickle
+	writer io.Writer // binary file writer
+
+	// PersistentId returns persistent id of an object and true if the object
+	// should be saved as a persistent reference (BINPERSID) rather than by value.
+	PersistentId func(obj interface{}) (interface{}, bool)
+}
+
+// NewPickler creates a new Pickler writing with pickle protocol 2 which
+// is the protocol used by Pytorch `torch.save()`.
+func NewPickler(w io.Writer) *Pickler {
+	return &Pickler{
+		proto:  2,
+		writer: w,
+	}
+}
+
+func (p *Pickler) write(data ...byte) error {
+	_, err := p.writer.Write(data)
+	return err
+}
+
+func (p *Pickler) writeOp(op rune, data ...byte) error {
+	return p.write(append([]byte{byte(op)}, data...)...)
+}
+
+// Dump writes a pickled representation of obj to the underlying writer.
+func (p *Pickler) Dump(obj interface{}) error {
+	if err := p.writeOp(PROTO, p.proto); err != nil {
+		err = fmt.Errorf("Pickler.Dump() failed: %w", err)
+		return err
+	}
+
+	if err := p.save(obj); err != nil {
+		err = fmt.Errorf("Pickler.Dump() failed: %w", err)
+		return err
+	}
+
+	return p.writeOp(STOP)
+}
+
+func (p *Pickler) save(obj interface{}) error {
+	if p.PersistentId != nil {
+		if pid, ok := p.PersistentId(obj); ok {
+			if err := p.save(pid); err != nil {
+				return err
+			}
+			return p.writeOp(BINPERSID)
+		}
+	}
+
+	switch v := obj.(type) {
+	case nil:
+		return p.writeOp(NONE)
+	case bool:
+		if v {
+			return p.writeOp(NEWTRUE)
+		}
+		return p.writeOp(NEWFALSE)
+	case int:
+		return p.saveInt(int64(v))
+	case int32:
+		return p.saveInt(int64(v))
+	case int64:
+		return p.saveInt(v)
+	case float32:
+		return p.saveFloat(float64(v))
+	case float64:
+		return p.saveFloat(v)
+	case string:
+		return p.saveString(v)
+	case []byte:
+		return p.saveBytes(v)
+	case *Tuple:
+		return p.saveTuple(v)
+	case *List:
+		return p.saveList(v)
+	case *Dict:
+		return p.saveDict(v)
+	case *OrderedDict:
+		return p.saveOrderedDict(v)
+	case *GenericClass:
+		return p.saveGlobal(v.Module, v.Name)
+	case PyReducer:
+		return p.saveReduce(v)
+	default:
+		err := picklingError(fmt.Sprintf("unsupported type %T", obj))
+		return err
+	}
+}
+
+func (p *Pickler) saveInt(v int64) error {
+	switch {
+	case v >= 0 && v <= math.MaxUint8:
+		return p.writeOp(BININT1, byte(v))
+	case v >= 0 && v <= math.MaxUint16:
+		buf := make([]byte, 2)
+		binary.LittleEndian.PutUint16(buf, uint16(v))
+		return p.writeOp(BININT2, buf...)
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, uint32(int32(v)))
+		return p.writeOp(BININT, buf...)
+	default:
+		// LONG1: little-endian two's complement encoding with minimal bytes.
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, uint64(v))
+		n := 8
+		for n > 1 {
+			last, prev := buf[n-1], buf[n-2]
+			if (last == 0x00 && prev&0x80 == 0) || (last == 0xff && prev&0x80 != 0) {
+				n--
+				continue
+			}
+			break
+		}
+		return p.writeOp(LONG1, append([]byte{byte(n)}, buf[:n]...)...)
+	}
+}
+
+func (p *Pickler) saveFloat(v float64) error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, math.Float64bits(v))
+	return p.writeOp(BINFLOAT, buf...)
+}
+
+func (p *Pickler) saveString(v string) error {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, uint32(len(v)))
+	if err := p.writeOp(BINUNICODE, buf...); err != nil {
+		return err
+	}
+	return p.write([]byte(v)...)
+}
+
+func (p *Pickler) saveBytes(v []byte) error {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, uint32(len(v)))
+	if err := p.writeOp(BINBYTES, buf...); err != nil {
+		return err
+	}
+	return p.write(v...)
+}
+
+func (p *Pickler) saveTuple(t *Tuple) error {
+	switch t.Len() {
+	case 0:
+		return p.writeOp(EMPTY_TUPLE)
+	case 1, 2, 3:
+		for _, item := range *t {
+			if err := p.save(item); err != nil {
+				return err
+			}
+		}
+		op := []rune{TUPLE1, TUPLE2, TUPLE3}[t.Len()-1]
+		return p.writeOp(op)
+	default:
+		if err := p.writeOp(MARK); err != nil {
+			return err
+		}
+		for _, item := range *t {
+			if err := p.save(item); err != nil {
+				return err
+			}
+		}
+		return p.writeOp(TUPLE)
+	}
+}
+
+func (p *Pickler) saveList(l *List) error {
+	if err := p.writeOp(EMPTY_LIST); err != nil {
+		return err
+	}
+	if l.Len() == 0 {
+		return nil
+	}
+	if err := p.writeOp(MARK); err != nil {
+		return err
+	}
+	for _, item := range *l {
+		if err := p.save(item); err != nil {
+			return err
+		}
+	}
+	return p.writeOp(APPENDS)
+}
+
+func (p *Pickler) saveDict(d *Dict) error {
+	if err := p.writeOp(EMPTY_DICT); err != nil {
+		return err
+	}
+	if d.Len() == 0 {
+		return nil
+	}
+	if err := p.writeOp(MARK); err != nil {
+		return err
+	}
+	for _, entry := range *d {
+		if err := p.save(entry.Key); err != nil {
+			return err
+		}
+		if err := p.save(entry.Value); err != nil {
+			return err
+		}
+	}
+	return p.writeOp(SETITEMS)
+}
+
+// saveOrderedDict writes `collections.OrderedDict()` followed by its items
+// in insertion order.
+func (p *Pickler) saveOrderedDict(o *OrderedDict) error {
+	if err := p.saveGlobal("collections", "OrderedDict"); err != nil {
+		return err
+	}
+	if err := p.writeOp(EMPTY_TUPLE); err != nil {
+		return err
+	}
+	if err := p.writeOp(REDUCE); err != nil {
+		return err
+	}
+	if o.Len() == 0 {
+		return nil
+	}
+	if err := p.writeOp(MARK); err != nil {
+		return err
+	}
+	for e := o.List.Front(); e != nil; e = e.Next() {
+		entry := e.Value.(*OrderedDictEntry)
+		if err := p.save(entry.Key); err != nil {
+			return err
+		}
+		if err := p.save(entry.Value); err != nil {
+			return err
+		}
+	}
+	return p.writeOp(SETITEMS)
+}
+
+func (p *Pickler) saveGlobal(module, name string) error {
+	data := []byte(fmt.Sprintf("%s\n%s\n", module, name))
+	return p.writeOp(GLOBAL, data...)
+}
+
+func (p *Pickler) saveReduce(r PyReducer) error {
+	callable, args, err := r.PyReduce()
+	if err != nil {
+		return err
+	}
+	if err := p.save(callable); err != nil {
+		return err
+	}
+	if err := p.save(args); err != nil {
+		return err
+	}
+	return p.writeOp(REDUCE)
+}
+
+// Dumps pickles an object and returns its string representation.
+func Dumps(obj interface{}) (string, error) {
+	var buf bytes.Buffer
+	p := NewPickler(&buf)
+	if err := p.Dump(obj); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
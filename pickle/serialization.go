@@ -10,6 +10,8 @@ package pickle
 import (
 	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -19,6 +21,7 @@ import (
 	"path"
 	"reflect"
 	"sort"
+	"syscall"
 
 	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/nn"
@@ -31,18 +34,103 @@ const protocolVersion = 1001
 var ErrInvalidMagicNumber = errors.New("invalid pytorch magic number")
 var ErrInvalidProtocolVersion = errors.New("invalid pytorch protocol version")
 
-// Encode encodes model using pickling machinery.
+// Encode encodes weights of a VarStore using pickling machinery and writes them
+// to a Pytorch zip file.
 // Output pickled model can be loads with Python Pytorch as `torch.load("pytorch_model.bin")`
+func Encode(vs *nn.VarStore, outputFile string) error {
+	var namedTensors []ts.NamedTensor
+	for name, x := range vs.Variables() {
+		x := x
+		namedTensors = append(namedTensors, ts.NamedTensor{
+			Name:   name,
+			Tensor: &x,
+		})
+	}
+
+	if err := EncodeNamedTensors(namedTensors, outputFile); err != nil {
+		err = fmt.Errorf("Encode() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// EncodeNamedTensors pickles named tensors as a Pytorch state dict (`collections.OrderedDict`)
+// and writes them to a Pytorch zip file.
 //
-// TODO. implement pickling part so that model can be exported and load with Python Pytorch.
-// See https://github.com/python/cpython/blob/b0de6299a840a397d4fe3e6c98159d9f258d3295/Lib/pickle.py#L407
-func Encode(model ts.Module, outputFile string) error {
-	panic("NotImplementedError")
+// The zip file layout is the same as the one created by `torch.save()`:
+// `archive/data.pkl` holds the pickled state dict and each tensor storage is
+// written to a separate `archive/data/<key>` record.
+func EncodeNamedTensors(namedTensors []ts.NamedTensor, outputFile string) error {
+	sorted := make([]ts.NamedTensor, len(namedTensors))
+	copy(sorted, namedTensors)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	stateDict := NewOrderedDict()
+	storages := make([]*storageRef, 0, len(sorted))
+	for i, x := range sorted {
+		tensor, err := newTensorReducer(x.Tensor, fmt.Sprintf("%d", i))
+		if err != nil {
+			err = fmt.Errorf("EncodeNamedTensors() failed: tensor %q: %w", x.Name, err)
+			return err
+		}
+		stateDict.Set(x.Name, tensor)
+		storages = append(storages, tensor.storage)
+	}
+
+	f, err := os.Create(outputFile)
+	if err != nil {
+		err = fmt.Errorf("EncodeNamedTensors() failed: %w", err)
+		return err
+	}
+	defer f.Close()
+
+	if err := writeZipFile(f, stateDict, storages); err != nil {
+		err = fmt.Errorf("EncodeNamedTensors() failed: %w", err)
+		return err
+	}
+
+	return nil
 }
 
 // Decode decodes pickled data created by 'torch.save()' with Python Pytorch
 // and rebuilds named tensor weights.
+//
+// NOTE. For Pytorch zip format, storages are memory-mapped and each tensor is
+// built directly from mapped bytes. See `DecodeLazy()`.
 func Decode(filename string) (map[string]*ts.Tensor, error) {
+	if isZipFile(filename) {
+		lt, err := DecodeLazy(filename)
+		if err != nil {
+			err := fmt.Errorf("Decode() failed: %w", err)
+			return nil, err
+		}
+		defer lt.Close()
+
+		namedTensors := make(map[string]*ts.Tensor, len(lt.names))
+		for _, name := range lt.names {
+			x, err := lt.Tensor(name)
+			if err != nil {
+				for _, x := range namedTensors {
+					x.MustDrop()
+				}
+				err := fmt.Errorf("Decode() failed: %w", err)
+				return nil, err
+			}
+			namedTensors[name] = x
+		}
+
+		return namedTensors, nil
+	}
+
+	return decodeEager(filename)
+}
+
+// decodeEager reads all storages of a checkpoint into memory up front then
+// rebuilds named tensor weights from them.
+func decodeEager(filename string) (map[string]*ts.Tensor, error) {
 	newUnpickler := func(r io.Reader) Unpickler {
 		return NewUnpickler(r)
 	}
@@ -128,7 +216,7 @@ func Decode(filename string) (map[string]*ts.Tensor, error) {
 				stride = []int64{1}
 			}
 
-			x := ts.MustOfSlice(data).MustAsStrided(size, stride, []int64{storageOffset}, true).MustTotype(dtype, true).MustTo(device, true)
+			x := ts.MustOfSlice(data, ts.WithDType(dtype)).MustAsStrided(size, stride, []int64{storageOffset}, true).MustTotype(dtype, true).MustTo(device, true)
 			if sx.RequiresGrad {
 				x.MustRequiresGrad_(sx.RequiresGrad)
 			}
@@ -241,6 +329,657 @@ func loadTensor(
 	return storage, err
 }
 
+// Lazy loading:
+// =============
+
+// LazyStorage is a storage which only keeps a reference to its record in zip file.
+// Data is read (or sliced from memory-mapped file) only when requested.
+type LazyStorage struct {
+	BaseStorage
+	class StorageClass
+	dtype gotch.DType
+	file  *zip.File
+	mmap  []byte // memory-mapped zip file. Can be nil.
+}
+
+var _ Storage = &LazyStorage{}
+
+func newLazyStorage(class StorageClass, size int, location string, file *zip.File, mmap []byte) *LazyStorage {
+	return &LazyStorage{
+		BaseStorage: BaseStorage{Size: size, Location: location},
+		class:       class,
+		dtype:       class.New(0, location).DType(),
+		file:        file,
+		mmap:        mmap,
+	}
+}
+
+func (s *LazyStorage) SetFromFile(r io.Reader) error {
+	return fmt.Errorf("LazyStorage.SetFromFile() failed: lazy storage is read-only")
+}
+
+func (s *LazyStorage) SetFromFileWithSize(r io.Reader, size int) error {
+	return fmt.Errorf("LazyStorage.SetFromFileWithSize() failed: lazy storage is read-only")
+}
+
+// GetData reads storage data to a Go slice of storage data type.
+func (s *LazyStorage) GetData() interface{} {
+	f, err := s.file.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	storage := s.class.New(s.Size, s.Location)
+	if err := storage.SetFromFileWithSize(f, s.Size); err != nil {
+		log.Fatal(err)
+	}
+
+	return storage.GetData()
+}
+
+func (s *LazyStorage) DType() gotch.DType {
+	return s.dtype
+}
+
+func (s *LazyStorage) Device() gotch.Device {
+	switch s.Location {
+	case "cuda":
+		return gotch.CudaIfAvailable()
+	default:
+		return gotch.CPU
+	}
+}
+
+// Bytes returns raw bytes of storage data.
+//
+// If zip record is not compressed and file is memory-mapped, returned bytes
+// are a slice of mapped memory and no data is copied.
+func (s *LazyStorage) Bytes() ([]byte, error) {
+	nbytes := int64(s.Size) * int64(s.dtype.Size())
+	if s.file.Method == zip.Store && s.mmap != nil {
+		offset, err := s.file.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		if offset+nbytes > int64(len(s.mmap)) || int64(s.file.UncompressedSize64) < nbytes {
+			err := fmt.Errorf("LazyStorage.Bytes() failed: storage data out of range")
+			return nil, err
+		}
+		return s.mmap[offset : offset+nbytes], nil
+	}
+
+	f, err := s.file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data := make([]byte, nbytes)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// LazyTensors holds an index of tensors in a Pytorch zip file.
+//
+// Tensors are only materialized when requested by name with `LazyTensors.Tensor()`
+// so that peak memory stays around size of loaded tensors rather than multiple
+// times of the model size.
+type LazyTensors struct {
+	tensors map[string]*StorageTensor
+	names   []string // tensor names in pickled order
+	mmap    []byte
+}
+
+// DecodeLazy memory-maps a Pytorch zip file created by `torch.save()`, unpickles
+// `data.pkl` and indexes its storages without reading any tensor data.
+//
+// NOTE. LazyTensors.Close() should be called to unmap file when done.
+func DecodeLazy(filename string) (*LazyTensors, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		err = fmt.Errorf("DecodeLazy() failed: %w", err)
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		err = fmt.Errorf("DecodeLazy() failed: %w", err)
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		err = fmt.Errorf("DecodeLazy() failed: empty file %q", filename)
+		return nil, err
+	}
+
+	mmap, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		err = fmt.Errorf("DecodeLazy() failed: mmap: %w", err)
+		return nil, err
+	}
+
+	lt := &LazyTensors{
+		tensors: make(map[string]*StorageTensor),
+		mmap:    mmap,
+	}
+
+	result, err := lazyLoadZipFile(mmap)
+	if err != nil {
+		lt.Close()
+		err = fmt.Errorf("DecodeLazy() failed: %w", err)
+		return nil, err
+	}
+
+	add := func(key, value interface{}) error {
+		name, ok := key.(string)
+		if !ok {
+			return fmt.Errorf("expected string key, got %v", reflect.TypeOf(key).String())
+		}
+		sx, isStorageTensor := value.(*StorageTensor)
+		if !isStorageTensor {
+			return fmt.Errorf("expected 'StorageTensor' type, got %v", reflect.TypeOf(value).String())
+		}
+		// Dealing with Pytorch `..._tracked` variables.
+		if sx.Source.(*LazyStorage).Size == 0 {
+			log.Printf("INFO: skip weight %q with zero data length.\n", name)
+			return nil
+		}
+		lt.tensors[name] = sx
+		lt.names = append(lt.names, name)
+		return nil
+	}
+
+	switch result := result.(type) {
+	case *Dict:
+		for _, item := range *result {
+			if err := add(item.Key, item.Value); err != nil {
+				lt.Close()
+				err = fmt.Errorf("DecodeLazy() failed: %w", err)
+				return nil, err
+			}
+		}
+	case *OrderedDict:
+		for e := result.List.Front(); e != nil; e = e.Next() {
+			item := e.Value.(*OrderedDictEntry)
+			if err := add(item.Key, item.Value); err != nil {
+				lt.Close()
+				err = fmt.Errorf("DecodeLazy() failed: %w", err)
+				return nil, err
+			}
+		}
+	default:
+		lt.Close()
+		err := fmt.Errorf("DecodeLazy() failed: expected '*pickle.OrderedDict' or '*pickle.Dict' type, got %v\n", reflect.TypeOf(result).String())
+		return nil, err
+	}
+
+	return lt, nil
+}
+
+// lazyLoadZipFile unpickles `data.pkl` from a memory-mapped zip file
+// with storages of `*LazyStorage` type.
+func lazyLoadZipFile(mmap []byte) (interface{}, error) {
+	r, err := zip.NewReader(bytes.NewReader(mmap), int64(len(mmap)))
+	if err != nil {
+		return nil, err
+	}
+
+	fileRecords := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		_, recordName := path.Split(f.Name)
+		fileRecords[recordName] = f
+	}
+
+	if _, isTorchScript := fileRecords["constants.pkl"]; isTorchScript {
+		return nil, fmt.Errorf("TorchScript is not supported")
+	}
+
+	dataFile, hasDataFile := fileRecords["data.pkl"]
+	if !hasDataFile {
+		return nil, fmt.Errorf("data.pkl not found in zip file")
+	}
+	df, err := dataFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer df.Close()
+
+	storages := make(map[string]Storage)
+
+	u := NewUnpickler(df)
+	u.FindClass = makePickleFindClass(u.FindClass)
+	u.PersistentLoad = func(savedId interface{}) (interface{}, error) {
+		tuple, tupleOk := savedId.(*Tuple)
+		if !tupleOk || tuple.Len() < 5 {
+			return nil, fmt.Errorf("PersistentLoad: storage tuple expected, got %#v", savedId)
+		}
+		typename, typenameOk := tuple.Get(0).(string)
+		if !typenameOk || typename != "storage" {
+			return nil, fmt.Errorf("unknown typename for PersistentLoad, expected 'storage' but got '%v'", tuple.Get(0))
+		}
+		dataType, dataTypeOk := tuple.Get(1).(StorageClass)
+		key, keyOk := tuple.Get(2).(string)
+		location, locationOk := tuple.Get(3).(string)
+		size, sizeOk := tuple.Get(4).(int)
+		if !dataTypeOk || !keyOk || !locationOk || !sizeOk {
+			return nil, fmt.Errorf("PersistentLoad: unexpected data types")
+		}
+
+		if storage, ok := storages[key]; ok {
+			return storage, nil
+		}
+
+		file, fileOk := fileRecords[key]
+		if !fileOk {
+			return nil, fmt.Errorf("cannot find zip record '%s'", key)
+		}
+		storage := newLazyStorage(dataType, size, location, file, mmap)
+		storages[key] = storage
+
+		return storage, nil
+	}
+
+	return u.Load()
+}
+
+// Close unmaps the zip file.
+func (lt *LazyTensors) Close() error {
+	if lt.mmap == nil {
+		return nil
+	}
+
+	err := syscall.Munmap(lt.mmap)
+	lt.mmap = nil
+
+	return err
+}
+
+// Names returns names of all tensors in pickled order.
+func (lt *LazyTensors) Names() []string {
+	names := make([]string, len(lt.names))
+	copy(names, lt.names)
+
+	return names
+}
+
+// Has returns whether a tensor with the given name exists.
+func (lt *LazyTensors) Has(name string) bool {
+	_, ok := lt.tensors[name]
+	return ok
+}
+
+// Shape returns shape of a named tensor without reading its data.
+func (lt *LazyTensors) Shape(name string) ([]int64, error) {
+	sx, ok := lt.tensors[name]
+	if !ok {
+		err := fmt.Errorf("LazyTensors.Shape() failed: tensor %q not found", name)
+		return nil, err
+	}
+
+	if sx.Source.(*LazyStorage).Size == 1 && len(sx.Size) == 0 {
+		return []int64{1}, nil
+	}
+
+	shape := make([]int64, len(sx.Size))
+	copy(shape, sx.Size)
+
+	return shape, nil
+}
+
+// DType returns dtype of a named tensor without reading its data.
+func (lt *LazyTensors) DType(name string) (gotch.DType, error) {
+	sx, ok := lt.tensors[name]
+	if !ok {
+		err := fmt.Errorf("LazyTensors.DType() failed: tensor %q not found", name)
+		return gotch.Invalid, err
+	}
+
+	return sx.Source.DType(), nil
+}
+
+// Tensor materializes a named tensor from its storage bytes.
+func (lt *LazyTensors) Tensor(name string) (*ts.Tensor, error) {
+	if lt.mmap == nil {
+		err := fmt.Errorf("LazyTensors.Tensor() failed: file has been closed")
+		return nil, err
+	}
+
+	sx, ok := lt.tensors[name]
+	if !ok {
+		err := fmt.Errorf("LazyTensors.Tensor() failed: tensor %q not found", name)
+		return nil, err
+	}
+
+	x, err := lazyTensor(sx)
+	if err != nil {
+		err = fmt.Errorf("LazyTensors.Tensor() failed: tensor %q: %w", name, err)
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func lazyTensor(sx *StorageTensor) (*ts.Tensor, error) {
+	storage := sx.Source.(*LazyStorage)
+	data, err := storage.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	dtype := storage.DType()
+	size := sx.Size
+	stride := sx.Stride
+	if storage.Size == 1 && len(size) == 0 {
+		size = []int64{1}
+		stride = []int64{1}
+	}
+
+	var x *ts.Tensor
+	elemSize := int64(dtype.Size())
+	numel := int64(ts.ElementCount(size))
+	start := sx.StorageOffset * elemSize
+	end := start + numel*elemSize
+	if reflect.DeepEqual(stride, contiguousStride(size)) && end <= int64(len(data)) {
+		// Contiguous tensor: build tensor straight from its bytes.
+		x, err = ts.OfDataSize(data[start:end], size, dtype)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		base, err := ts.OfDataSize(data, []int64{int64(storage.Size)}, dtype)
+		if err != nil {
+			return nil, err
+		}
+		strided, err := base.AsStrided(size, stride, []int64{sx.StorageOffset}, true)
+		if err != nil {
+			return nil, err
+		}
+		x, err = strided.Contiguous(true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	x, err = x.To(storage.Device(), true)
+	if err != nil {
+		return nil, err
+	}
+	if sx.RequiresGrad {
+		if err := x.RequiresGrad_(true); err != nil {
+			return nil, err
+		}
+	}
+
+	return x, nil
+}
+
+// loadLazy copies tensors from lazy tensors to matched variables in VarStore.
+// It returns names of variables not found or with mismatched shape.
+func loadLazy(vs *nn.VarStore, lt *LazyTensors, partial bool) ([]string, error) {
+	var missingVariables []string
+	for name, v := range vs.Variables() {
+		v := v
+		if !lt.Has(name) {
+			if !partial {
+				err := fmt.Errorf("there's a tensor with name %q in VarStore, but not found in the loaded weights", name)
+				return nil, err
+			}
+			missingVariables = append(missingVariables, name)
+			continue
+		}
+
+		sourceShape, err := lt.Shape(name)
+		if err != nil {
+			return nil, err
+		}
+		destShape := v.MustSize()
+		if !reflect.DeepEqual(destShape, sourceShape) {
+			if !partial {
+				err := fmt.Errorf("Mismatched shape error for variable name: %v - At store: %v - At source %v", name, destShape, sourceShape)
+				return nil, err
+			}
+			fmt.Printf("WARNING: Mismatched shape error for variable name: %v - At store: %v - At source %v. Skip loading this weight...\n", name, destShape, sourceShape)
+			missingVariables = append(missingVariables, name)
+			continue
+		}
+
+		x, err := lt.Tensor(name)
+		if err != nil {
+			return nil, err
+		}
+		ts.NoGrad(func() {
+			v.Copy_(x)
+		})
+		x.MustDrop()
+	}
+
+	return missingVariables, nil
+}
+
+// Pickling tensors:
+// =================
+
+// zipAlignment is the alignment of tensor storage records in zip file. Pytorch
+// aligns records so that storages can be memory-mapped.
+const zipAlignment = 64
+
+// storageRef represents a tensor storage which is saved as a separate zip record
+// and referenced from `data.pkl` by its persistent id.
+type storageRef struct {
+	key      string
+	class    string // Pytorch storage class name. E.g. "FloatStorage"
+	location string
+	numel    int
+	data     []byte
+}
+
+// persistentId returns storage persistent id as pickled by Pytorch:
+// ('storage', storage_type, key, location, numel)
+func (s *storageRef) persistentId() *Tuple {
+	return NewTupleFromSlice([]interface{}{
+		"storage",
+		NewGenericClass("torch", s.class),
+		s.key,
+		s.location,
+		s.numel,
+	})
+}
+
+// tensorReducer pickles a tensor as a `torch._utils._rebuild_tensor_v2` call.
+type tensorReducer struct {
+	storage      *storageRef
+	size         []int64
+	stride       []int64
+	requiresGrad bool
+}
+
+var _ PyReducer = &tensorReducer{}
+
+// PyReduce implements PyReducer interface.
+//
+// Arguments: (storage, storage_offset, size, stride, requires_grad, backward_hooks)
+func (t *tensorReducer) PyReduce() (interface{}, *Tuple, error) {
+	args := NewTupleFromSlice([]interface{}{
+		t.storage,
+		0,
+		int64SliceToTuple(t.size),
+		int64SliceToTuple(t.stride),
+		t.requiresGrad,
+		NewOrderedDict(),
+	})
+
+	return NewGenericClass("torch._utils", "_rebuild_tensor_v2"), args, nil
+}
+
+func newTensorReducer(x *ts.Tensor, key string) (*tensorReducer, error) {
+	dtype := x.DType()
+	class, ok := dtype2StorageName[dtype]
+	if !ok {
+		err := fmt.Errorf("unsupported dtype %v", dtype)
+		return nil, err
+	}
+
+	device, err := x.Device()
+	if err != nil {
+		return nil, err
+	}
+	location := "cpu"
+	if device.Name == "CUDA" {
+		location = fmt.Sprintf("cuda:%d", device.Value)
+	}
+
+	size, err := x.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	requiresGrad, err := x.RequiresGrad()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := x.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tensorReducer{
+		storage: &storageRef{
+			key:      key,
+			class:    class,
+			location: location,
+			numel:    int(x.Numel()),
+			data:     data,
+		},
+		size:         size,
+		stride:       contiguousStride(size),
+		requiresGrad: requiresGrad,
+	}, nil
+}
+
+// contiguousStride returns strides of a contiguous (row-major) tensor of given shape.
+func contiguousStride(size []int64) []int64 {
+	stride := make([]int64, len(size))
+	var acc int64 = 1
+	for i := len(size) - 1; i >= 0; i-- {
+		stride[i] = acc
+		acc *= size[i]
+	}
+
+	return stride
+}
+
+func int64SliceToTuple(slice []int64) *Tuple {
+	items := make([]interface{}, len(slice))
+	for i, v := range slice {
+		items[i] = v
+	}
+
+	return NewTupleFromSlice(items)
+}
+
+// countWriter counts bytes written to the underlying writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// writeZipFile writes pickled object and tensor storages to a zip archive
+// in Pytorch format.
+func writeZipFile(w io.Writer, obj interface{}, storages []*storageRef) error {
+	const archiveName = "archive"
+
+	cw := &countWriter{w: w}
+	zw := zip.NewWriter(cw)
+
+	createRecord := func(name string) (io.Writer, error) {
+		// Flush buffered data so that the current offset is known.
+		if err := zw.Flush(); err != nil {
+			return nil, err
+		}
+		fullName := path.Join(archiveName, name)
+		fh := &zip.FileHeader{
+			Name:   fullName,
+			Method: zip.Store,
+			Extra:  zipPadding(cw.n, fullName),
+		}
+		return zw.CreateHeader(fh)
+	}
+
+	pf, err := createRecord("data.pkl")
+	if err != nil {
+		return err
+	}
+	p := NewPickler(pf)
+	p.PersistentId = func(obj interface{}) (interface{}, bool) {
+		if s, ok := obj.(*storageRef); ok {
+			return s.persistentId(), true
+		}
+		return nil, false
+	}
+	if err := p.Dump(obj); err != nil {
+		return err
+	}
+
+	bf, err := createRecord("byteorder")
+	if err != nil {
+		return err
+	}
+	if _, err := bf.Write([]byte("little")); err != nil {
+		return err
+	}
+
+	for _, s := range storages {
+		sf, err := createRecord(path.Join("data", s.key))
+		if err != nil {
+			return err
+		}
+		if _, err := sf.Write(s.data); err != nil {
+			return err
+		}
+	}
+
+	vf, err := createRecord("version")
+	if err != nil {
+		return err
+	}
+	if _, err := vf.Write([]byte("3\n")); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// zipPadding returns a zip extra field which pads the local file header so that
+// the record data starts at a multiple of `zipAlignment`.
+//
+// Local file header: 30 bytes + file name + extra field.
+func zipPadding(offset int64, name string) []byte {
+	const headerSize = 30
+	const extraHeaderSize = 4 // 2 bytes id + 2 bytes size
+	start := offset + headerSize + int64(len(name)) + extraHeaderSize
+	padSize := (zipAlignment - start%zipAlignment) % zipAlignment
+
+	extra := make([]byte, extraHeaderSize+padSize)
+	extra[0], extra[1] = 'F', 'B'
+	binary.LittleEndian.PutUint16(extra[2:], uint16(padSize))
+	for i := extraHeaderSize; i < len(extra); i++ {
+		extra[i] = 'Z'
+	}
+
+	return extra
+}
+
 func loadLegacyFile(filename string, newUnpickler func(r io.Reader) Unpickler) (interface{}, error) {
 	f, err := os.Open(filename)
 	if err != nil {
@@ -450,6 +1189,8 @@ func makePickleFindClass(fallback func(module, name string) (interface{}, error)
 			return &FloatStorageClass{}, nil
 		case "torch.HalfStorage":
 			return &HalfStorageClass{}, nil
+		case "torch.BFloat16Storage":
+			return &BFloat16StorageClass{}, nil
 		case "torch.DoubleStorage":
 			return &DoubleStorageClass{}, nil
 		case "torch.CharStorage":
@@ -479,6 +1220,21 @@ func makePickleFindClass(fallback func(module, name string) (interface{}, error)
 // LoadAll finds and loads all weights from varstore.
 // It will throw err if one of weights from varstore cannot find from loaded pretrained model.
 func LoadAll(vs *nn.VarStore, modelFile string) error {
+	if isZipFile(modelFile) {
+		lt, err := DecodeLazy(modelFile)
+		if err != nil {
+			err = fmt.Errorf("LoadAll() failed: %w", err)
+			return err
+		}
+		defer lt.Close()
+
+		if _, err := loadLazy(vs, lt, false); err != nil {
+			err = fmt.Errorf("LoadAll() failed: %w", err)
+			return err
+		}
+		return nil
+	}
+
 	weights, err := Decode(modelFile)
 	if err != nil {
 		err = fmt.Errorf("LoadAll() failed: %w", err)
@@ -509,7 +1265,26 @@ func LoadAll(vs *nn.VarStore, modelFile string) error {
 
 // LoadPartial finds and loads weights for varstore.
 // It returns list of unfound weight names.
+//
+// For Pytorch zip format, only weights matched with variables of varstore are
+// read from file (see `DecodeLazy()`).
 func LoadPartial(vs *nn.VarStore, modelFile string) ([]string, error) {
+	if isZipFile(modelFile) {
+		lt, err := DecodeLazy(modelFile)
+		if err != nil {
+			err = fmt.Errorf("LoadPartial() failed: %w", err)
+			return nil, err
+		}
+		defer lt.Close()
+
+		missingVariables, err := loadLazy(vs, lt, true)
+		if err != nil {
+			err = fmt.Errorf("LoadPartial() failed: %w", err)
+			return nil, err
+		}
+		return missingVariables, nil
+	}
+
 	weights, err := Decode(modelFile)
 	if err != nil {
 		err = fmt.Errorf("LoadPartial() failed: %w", err)
@@ -587,6 +1362,26 @@ func (m *ModelInfor) Parameters() int {
 
 // LoadInfo loads pretrained weights and prints out name and shape of weights.
 func LoadModelInfo(modelFile string) (*ModelInfor, error) {
+	if isZipFile(modelFile) {
+		lt, err := DecodeLazy(modelFile)
+		if err != nil {
+			err = fmt.Errorf("LoadInfo() failed: %w", err)
+			return nil, err
+		}
+		defer lt.Close()
+
+		w := make(map[string][]int64)
+		var dtype gotch.DType
+		for i, name := range lt.names {
+			w[name], _ = lt.Shape(name)
+			if i == 0 {
+				dtype, _ = lt.DType(name)
+			}
+		}
+
+		return NewModelInfor(w, dtype), nil
+	}
+
 	weights, err := Decode(modelFile)
 	if err != nil {
 		err = fmt.Errorf("LoadInfo() failed: %w", err)
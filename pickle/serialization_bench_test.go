@@ -0,0 +1,126 @@
+package pickle_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/pickle"
+	"github.com/sugarme/gotch/ts"
+)
+
+// Benchmark loading a single layer from a ~256MB checkpoint.
+// Run with: go test -run=NONE -bench=LoadPartial -benchtime=5x ./pickle
+//
+// Eager loading reads every storage of checkpoint into memory, decodes all tensors
+// then copies matched ones to varstore whereas lazy loading only materializes
+// matched tensors from memory-mapped file.
+// Peak RSS is reported as "peak-MB" metric (Linux only).
+
+const (
+	benchNumLayers = 16
+	benchLayerSize = 2048 // 16 x 2048 x 2048 x 4 bytes = 256MB
+)
+
+func benchModelFile(b *testing.B) string {
+	file := filepath.Join(b.TempDir(), "model.pt")
+	var namedTensors []ts.NamedTensor
+	for i := 0; i < benchNumLayers; i++ {
+		x := ts.MustZeros([]int64{benchLayerSize, benchLayerSize}, gotch.Float, gotch.CPU)
+		namedTensors = append(namedTensors, ts.NamedTensor{Name: fmt.Sprintf("layer%v.weight", i), Tensor: x})
+	}
+	if err := pickle.EncodeNamedTensors(namedTensors, file); err != nil {
+		b.Fatal(err)
+	}
+	for _, x := range namedTensors {
+		x.Tensor.MustDrop()
+	}
+
+	return file
+}
+
+// resetPeakRSS resets VmHWM of current process.
+func resetPeakRSS() error {
+	return os.WriteFile("/proc/self/clear_refs", []byte("5"), 0)
+}
+
+// peakRSS returns VmHWM of current process in MB.
+func peakRSS() (float64, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmHWM:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		kb, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		return kb / 1024, nil
+	}
+
+	return 0, fmt.Errorf("VmHWM not found")
+}
+
+func benchmarkLoadPartial(b *testing.B, load func(vs *nn.VarStore, file string) error) {
+	file := benchModelFile(b)
+	if err := resetPeakRSS(); err != nil {
+		b.Skipf("peak RSS not available: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vs := nn.NewVarStore(gotch.CPU)
+		vs.Root().Sub("layer0").MustZeros("weight", []int64{benchLayerSize, benchLayerSize})
+		if err := load(vs, file); err != nil {
+			b.Fatal(err)
+		}
+		vs.Destroy()
+	}
+	b.StopTimer()
+
+	peak, err := peakRSS()
+	if err != nil {
+		b.Skipf("peak RSS not available: %v", err)
+	}
+	b.ReportMetric(peak, "peak-MB")
+}
+
+func BenchmarkLoadPartial_Eager(b *testing.B) {
+	benchmarkLoadPartial(b, func(vs *nn.VarStore, file string) error {
+		// NOTE. `pickle.Decode()` loads zip checkpoints lazily, hence the eager loader is used here.
+		weights, err := pickle.DecodeEager(file)
+		if err != nil {
+			return err
+		}
+		var namedTensors []ts.NamedTensor
+		for name, x := range weights {
+			namedTensors = append(namedTensors, ts.NamedTensor{Name: name, Tensor: x})
+		}
+		_, err = vs.LoadWeightsPartial(namedTensors)
+		for _, x := range weights {
+			x.MustDrop()
+		}
+		return err
+	})
+}
+
+func BenchmarkLoadPartial_Lazy(b *testing.B) {
+	benchmarkLoadPartial(b, func(vs *nn.VarStore, file string) error {
+		_, err := pickle.LoadPartial(vs, file)
+		return err
+	})
+}
@@ -0,0 +1,200 @@
+package pickle_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/half"
+	"github.com/sugarme/gotch/nn"
+	"github.com/sugarme/gotch/pickle"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestPickler_Dumps(t *testing.T) {
+	list := pickle.NewListFromSlice([]interface{}{1, 300, 70000, int64(1) << 40, -5, 1.5, "gotch", true, nil})
+	s, err := pickle.Dumps(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := pickle.Loads(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := pickle.NewListFromSlice([]interface{}{1, 300, 70000, 1 << 40, -5, 1.5, "gotch", true, nil})
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %#v\n", want)
+		t.Errorf("got: %#v\n", got)
+	}
+}
+
+func TestEncodeNamedTensors(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  interface{}
+		dtype gotch.DType
+	}{
+		{"half", []half.Float16{half.Fromfloat32(1.5), half.Fromfloat32(-2)}, gotch.Half},
+		{"bfloat16", []half.BFloat16{half.BFloat16(half.Float32ToBFloat16(1.5)), half.BFloat16(half.Float32ToBFloat16(-2))}, gotch.BFloat16},
+		{"float", []float32{1.5, -2, 3.25, 4}, gotch.Float},
+		{"double", []float64{1.5, -2, 3.25, 4}, gotch.Double},
+		{"long", []int64{1, -2, 3, 1 << 40}, gotch.Int64},
+		{"int", []int32{1, -2, 3, 4}, gotch.Int},
+		{"short", []int16{1, -2, 3, 4}, gotch.Int16},
+		{"char", []int8{1, -2, 3, 4}, gotch.Int8},
+		{"byte", []uint8{1, 2, 3, 255}, gotch.Uint8},
+		{"bool", []bool{true, false, false, true}, gotch.Bool},
+	}
+
+	var namedTensors []ts.NamedTensor
+	for _, tt := range tests {
+		x := ts.MustOfSlice(tt.data, ts.WithDType(tt.dtype))
+		if x.DType() != tt.dtype {
+			x = x.MustTotype(tt.dtype, true)
+		}
+		namedTensors = append(namedTensors, ts.NamedTensor{Name: tt.name, Tensor: x})
+	}
+
+	file := filepath.Join(t.TempDir(), "model.pt")
+	if err := pickle.EncodeNamedTensors(namedTensors, file); err != nil {
+		t.Fatal(err)
+	}
+
+	weights, err := pickle.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, x := range namedTensors {
+		got, ok := weights[x.Name]
+		if !ok {
+			t.Errorf("missing tensor %q", x.Name)
+			continue
+		}
+		if got.DType() != x.Tensor.DType() {
+			t.Errorf("%q: want dtype %v, got %v", x.Name, x.Tensor.DType(), got.DType())
+		}
+		if !reflect.DeepEqual(x.Tensor.Vals(), got.Vals()) {
+			t.Errorf("%q: want %v, got %v", x.Name, x.Tensor.Vals(), got.Vals())
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	vs := nn.NewVarStore(gotch.CPU)
+	path := vs.Root()
+	nn.NewLinear(path.Sub("fc"), 4, 3, nn.DefaultLinearConfig())
+	nn.NewBatchNorm(path.Sub("bn"), 1, 3, nn.DefaultBatchNormConfig())
+
+	file := filepath.Join(t.TempDir(), "model.pt")
+	if err := pickle.Encode(vs, file); err != nil {
+		t.Fatal(err)
+	}
+
+	weights, err := pickle.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, x := range vs.Variables() {
+		got, ok := weights[name]
+		if !ok {
+			t.Errorf("missing tensor %q", name)
+			continue
+		}
+		if !reflect.DeepEqual(x.MustSize(), got.MustSize()) {
+			t.Errorf("%q: want shape %v, got %v", name, x.MustSize(), got.MustSize())
+		}
+		if !reflect.DeepEqual(x.Float64Values(), got.Float64Values()) {
+			t.Errorf("%q: want %v, got %v", name, x.Float64Values(), got.Float64Values())
+		}
+	}
+}
+
+func TestDecodeLazy(t *testing.T) {
+	x := ts.MustArange(ts.IntScalar(6), gotch.Float, gotch.CPU).MustView([]int64{2, 3}, true)
+	y := ts.MustOfSlice([]int64{1, 2, 3})
+	namedTensors := []ts.NamedTensor{
+		{Name: "x", Tensor: x},
+		{Name: "y", Tensor: y},
+	}
+
+	file := filepath.Join(t.TempDir(), "model.pt")
+	if err := pickle.EncodeNamedTensors(namedTensors, file); err != nil {
+		t.Fatal(err)
+	}
+
+	lt, err := pickle.DecodeLazy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lt.Close()
+
+	if !reflect.DeepEqual(lt.Names(), []string{"x", "y"}) {
+		t.Errorf("want names: [x y], got: %v", lt.Names())
+	}
+
+	shape, err := lt.Shape("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(shape, []int64{2, 3}) {
+		t.Errorf("want shape: [2 3], got: %v", shape)
+	}
+	dtype, err := lt.DType("y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dtype != gotch.Int64 {
+		t.Errorf("want dtype: int64, got: %v", dtype)
+	}
+
+	got, err := lt.Tensor("y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Vals(), []int64{1, 2, 3}) {
+		t.Errorf("want: [1 2 3], got: %v", got.Vals())
+	}
+
+	if _, err := lt.Tensor("z"); err == nil {
+		t.Errorf("want error for missing tensor")
+	}
+}
+
+func TestLoadPartial_Lazy(t *testing.T) {
+	src := nn.NewVarStore(gotch.CPU)
+	nn.NewLinear(src.Root().Sub("fc1"), 4, 3, nn.DefaultLinearConfig())
+	nn.NewLinear(src.Root().Sub("fc2"), 3, 2, nn.DefaultLinearConfig())
+
+	file := filepath.Join(t.TempDir(), "model.pt")
+	if err := pickle.Encode(src, file); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := nn.NewVarStore(gotch.CPU)
+	nn.NewLinear(dst.Root().Sub("fc1"), 4, 3, nn.DefaultLinearConfig())
+	nn.NewLinear(dst.Root().Sub("fc3"), 3, 2, nn.DefaultLinearConfig())
+
+	missing, err := pickle.LoadPartial(dst, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 2 {
+		t.Errorf("want 2 missing variables, got: %v", missing)
+	}
+
+	srcVars := src.Variables()
+	for name, x := range dst.Variables() {
+		want, ok := srcVars[name]
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(want.Float64Values(), x.Float64Values()) {
+			t.Errorf("%q: want %v, got %v", name, want.Float64Values(), x.Float64Values())
+		}
+	}
+}
@@ -46,6 +46,20 @@ type Storage interface {
 	Device() gotch.Device
 }
 
+// dtype2StorageName maps DType to Pytorch storage class name.
+var dtype2StorageName map[gotch.DType]string = map[gotch.DType]string{
+	gotch.Half:     "HalfStorage",
+	gotch.BFloat16: "BFloat16Storage",
+	gotch.Float:    "FloatStorage",
+	gotch.Double:   "DoubleStorage",
+	gotch.Int8:     "CharStorage",
+	gotch.Int16:    "ShortStorage",
+	gotch.Int:      "IntStorage",
+	gotch.Int64:    "LongStorage",
+	gotch.Uint8:    "ByteStorage",
+	gotch.Bool:     "BoolStorage",
+}
+
 // BaseStorage represents a base storage.
 type BaseStorage struct {
 	Size     int
@@ -602,7 +616,7 @@ func (s *BoolStorage) GetData() interface{} {
 }
 
 func (s *BoolStorage) DType() gotch.DType {
-	return gotch.Float
+	return gotch.Bool
 }
 
 func (s *BoolStorage) Device() gotch.Device {
@@ -0,0 +1,75 @@
+package gotch
+
+import (
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	lib "github.com/sugarme/gotch/libtch"
+)
+
+var (
+	randMu sync.Mutex
+	randGo *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano())) // Go generator reset by `ManualSeed()`. Guarded by `randMu`.
+)
+
+// ManualSeed seeds random number generators for reproducibility:
+//   - libtorch default generators of CPU and all CUDA devices (e.g. used by
+//     `ts.MustRandn()`, dropout and variable initialization).
+//   - gotch Go generator (see `NewRand()`). Samplers in `dutil` and
+//     transforms in `vision/aug` derive their generators from it unless a
+//     generator is injected explicitly.
+//
+// NOTE. The global source of "math/rand" package is not seeded as `rand.Seed()`
+// is a no-op since Go 1.24.
+//
+// NOTE. Results may still differ between runs on CUDA devices unless
+// deterministic algorithms are enabled with `SetDeterministic()`.
+func ManualSeed(seed int64) {
+	lib.AtManualSeed(seed)
+
+	randMu.Lock()
+	randGo = rand.New(rand.NewSource(seed))
+	randMu.Unlock()
+}
+
+// NewRand creates a new random number generator whose seed is drawn from
+// gotch Go generator, hence it's reproducible after `ManualSeed()`.
+//
+// It's safe to call from multiple goroutines. The returned generator isn't.
+func NewRand() *rand.Rand {
+	randMu.Lock()
+	seed := randGo.Int63()
+	randMu.Unlock()
+
+	return rand.New(rand.NewSource(seed))
+}
+
+// SetDeterministic sets whether libtorch ops must use deterministic algorithms.
+// It also sets cudnn deterministic mode and disables cudnn benchmark mode if enabled.
+//
+// If warnOnlyOpt is true, ops that have no deterministic implementation log a warning
+// instead of throwing an error. Default=false.
+//
+// NOTE. Deterministic cuBLAS ops require environment variable
+// "CUBLAS_WORKSPACE_CONFIG=:4096:8". It's set if not specified yet.
+func SetDeterministic(b bool, warnOnlyOpt ...bool) {
+	warnOnly := false
+	if len(warnOnlyOpt) > 0 {
+		warnOnly = warnOnlyOpt[0]
+	}
+
+	if b {
+		if _, ok := os.LookupEnv("CUBLAS_WORKSPACE_CONFIG"); !ok {
+			os.Setenv("CUBLAS_WORKSPACE_CONFIG", ":4096:8")
+		}
+	}
+
+	lib.AtSetDeterministic(b, warnOnly)
+}
+
+// IsDeterministic returns whether libtorch ops must use deterministic algorithms.
+func IsDeterministic() bool {
+	return lib.AtDeterministic()
+}
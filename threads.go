@@ -0,0 +1,80 @@
+package gotch
+
+import (
+	"fmt"
+	"runtime"
+
+	lib "github.com/sugarme/gotch/libtch"
+)
+
+// Thread pools of libtorch on CPU
+//
+// Libtorch uses two thread pools on CPU:
+//   - intra-op pool (OpenMP) parallelizing a single op, e.g. a convolution.
+//   - inter-op pool running independent ops concurrently, e.g. in TorchScript
+//     models with forks.
+//
+// Both pools are separate from Go runtime threads. GOMAXPROCS only limits the
+// number of OS threads running Go code. A goroutine calling a libtorch op blocks
+// its OS thread in cgo while the op fans out to intra-op threads, so n goroutines
+// running ops concurrently may keep up to n x GetNumThreads() threads busy.
+// To avoid oversubscription on servers running many goroutines, cap the intra-op
+// pool so that (number of concurrent goroutines calling libtorch) x NumThreads is
+// about number of CPU cores, e.g. NumThreads = 1 for one request per core.
+//
+// Thread numbers can be set with environment variables "GOTCH_NUM_THREADS"
+// and "GOTCH_NUM_INTEROP_THREADS", which are applied when gotch package is
+// initialized, before any op runs.
+
+// SetNumThreads sets number of threads of libtorch intra-op pool on CPU.
+//
+// NOTE. OS threads which already ran parallel ops keep their setting, so it
+// should be called before running ops, i.e. at program start.
+func SetNumThreads(n int) error {
+	if n < 1 {
+		err := fmt.Errorf("SetNumThreads() failed: number of threads must be positive. Got %v", n)
+		return err
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	lib.AtSetNumThreads(n)
+	if msg := lib.LastErr(); msg != "" {
+		err := fmt.Errorf("SetNumThreads() failed: %v", msg)
+		return err
+	}
+
+	return nil
+}
+
+// GetNumThreads returns number of threads of libtorch intra-op pool on CPU.
+func GetNumThreads() int {
+	return lib.AtGetNumThreads()
+}
+
+// SetNumInteropThreads sets number of threads of libtorch inter-op pool on CPU.
+//
+// NOTE. It can only be called once and before any inter-op parallel work starts.
+func SetNumInteropThreads(n int) error {
+	if n < 1 {
+		err := fmt.Errorf("SetNumInteropThreads() failed: number of threads must be positive. Got %v", n)
+		return err
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	lib.AtSetNumInteropThreads(n)
+	if msg := lib.LastErr(); msg != "" {
+		err := fmt.Errorf("SetNumInteropThreads() failed: %v", msg)
+		return err
+	}
+
+	return nil
+}
+
+// GetNumInteropThreads returns number of threads of libtorch inter-op pool on CPU.
+func GetNumInteropThreads() int {
+	return lib.AtGetNumInteropThreads()
+}
@@ -0,0 +1,41 @@
+package gotch_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/sugarme/gotch"
+)
+
+func TestSetNumThreads(t *testing.T) {
+	// Intra-op setting is per OS thread, hence read it back on the same thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	n := gotch.GetNumThreads()
+	defer gotch.SetNumThreads(n)
+
+	if err := gotch.SetNumThreads(2); err != nil {
+		t.Fatal(err)
+	}
+	if got := gotch.GetNumThreads(); got != 2 {
+		t.Errorf("Want 2 threads, got %v", got)
+	}
+
+	if err := gotch.SetNumThreads(0); err == nil {
+		t.Errorf("Want error for zero threads")
+	}
+}
+
+func TestSetNumInteropThreads(t *testing.T) {
+	// First call may fail if the pool was set by "GOTCH_NUM_INTEROP_THREADS"
+	// or already started. Second call always fails as the pool can only be set once.
+	gotch.SetNumInteropThreads(2)
+
+	if err := gotch.SetNumInteropThreads(3); err == nil {
+		t.Errorf("Want libtorch error for setting inter-op threads twice")
+	}
+	if got := gotch.GetNumInteropThreads(); got < 1 {
+		t.Errorf("Want positive inter-op threads, got %v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package ts
+
+// Automatic mixed precision.
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+
+	"github.com/sugarme/gotch"
+	lib "github.com/sugarme/gotch/libtch"
+)
+
+// Autocast runs fn with automatic mixed precision enabled. Inside fn, eligible
+// ops (e.g. matmul, linear, convolution) run in lower precision dtype while
+// precision-sensitive ops (e.g. softmax, losses) run in float32.
+//
+// Supported dtypes are:
+//   - gotch.BFloat16: autocast on CPU and CUDA devices.
+//   - gotch.Half: autocast on CUDA devices only.
+//
+// Autocast state in libtorch is thread-local, so the calling goroutine is locked
+// to its OS thread while fn runs and ops should be run by the calling goroutine.
+// Autocast can be nested, previous state is restored when fn returns.
+//
+// Backward passes should be run outside Autocast. Gradients have the same
+// dtype as corresponding forward ops.
+func Autocast(dtype gotch.DType, fn func()) error {
+	var cpu, cuda bool
+	switch dtype {
+	case gotch.BFloat16:
+		cpu, cuda = true, true
+	case gotch.Half:
+		cuda = true
+	default:
+		err := fmt.Errorf("Autocast() failed: unsupported dtype %v. Expected Half or BFloat16", dtype)
+		return err
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	state, err := getAutocastState()
+	if err != nil {
+		return fmt.Errorf("Autocast() failed: %w", err)
+	}
+	defer setAutocastState(state)
+
+	if cpu {
+		lib.AtAutocastCpuSetEnabled(true)
+		lib.AtAutocastSetCpuDtype(dtype.CKind())
+	}
+	if cuda {
+		lib.AtAutocastSetEnabled(true)
+		lib.AtAutocastSetGpuDtype(dtype.CKind())
+	}
+	if err := TorchErr(); err != nil {
+		return fmt.Errorf("Autocast() failed: %w", err)
+	}
+
+	lib.AtAutocastIncrementNesting()
+	defer func() {
+		// Cached casts of leaf tensors (e.g. weights) are freed when leaving
+		// the outermost autocast.
+		if lib.AtAutocastDecrementNesting() == 0 {
+			lib.AtAutocastClearCache()
+		}
+	}()
+
+	fn()
+
+	return nil
+}
+
+// MustAutocast runs fn with automatic mixed precision enabled. It panics if error.
+func MustAutocast(dtype gotch.DType, fn func()) {
+	err := Autocast(dtype, fn)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// AutocastEnabled returns whether autocast is enabled for the device type
+// on the current OS thread.
+func AutocastEnabled(device gotch.Device) bool {
+	if device.IsCuda() {
+		return lib.AtAutocastIsEnabled()
+	}
+
+	return lib.AtAutocastCpuIsEnabled()
+}
+
+// autocastState is a snapshot of thread-local autocast state.
+type autocastState struct {
+	cpuEnabled  bool
+	cudaEnabled bool
+	cpuDType    int32
+	cudaDType   int32
+}
+
+func getAutocastState() (autocastState, error) {
+	s := autocastState{
+		cpuEnabled:  lib.AtAutocastCpuIsEnabled(),
+		cudaEnabled: lib.AtAutocastIsEnabled(),
+		cpuDType:    lib.AtAutocastGetCpuDtype(),
+		cudaDType:   lib.AtAutocastGetGpuDtype(),
+	}
+	if err := TorchErr(); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+func setAutocastState(s autocastState) {
+	lib.AtAutocastCpuSetEnabled(s.cpuEnabled)
+	lib.AtAutocastSetEnabled(s.cudaEnabled)
+	lib.AtAutocastSetCpuDtype(s.cpuDType)
+	lib.AtAutocastSetGpuDtype(s.cudaDType)
+	if err := TorchErr(); err != nil {
+		log.Printf("WARNING: restoring autocast state failed: %v\n", err)
+	}
+}
+
+// AmpNonFiniteCheckAndUnscale_ multiplies the tensor in-place by invScale and
+// sets foundInf to 1.0 if the tensor contains inf or NaN values.
+//
+// foundInf and invScale are single-element float32 tensors on the same device
+// as the tensor.
+//
+// NOTE. libtorch implements this fused op for CUDA tensors only.
+func (ts *Tensor) AmpNonFiniteCheckAndUnscale_(foundInf, invScale *Tensor) error {
+	lib.At_AmpNonFiniteCheckAndUnscale(ts.ctensor, foundInf.ctensor, invScale.ctensor)
+	if err := TorchErr(); err != nil {
+		err = fmt.Errorf("AmpNonFiniteCheckAndUnscale_() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package ts_test
+
+import (
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestAutocast_CPU(t *testing.T) {
+	a := ts.MustRandn([]int64{4, 8}, gotch.Float, gotch.CPU)
+	b := ts.MustRandn([]int64{8, 2}, gotch.Float, gotch.CPU)
+
+	err := ts.Autocast(gotch.BFloat16, func() {
+		if !ts.AutocastEnabled(gotch.CPU) {
+			t.Errorf("Expected CPU autocast enabled inside Autocast()")
+		}
+
+		c := a.MustMatmul(b, false)
+		if got := c.DType(); got != gotch.BFloat16 {
+			t.Errorf("Expected matmul dtype BFloat16 inside Autocast(), got %v", got)
+		}
+
+		// Nested autocast restores enclosing state.
+		ts.MustAutocast(gotch.BFloat16, func() {})
+		if !ts.AutocastEnabled(gotch.CPU) {
+			t.Errorf("Expected CPU autocast enabled after nested Autocast()")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ts.AutocastEnabled(gotch.CPU) {
+		t.Errorf("Expected CPU autocast disabled after Autocast()")
+	}
+	c := a.MustMatmul(b, false)
+	if got := c.DType(); got != gotch.Float {
+		t.Errorf("Expected matmul dtype Float outside Autocast(), got %v", got)
+	}
+}
+
+func TestAutocast_InvalidDType(t *testing.T) {
+	err := ts.Autocast(gotch.Int64, func() {
+		t.Errorf("Expected fn not called")
+	})
+	if err == nil {
+		t.Errorf("Expected error for unsupported dtype")
+	}
+}
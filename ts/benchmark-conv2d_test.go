@@ -1,6 +1,8 @@
 package ts_test
 
 import (
+	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/sugarme/gotch"
@@ -39,6 +41,85 @@ func BenchmarkConv2dCPU(b *testing.B) {
 	}
 }
 
+// conv2dInputs creates input and weight of the CPU benchmarks.
+func conv2dInputs() (*ts.Tensor, *ts.Tensor) {
+	x := ts.MustRandn([]int64{32, 64, 64, 64}, gotch.Float, gotch.CPU)
+	kDims := []int64{1, 64, 3, 3}
+	kernelTemplate := []int64{
+		1, 1, 1,
+		1, -8, 1,
+		1, 1, 1,
+	}
+	var kernelData []int64
+	for i := 0; i < int(kDims[0]*kDims[1]); i++ {
+		kernelData = append(kernelData, kernelTemplate...)
+	}
+	weight := ts.MustOfSlice(kernelData).MustView(kDims, true).MustTotype(gotch.Float, true)
+
+	return x, weight
+}
+
+// BenchmarkConv2dCPUThreads shows effect of number of libtorch intra-op threads
+// on ops run by a single goroutine.
+//
+// GOMAXPROCS=8 go test -bench=BenchmarkConv2dCPUThreads -benchtime=20x -run=^a | tee op-conv-threads-bench.txt
+// benchstat op-conv-threads-bench.txt
+func BenchmarkConv2dCPUThreads(b *testing.B) {
+	x, weight := conv2dInputs()
+	stride := []int64{1, 1}
+	padding := []int64{0, 0}
+	dilation := []int64{1, 1}
+
+	defaultThreads := gotch.GetNumThreads()
+	for _, n := range []int{1, 2, 4, defaultThreads} {
+		b.Run(fmt.Sprintf("threads=%d", n), func(b *testing.B) {
+			// Number of threads is applied to the calling OS thread. Other OS threads
+			// pick it up at their first parallel op only.
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			if err := gotch.SetNumThreads(n); err != nil {
+				b.Fatal(err)
+			}
+			defer gotch.SetNumThreads(defaultThreads)
+
+			for i := 0; i < b.N; i++ {
+				out, err := ts.Conv2d(x, weight, ts.NewTensor(), stride, padding, dilation, 1)
+				if err != nil {
+					panic(err)
+				}
+				out.MustDrop()
+			}
+		})
+	}
+}
+
+// BenchmarkConv2dCPUParallel runs ops from concurrent goroutines. Each goroutine
+// runs ops on its own OS thread which fans out to intra-op threads, so capping
+// intra-op threads avoids oversubscription. Compare runs with different number
+// of threads set at program start:
+//
+// GOTCH_NUM_THREADS=1 GOMAXPROCS=8 go test -bench=BenchmarkConv2dCPUParallel -benchtime=100x -run=^a | tee op-conv-parallel-1.txt
+// GOTCH_NUM_THREADS=8 GOMAXPROCS=8 go test -bench=BenchmarkConv2dCPUParallel -benchtime=100x -run=^a | tee op-conv-parallel-8.txt
+// benchstat op-conv-parallel-1.txt op-conv-parallel-8.txt
+func BenchmarkConv2dCPUParallel(b *testing.B) {
+	x, weight := conv2dInputs()
+	stride := []int64{1, 1}
+	padding := []int64{0, 0}
+	dilation := []int64{1, 1}
+
+	b.Run(fmt.Sprintf("threads=%d", gotch.GetNumThreads()), func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				out, err := ts.Conv2d(x, weight, ts.NewTensor(), stride, padding, dilation, 1)
+				if err != nil {
+					panic(err)
+				}
+				out.MustDrop()
+			}
+		})
+	})
+}
+
 // GOMAXPROCS=8 go test -bench=BenchmarkConv2d -benchtime=100x -run=^a | tee op-conv-bench.txt
 // benchstat op-conv-bench.txt
 func BenchmarkConv2dCUDA(b *testing.B) {
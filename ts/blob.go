@@ -0,0 +1,150 @@
+package ts
+
+// Zero-copy tensors over external memory.
+
+import (
+	"fmt"
+	"log"
+	"unsafe"
+
+	"github.com/sugarme/gotch"
+	lib "github.com/sugarme/gotch/libtch"
+)
+
+// FromBlob creates a CPU tensor over the memory at `ptr` without copying data.
+//
+// Parameters:
+//   - ptr: address of the first element. It must point to C-allocated memory
+//     (e.g. C.malloc or mmap) or memory pinned outside the Go heap, never Go
+//     memory: libtorch keeps the pointer after FromBlob returns, whereas cgo
+//     forbids C from retaining Go pointers and the returned tensor doesn't keep
+//     Go memory alive.
+//   - shape: tensor shape.
+//   - strides: element strides of each dimension. If nil, the memory is assumed
+//     to be contiguous in row-major (C) order.
+//   - dtype: element type.
+//   - deleter: called once when libtorch releases the memory, i.e. after the
+//     returned tensor and all tensors sharing its storage (views) are dropped.
+//     It's a place to free or unmap the memory. It runs synchronously inside
+//     the C call releasing the last tensor, which can be `Drop()` or a finalizer,
+//     so it can be called from any goroutine. It's run outside of gotch's tensor
+//     lock, hence it may create or drop tensors. If nil, the caller keeps ownership and must keep the memory
+//     valid as long as the tensors are alive.
+//
+// If FromBlob returns an error, deleter is not called and the caller keeps ownership.
+//
+// Example:
+//
+//	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+//	x, err := ts.FromBlob(unsafe.Pointer(&data[0]), []int64{n, 3, 224, 224}, nil, gotch.Float, func() {
+//		syscall.Munmap(data)
+//	})
+func FromBlob(ptr unsafe.Pointer, shape []int64, strides []int64, dtype gotch.DType, deleter func()) (*Tensor, error) {
+	if ptr == nil && FlattenDim(shape) > 0 {
+		err := fmt.Errorf("FromBlob() failed: nil data pointer")
+		return nil, err
+	}
+	if strides == nil {
+		strides = contiguousStrides(shape)
+	}
+	if len(strides) != len(shape) {
+		err := fmt.Errorf("FromBlob() failed: shape %v and strides %v mismatched", shape, strides)
+		return nil, err
+	}
+
+	if deleter == nil {
+		ctensor := lib.AtTensorOfBlob(ptr, shape, strides, dtype.CKind(), gotch.CPU.CInt())
+		if err := TorchErr(); err != nil {
+			err = fmt.Errorf("FromBlob() failed: %w", err)
+			return nil, err
+		}
+
+		return newTensor(ctensor, "FromBlob"), nil
+	}
+
+	ctx := lib.PStore.Set(deleter)
+	ctensor := lib.AtTensorOfBlobWithDeleter(ptr, shape, strides, dtype.CKind(), gotch.CPU.CInt(), ctx)
+	if err := TorchErr(); err != nil {
+		// Storage hasn't been created, hence the deleter won't be called.
+		if lib.PStore.Get(ctx) != nil {
+			lib.PStore.Free(ctx)
+		}
+		err = fmt.Errorf("FromBlob() failed: %w", err)
+		return nil, err
+	}
+
+	return newTensor(ctensor, "FromBlob"), nil
+}
+
+// MustFromBlob creates a CPU tensor over the memory at `ptr` without copying data. It panics if error.
+func MustFromBlob(ptr unsafe.Pointer, shape []int64, strides []int64, dtype gotch.DType, deleter func()) *Tensor {
+	x, err := FromBlob(ptr, shape, strides, dtype, deleter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return x
+}
+
+// contiguousStrides returns element strides of a contiguous row-major tensor.
+func contiguousStrides(shape []int64) []int64 {
+	strides := make([]int64, len(shape))
+	stride := int64(1)
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= shape[i]
+	}
+
+	return strides
+}
+
+// AsBytes returns a byte slice over the memory of a contiguous CPU tensor
+// without copying data. It's the mirror of `FromBlob()`.
+//
+// NOTE. The slice is only valid while the tensor is alive, i.e. not dropped
+// nor garbage collected (use `runtime.KeepAlive()` if needed). Writing to
+// the slice modifies the tensor.
+func (ts *Tensor) AsBytes() ([]byte, error) {
+	device, err := ts.Device()
+	if err != nil {
+		err = fmt.Errorf("AsBytes() failed: %w", err)
+		return nil, err
+	}
+	if device.Name != "CPU" {
+		err = fmt.Errorf("AsBytes() failed: expected a CPU tensor, got device %v", device)
+		return nil, err
+	}
+
+	contiguous, err := ts.IsContiguous()
+	if err != nil {
+		err = fmt.Errorf("AsBytes() failed: %w", err)
+		return nil, err
+	}
+	if !contiguous {
+		err = fmt.Errorf("AsBytes() failed: expected a contiguous tensor. Use `Tensor.Contiguous()` first")
+		return nil, err
+	}
+
+	nbytes := int(ts.Numel() * ts.DType().Size())
+	if nbytes == 0 {
+		return []byte{}, nil
+	}
+
+	ptr, err := ts.DataPtr()
+	if err != nil {
+		err = fmt.Errorf("AsBytes() failed: %w", err)
+		return nil, err
+	}
+
+	return unsafe.Slice((*byte)(ptr), nbytes), nil
+}
+
+// MustAsBytes returns a byte slice over the memory of a contiguous CPU tensor. It panics if error.
+func (ts *Tensor) MustAsBytes() []byte {
+	b, err := ts.AsBytes()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return b
+}
@@ -0,0 +1,106 @@
+package ts_test
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+// mmapFloat32 allocates n float32 elements outside Go memory.
+func mmapFloat32(t *testing.T, n int) ([]byte, []float32) {
+	mem, err := syscall.Mmap(-1, 0, n*4, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mem, unsafe.Slice((*float32)(unsafe.Pointer(&mem[0])), n)
+}
+
+func TestFromBlob(t *testing.T) {
+	mem, data := mmapFloat32(t, 6)
+	for i := range data {
+		data[i] = float32(i)
+	}
+
+	released := false
+	x, err := ts.FromBlob(unsafe.Pointer(&data[0]), []int64{2, 3}, nil, gotch.Float, func() {
+		released = true
+		syscall.Munmap(mem)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := ts.MustValues[float32](x)
+	want := []float32{0, 1, 2, 3, 4, 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// No copy: changes of the memory are seen by the tensor and vice versa.
+	data[0] = 10
+	if v := ts.MustValues[float32](x)[0]; v != 10 {
+		t.Errorf("want first element 10, got %v", v)
+	}
+	x.MustFill_(ts.FloatScalar(2))
+	if data[5] != 2 {
+		t.Errorf("want memory element 2, got %v", data[5])
+	}
+
+	b := x.MustAsBytes()
+	if len(b) != 6*4 {
+		t.Errorf("want 24 bytes, got %v", len(b))
+	}
+	if unsafe.Pointer(&b[0]) != unsafe.Pointer(&data[0]) {
+		t.Errorf("AsBytes() should share memory with the tensor")
+	}
+
+	x.MustDrop()
+	if !released {
+		t.Errorf("Expected deleter to be called when the tensor is dropped")
+	}
+}
+
+func TestFromBlob_Strides(t *testing.T) {
+	mem, data := mmapFloat32(t, 6)
+	defer syscall.Munmap(mem)
+	for i := range data {
+		data[i] = float32(i)
+	}
+
+	// Column-major 3x2 matrix.
+	x := ts.MustFromBlob(unsafe.Pointer(&data[0]), []int64{3, 2}, []int64{1, 3}, gotch.Float, nil)
+	defer x.MustDrop()
+
+	got := ts.MustValues[float32](x)
+	want := []float32{0, 3, 1, 4, 2, 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if _, err := x.AsBytes(); err == nil {
+		t.Errorf("Expected error for non-contiguous tensor")
+	}
+}
+
+func TestFromBlob_DeleterDropsTensor(t *testing.T) {
+	mem, data := mmapFloat32(t, 4)
+
+	released := false
+	x := ts.MustFromBlob(unsafe.Pointer(&data[0]), []int64{4}, nil, gotch.Float, func() {
+		// Creating and dropping tensors inside the deleter must not deadlock.
+		y := ts.MustOnes([]int64{2}, gotch.Float, gotch.CPU)
+		y.MustDrop()
+		released = true
+		syscall.Munmap(mem)
+	})
+
+	x.MustDrop()
+	if !released {
+		t.Errorf("Expected deleter to be called when the tensor is dropped")
+	}
+}
@@ -0,0 +1,227 @@
+package ts
+
+// Generic, type-safe tensor constructors and accessors.
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"unsafe"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/half"
+	lib "github.com/sugarme/gotch/libtch"
+)
+
+// Number is a set of Go types which can be tensor elements.
+//
+// Go type and tensor dtype correspondence:
+//   - uint8: Uint8
+//   - int8: Int8
+//   - int16: Int16
+//   - int32: Int
+//   - int64: Int64
+//   - half.Float16: Half
+//   - half.BFloat16: BFloat16
+//   - float32: Float
+//   - float64: Double
+//   - bool: Bool
+//   - complex64: ComplexFloat
+//   - complex128: ComplexDouble
+type Number interface {
+	uint8 | int8 | int16 | int32 | int64 |
+		half.Float16 | half.BFloat16 | float32 | float64 |
+		bool | complex64 | complex128
+}
+
+// DTypeOf returns tensor dtype corresponding to Go type T.
+func DTypeOf[T Number]() gotch.DType {
+	var zero T
+	switch any(zero).(type) {
+	case half.Float16:
+		return gotch.Half
+	case half.BFloat16:
+		return gotch.BFloat16
+	}
+
+	dtype, err := gotch.GoKind2DType(reflect.TypeOf(zero).Kind())
+	if err != nil {
+		// Unreachable: all other types of `Number` have a corresponding dtype.
+		log.Fatal(err)
+	}
+
+	return dtype
+}
+
+// checkDType checks whether Go type T matches tensor dtype.
+func checkDType[T Number](dtype gotch.DType) error {
+	var zero T
+	typ, err := dtype.GoType()
+	if err != nil {
+		return err
+	}
+
+	if dtype != DTypeOf[T]() || typ.Size() != unsafe.Sizeof(zero) {
+		goType := typ.String()
+		switch dtype {
+		case gotch.Half:
+			goType = "half.Float16"
+		case gotch.BFloat16:
+			goType = "half.BFloat16"
+		}
+		err := fmt.Errorf("mismatched element type: tensor dtype %v corresponds to Go type %v, got %T", dtype, goType, zero)
+		return err
+	}
+
+	return nil
+}
+
+// FromSlice creates a tensor from a slice of data with optional shape.
+// If shape is not specified, a 1D tensor is created.
+//
+// Example:
+//
+//	x, err := ts.FromSlice([]float32{1, 2, 3, 4, 5, 6}, 2, 3)
+func FromSlice[T Number](data []T, shape ...int64) (*Tensor, error) {
+	if len(shape) == 0 {
+		shape = []int64{int64(len(data))}
+	}
+	if n := FlattenDim(shape); n != len(data) {
+		err := fmt.Errorf("FromSlice() failed: number of data elements (%v) and shape %v mismatched", len(data), shape)
+		return nil, err
+	}
+
+	dtype := DTypeOf[T]()
+	var dataPtr unsafe.Pointer
+	if len(data) > 0 {
+		dataPtr = unsafe.Pointer(&data[0])
+	}
+
+	ctensor := lib.AtTensorOfData(dataPtr, shape, uint(len(shape)), dtype.Size(), int(dtype.CKind()))
+	if err := TorchErr(); err != nil {
+		err = fmt.Errorf("FromSlice() failed: %w", err)
+		return nil, err
+	}
+
+	return newTensor(ctensor, "FromSlice"), nil
+}
+
+// MustFromSlice creates a tensor from a slice of data with optional shape. It panics if error.
+func MustFromSlice[T Number](data []T, shape ...int64) *Tensor {
+	x, err := FromSlice(data, shape...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return x
+}
+
+// Values returns all values of a tensor in a flattened slice of Go type T.
+//
+// Tensor dtype must correspond to T (see `Number`). It does not convert
+// tensor dtype, use `Tensor.Totype()` first if needed.
+func Values[T Number](x *Tensor) ([]T, error) {
+	if err := checkDType[T](x.DType()); err != nil {
+		err = fmt.Errorf("Values() failed: %w", err)
+		return nil, err
+	}
+
+	numel := x.Numel()
+	vals := make([]T, numel)
+	if numel == 0 {
+		return vals, nil
+	}
+
+	lib.AtCopyData(x.ctensor, unsafe.Pointer(&vals[0]), numel, x.DType().Size())
+	if err := TorchErr(); err != nil {
+		err = fmt.Errorf("Values() failed: %w", err)
+		return nil, err
+	}
+
+	return vals, nil
+}
+
+// MustValues returns all values of a tensor in a flattened slice of Go type T. It panics if error.
+func MustValues[T Number](x *Tensor) []T {
+	vals, err := Values[T](x)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return vals
+}
+
+// Item returns value of a tensor holding a single element.
+func Item[T Number](x *Tensor) (T, error) {
+	var zero T
+	if numel := x.Numel(); numel != 1 {
+		err := fmt.Errorf("Item() failed: expected a tensor with a single element, got %v elements", numel)
+		return zero, err
+	}
+
+	vals, err := Values[T](x)
+	if err != nil {
+		err = fmt.Errorf("Item() failed: %w", err)
+		return zero, err
+	}
+
+	return vals[0], nil
+}
+
+// MustItem returns value of a tensor holding a single element. It panics if error.
+func MustItem[T Number](x *Tensor) T {
+	v, err := Item[T](x)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return v
+}
+
+// TypedIterable is an iterator over elements of a 1D tensor of Go type T.
+type TypedIterable[T Number] struct {
+	Index  int64
+	Len    int64
+	values []T
+}
+
+// Next returns next element and whether it's available.
+func (it *TypedIterable[T]) Next() (item T, ok bool) {
+	if it.Index >= it.Len {
+		return item, false
+	}
+
+	item = it.values[it.Index]
+	it.Index += 1
+
+	return item, true
+}
+
+// Iter creates a typed iterator over elements of a 1D tensor. Tensor dtype
+// must correspond to T (see `Number`).
+//
+// Example:
+//
+//	it, err := ts.Iter[float32](x)
+//	for v, ok := it.Next(); ok; v, ok = it.Next() {
+//		fmt.Println(v)
+//	}
+func Iter[T Number](x *Tensor) (*TypedIterable[T], error) {
+	num, err := x.Size1() // size for 1D tensor
+	if err != nil {
+		err = fmt.Errorf("Iter() failed: %w", err)
+		return nil, err
+	}
+
+	values, err := Values[T](x)
+	if err != nil {
+		err = fmt.Errorf("Iter() failed: %w", err)
+		return nil, err
+	}
+
+	return &TypedIterable[T]{
+		Index:  0,
+		Len:    num,
+		values: values,
+	}, nil
+}
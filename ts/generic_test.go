@@ -0,0 +1,114 @@
+package ts_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/half"
+	"github.com/sugarme/gotch/ts"
+)
+
+func testFromSliceValues[T ts.Number](t *testing.T, data []T, dtype gotch.DType) {
+	x, err := ts.FromSlice(data, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.MustDrop()
+
+	if got := x.DType(); got != dtype {
+		t.Errorf("%T: want dtype %v, got %v", data, dtype, got)
+	}
+	if got := x.MustSize(); !reflect.DeepEqual(got, []int64{2, 2}) {
+		t.Errorf("%T: want shape [2 2], got %v", data, got)
+	}
+
+	got, err := ts.Values[T](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Errorf("%T: want values %v, got %v", data, data, got)
+	}
+}
+
+func TestFromSlice_Values(t *testing.T) {
+	testFromSliceValues(t, []uint8{0, 1, 2, 255}, gotch.Uint8)
+	testFromSliceValues(t, []int8{-128, -1, 0, 127}, gotch.Int8)
+	testFromSliceValues(t, []int16{-3, -1, 0, 300}, gotch.Int16)
+	testFromSliceValues(t, []int32{-3, -1, 0, 70000}, gotch.Int)
+	testFromSliceValues(t, []int64{-3, -1, 0, 1 << 40}, gotch.Int64)
+	testFromSliceValues(t, []float32{-1.5, 0, 0.25, 3}, gotch.Float)
+	testFromSliceValues(t, []float64{-1.5, 0, 0.25, 1e100}, gotch.Double)
+	testFromSliceValues(t, []bool{true, false, false, true}, gotch.Bool)
+	testFromSliceValues(t, []complex64{1 + 2i, -1, 0, 3i}, gotch.ComplexFloat)
+	testFromSliceValues(t, []complex128{1 + 2i, -1, 0, 3i}, gotch.ComplexDouble)
+	testFromSliceValues(t, []half.Float16{half.Fromfloat32(1.5), half.Fromfloat32(-2), 0, half.Fromfloat32(0.25)}, gotch.Half)
+	testFromSliceValues(t, []half.BFloat16{half.BFloat16(half.Float32ToBFloat16(1.5)), half.BFloat16(half.Float32ToBFloat16(-2)), 0, half.BFloat16(half.Float32ToBFloat16(0.25))}, gotch.BFloat16)
+}
+
+func TestFromSlice_Half(t *testing.T) {
+	x := ts.MustFromSlice([]half.Float16{half.Fromfloat32(1.5), half.Fromfloat32(-2)})
+	y := x.MustTotype(gotch.Float, true)
+	got := ts.MustValues[float32](y)
+	want := []float32{1.5, -2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestFromSlice_ShapeMismatch(t *testing.T) {
+	_, err := ts.FromSlice([]float32{1, 2, 3}, 2, 2)
+	if err == nil {
+		t.Errorf("Expected error for mismatched shape")
+	}
+}
+
+func TestValues_DTypeMismatch(t *testing.T) {
+	x := ts.MustFromSlice([]int64{1, 2, 3})
+	if _, err := ts.Values[float32](x); err == nil {
+		t.Errorf("Expected error reading Int64 tensor as float32")
+	}
+
+	y := ts.MustFromSlice([]half.Float16{0, 0})
+	if _, err := ts.Values[half.BFloat16](y); err == nil {
+		t.Errorf("Expected error reading Half tensor as half.BFloat16")
+	}
+}
+
+func TestItem(t *testing.T) {
+	x := ts.MustFromSlice([]float64{1, 2, 3}).MustSum(gotch.Double, true)
+	got, err := ts.Item[float64](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 6 {
+		t.Errorf("want 6, got %v", got)
+	}
+
+	y := ts.MustFromSlice([]float64{1, 2, 3})
+	if _, err := ts.Item[float64](y); err == nil {
+		t.Errorf("Expected error for tensor with more than one element")
+	}
+}
+
+func TestIter_Typed(t *testing.T) {
+	x := ts.MustFromSlice([]int32{3, 1, 2})
+	it, err := ts.Iter[int32](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int32
+	for v, ok := it.Next(); ok; v, ok = it.Next() {
+		got = append(got, v)
+	}
+	want := []int32{3, 1, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if _, err := ts.Iter[int64](x); err == nil {
+		t.Errorf("Expected error iterating Int tensor as int64")
+	}
+}
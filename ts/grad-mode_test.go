@@ -0,0 +1,130 @@
+package ts_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestNoGrad_Nested(t *testing.T) {
+	if !ts.IsGradEnabled() {
+		t.Fatalf("Expected grad enabled by default")
+	}
+
+	ts.NoGrad(func() {
+		if ts.IsGradEnabled() {
+			t.Errorf("Expected grad disabled inside NoGrad()")
+		}
+		ts.EnableGrad(func() {
+			if !ts.IsGradEnabled() {
+				t.Errorf("Expected grad enabled inside EnableGrad()")
+			}
+		})
+		if ts.IsGradEnabled() {
+			t.Errorf("Expected grad disabled after nested EnableGrad()")
+		}
+	})
+
+	ts.NoGrad(func() {
+		guard := ts.NewNoGradGuard()
+		guard.Drop()
+		if ts.IsGradEnabled() {
+			t.Errorf("Expected NoGradGuard restoring disabled grad mode")
+		}
+	})
+
+	if !ts.IsGradEnabled() {
+		t.Errorf("Expected grad enabled after NoGrad()")
+	}
+}
+
+func TestInferenceMode(t *testing.T) {
+	x := ts.MustOnes([]int64{2, 2}, gotch.Float, gotch.CPU).MustSetRequiresGrad(true, true)
+
+	var y *ts.Tensor
+	err := ts.InferenceMode(func() {
+		if !ts.IsInferenceModeEnabled() {
+			t.Errorf("Expected inference mode enabled inside InferenceMode()")
+		}
+		y = x.MustMulScalar(ts.FloatScalar(2.0), false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ts.IsInferenceModeEnabled() {
+		t.Errorf("Expected inference mode disabled after InferenceMode()")
+	}
+	if !y.MustIsInference(false) {
+		t.Errorf("Expected inference tensor created inside InferenceMode()")
+	}
+	if y.MustRequiresGrad() {
+		t.Errorf("Expected tensor created inside InferenceMode() not requiring grad")
+	}
+}
+
+// TestGradMode_Concurrent runs training and evaluation goroutines concurrently.
+// Grad mode of evaluation goroutines must not leak to training ones and vice versa.
+func TestGradMode_Concurrent(t *testing.T) {
+	const (
+		workers = 32
+		iters   = 50
+	)
+
+	var wg sync.WaitGroup
+	errs := make(chan string, workers*iters)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iters; i++ {
+				x := ts.MustOnes([]int64{4}, gotch.Float, gotch.CPU).MustSetRequiresGrad(true, true)
+				switch w % 3 {
+				case 0: // training
+					runtime.Gosched()
+					y := x.MustMulScalar(ts.FloatScalar(2.0), false).MustSum(gotch.Float, true)
+					if !y.MustRequiresGrad() {
+						errs <- "training: output doesn't require grad"
+						y.MustDrop()
+						x.MustDrop()
+						continue
+					}
+					y.MustBackward()
+					grad := x.MustGrad(false)
+					if v := grad.Float64Values(true); v[0] != 2 {
+						errs <- "training: wrong grad"
+					}
+					y.MustDrop()
+				case 1: // evaluation
+					ts.NoGrad(func() {
+						runtime.Gosched()
+						y := x.MustMulScalar(ts.FloatScalar(2.0), false)
+						if y.MustRequiresGrad() {
+							errs <- "NoGrad: output requires grad"
+						}
+						y.MustDrop()
+					})
+				case 2: // inference
+					ts.MustInferenceMode(func() {
+						runtime.Gosched()
+						y := x.MustMulScalar(ts.FloatScalar(2.0), false)
+						if y.MustRequiresGrad() || !y.MustIsInference(false) {
+							errs <- "InferenceMode: output requires grad or isn't an inference tensor"
+						}
+						y.MustDrop()
+					})
+				}
+				x.MustDrop()
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for msg := range errs {
+		t.Error(msg)
+	}
+}
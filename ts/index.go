@@ -49,14 +49,30 @@ package ts
 // 	t.Size()									// [2,3,1]
 //	```
 //
-// Unlike NumPy, the `i` operation does not support advanced indexing.
-// The result can be different from NumPy with same set of arguments.
-// For example, `tensor.i(..1, []int{0,3}, []int{2,1,3})` does narrowing
-// on first dimension, and index selection on second and third dimensions.
-// The analogous NumPy indexing `array[:1, [0, 3], [2, 1, 3]]` throws
-// shape mismatch error due to advanced indexing rule. Another distinction
-// is that `i` guarantees the input and result tensor shares the same
-// underlying storage, while NumPy may copy the tensor in certain scenarios.
+// `Ellipsis` expands to as many full slices as needed to index all dimensions,
+// and `Slice` supports Python-style `start:end:step` slices including negative steps.
+//
+//	```
+//	x := ts.MustArange(ts.IntScalar(24), gotch.Int64, gotch.CPU).MustView([]int64{2, 3, 4}, true)
+//	y := x.Idx([]ts.TensorIndexer{ts.NewEllipsis(), ts.NewSliceAll(-1)}) // x[..., ::-1]
+//	y.Size()									// [2,3,4]
+//	```
+//
+// Advanced indexing follows PyTorch `__getitem__` semantics. Integer tensor indices
+// (`IndexSelect`) of any shape are broadcast together, boolean tensors are used as
+// masks. If advanced indices are adjacent, the result dimensions replace the indexed
+// ones, otherwise they come first.
+//
+//	```
+//	x := ts.MustArange(ts.IntScalar(12), gotch.Int64, gotch.CPU).MustView([]int64{3, 4}, true)
+//	y := x.Idx([]ts.TensorIndexer{ts.NewSliceIndex([]int64{0, 2}), ts.NewSliceIndex([]int64{1, 3})}) // x[[0, 2], [1, 3]]
+//	y.Vals()									// [1, 11]
+//	mask := x.MustGt(ts.IntScalar(8), false)
+//	y = x.Idx(ts.NewIndexSelect(mask))			// x[x > 8]
+//	y.Vals()									// [9, 10, 11]
+//	```
+//
+// `Tensor.IdxPut()` assigns values to indexed elements like PyTorch `__setitem__`.
 
 // NOTE: select, narrow and indexing operations (except when using a LongTensor index) return views onto the same memory.
 // https://discuss.pytorch.org/t/does-select-and-narrow-return-a-view-or-copy/289
@@ -67,6 +83,7 @@ import (
 	"reflect"
 
 	"github.com/sugarme/gotch"
+	lib "github.com/sugarme/gotch/libtch"
 )
 
 type NewAxis struct{}
@@ -82,6 +99,22 @@ type Narrow struct {
 type IndexSelect struct{ Index *Tensor }
 type InsertNewAxis struct{}
 
+// Ellipsis expands to as many full slices as needed to index all dimensions
+// of a tensor, like `...` in NumPy and PyTorch. At most one Ellipsis can be used.
+type Ellipsis struct{}
+
+// Slice selects elements from `Start` to `End` (excluded) with `Step` along a
+// dimension, like `start:end:step` in NumPy and PyTorch.
+//
+// Nil `Start` or `End` is unbounded. Negative values count from the end of the
+// dimension. Negative `Step` takes elements in reverse order (NOTE. the result
+// is a copy, not a view). Zero `Step` is 1.
+type Slice struct {
+	Start *int64
+	End   *int64
+	Step  int64
+}
+
 // NewSelect creates an tensor indexer with given index.
 // `index` must be in range of tensor dimension. E.g. tensor shape [2,8]
 // will have size = 2, hence `index` should be in range from [0,2)
@@ -93,6 +126,8 @@ func NewNarrow(start, end int64) *Narrow {
 	return &Narrow{Start: start, End: end}
 }
 
+// NewIndexSelect creates an tensor indexer with given index tensor.
+// Index tensor can be an integer tensor of any shape or a boolean mask.
 func NewIndexSelect(ts *Tensor) *IndexSelect {
 	return &IndexSelect{Index: ts}
 }
@@ -107,6 +142,80 @@ func NewSliceIndex(sl []int64) *IndexSelect {
 	return &IndexSelect{Index: ts}
 }
 
+func NewEllipsis() *Ellipsis {
+	return &Ellipsis{}
+}
+
+// NewSlice creates a `start:end:step` slice indexer.
+func NewSlice(start, end, step int64) *Slice {
+	return &Slice{Start: &start, End: &end, Step: step}
+}
+
+// NewSliceFrom creates a `start::step` slice indexer.
+func NewSliceFrom(start, step int64) *Slice {
+	return &Slice{Start: &start, Step: step}
+}
+
+// NewSliceTo creates a `:end:step` slice indexer.
+func NewSliceTo(end, step int64) *Slice {
+	return &Slice{End: &end, Step: step}
+}
+
+// NewSliceAll creates a `::step` slice indexer.
+func NewSliceAll(step int64) *Slice {
+	return &Slice{Step: step}
+}
+
+// indices returns start, number of elements and step of the slice applied
+// on a dimension of given size. It follows Python `slice.indices()`.
+func (s *Slice) indices(size int64) (start, count, step int64) {
+	step = s.Step
+	if step == 0 {
+		step = 1
+	}
+
+	clamp := func(v, lo, hi int64) int64 {
+		if v < 0 {
+			v += size
+		}
+		if v < lo {
+			return lo
+		}
+		if v > hi {
+			return hi
+		}
+		return v
+	}
+
+	var end int64
+	if step > 0 {
+		start, end = 0, size
+		if s.Start != nil {
+			start = clamp(*s.Start, 0, size)
+		}
+		if s.End != nil {
+			end = clamp(*s.End, 0, size)
+		}
+		if end > start {
+			count = (end-start-1)/step + 1
+		}
+		return start, count, step
+	}
+
+	start, end = size-1, -1
+	if s.Start != nil {
+		start = clamp(*s.Start, -1, size-1)
+	}
+	if s.End != nil {
+		end = clamp(*s.End, -1, size-1)
+	}
+	if start > end {
+		count = (start-end-1)/(-step) + 1
+	}
+
+	return start, count, step
+}
+
 // type SelectFn func(int64)
 // type NarrowFn func(from int64, to int64)
 // type IndexSelectFn func(ts Tensor)
@@ -129,182 +238,306 @@ type IndexOp interface {
 // Idx implements `IndexOp` interface for Tensor
 //
 // NOTE:
-// - `index`: expects type `TensorIndexer` or `[]*TensorIndexer`
+// - `index`: expects type `TensorIndexer` or `[]TensorIndexer`
 func (ts *Tensor) Idx(index interface{}) (retVal *Tensor) {
+	indexes, err := toIndexSpec(index)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// indexTyp := reflect.TypeOf(index)
-	indexVal := reflect.ValueOf(index)
-
-	var indexes []TensorIndexer
-
-	typ := indexVal.Kind().String()
-	switch typ {
-	case "ptr": // T: A
-		indexes = append(indexes, index.(TensorIndexer))
-	case "slice": // T: []TensorIndexer
-		switch len(index.([]TensorIndexer)) {
-		case 1: // T: [A]
-			idxA := index.([]TensorIndexer)[0]
-			indexes = append(indexes, idxA)
-		case 2: // T: [A, B]
-			idxA := index.([]TensorIndexer)[0]
-			idxB := index.([]TensorIndexer)[1]
-			indexes = append(indexes, idxA, idxB)
-		case 3: // T: [A, B, C]
-			idxA := index.([]TensorIndexer)[0]
-			idxB := index.([]TensorIndexer)[1]
-			idxC := index.([]TensorIndexer)[2]
-			indexes = append(indexes, idxA, idxB, idxC)
-		case 4: // T: [A, B, C, D]
-			idxA := index.([]TensorIndexer)[0]
-			idxB := index.([]TensorIndexer)[1]
-			idxC := index.([]TensorIndexer)[2]
-			idxD := index.([]TensorIndexer)[3]
-			indexes = append(indexes, idxA, idxB, idxC, idxD)
-		case 5: // T: [A, B, C, D, E]
-			idxA := index.([]TensorIndexer)[0]
-			idxB := index.([]TensorIndexer)[1]
-			idxC := index.([]TensorIndexer)[2]
-			idxD := index.([]TensorIndexer)[3]
-			idxE := index.([]TensorIndexer)[4]
-			indexes = append(indexes, idxA, idxB, idxC, idxD, idxE)
-		case 6: // T: [A, B, C, D, E, F]
-			idxA := index.([]TensorIndexer)[0]
-			idxB := index.([]TensorIndexer)[1]
-			idxC := index.([]TensorIndexer)[2]
-			idxD := index.([]TensorIndexer)[3]
-			idxE := index.([]TensorIndexer)[4]
-			idxF := index.([]TensorIndexer)[5]
-			indexes = append(indexes, idxA, idxB, idxC, idxD, idxE, idxF)
-		case 7: // T: [A, B, C, D, E, F, G]
-			idxA := index.([]TensorIndexer)[0]
-			idxB := index.([]TensorIndexer)[1]
-			idxC := index.([]TensorIndexer)[2]
-			idxD := index.([]TensorIndexer)[3]
-			idxE := index.([]TensorIndexer)[4]
-			idxF := index.([]TensorIndexer)[5]
-			idxG := index.([]TensorIndexer)[6]
-			indexes = append(indexes, idxA, idxB, idxC, idxD, idxE, idxF, idxG)
-		default:
-			log.Fatalf("Invalid input 'index' slice length (%v) - max is 7\n", len(index.([]TensorIndexer)))
+	return ts.mustIndexer(indexes)
+}
+
+// IdxPut assigns values to the elements of the tensor selected by index, like
+// `tensor[index] = value` in PyTorch. Value is broadcast to the shape of the
+// indexed elements and converted to the tensor dtype and device.
+//
+// NOTE:
+// - `index`: expects type `TensorIndexer` or `[]TensorIndexer`
+// - negative step slices can't be combined with tensor indices.
+func (ts *Tensor) IdxPut(index interface{}, value *Tensor) error {
+	indexes, err := toIndexSpec(index)
+	if err != nil {
+		err = fmt.Errorf("IdxPut() failed: %w", err)
+		return err
+	}
+
+	view, indices, flipDims, err := ts.basicIndexer(indexes)
+	if err != nil {
+		err = fmt.Errorf("IdxPut() failed: %w", err)
+		return err
+	}
+	defer func() {
+		view.MustDrop()
+		dropIndices(indices)
+	}()
+
+	device, err := view.Device()
+	if err != nil {
+		err = fmt.Errorf("IdxPut() failed: %w", err)
+		return err
+	}
+	values, err := value.To(device, false)
+	if err != nil {
+		err = fmt.Errorf("IdxPut() failed: %w", err)
+		return err
+	}
+	values, err = values.Totype(view.DType(), true)
+	if err != nil {
+		err = fmt.Errorf("IdxPut() failed: %w", err)
+		return err
+	}
+
+	if len(indices) > 0 {
+		defer values.MustDrop()
+		if len(flipDims) > 0 {
+			err = fmt.Errorf("IdxPut() failed: negative step slice can't be combined with tensor indices")
+			return err
+		}
+		if err = view.IndexPut_(indices, values, false); err != nil {
+			err = fmt.Errorf("IdxPut() failed: %w", err)
+			return err
+		}
+		return nil
+	}
+
+	// The view is in the original order, hence values of negative step
+	// slices are reversed instead. Values can have fewer dimensions than
+	// the view as they are broadcast from the right.
+	var dims []int64
+	offset := int64(values.Dim()) - int64(view.Dim())
+	for _, d := range flipDims {
+		if d+offset >= 0 {
+			dims = append(dims, d+offset)
+		}
+	}
+	if len(dims) > 0 {
+		values, err = values.Flip(dims, true)
+		if err != nil {
+			err = fmt.Errorf("IdxPut() failed: %w", err)
+			return err
 		}
+	}
+	defer values.MustDrop()
+
+	lib.AtCopy_(view.ctensor, values.ctensor)
+	if err = TorchErr(); err != nil {
+		err = fmt.Errorf("IdxPut() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// MustIdxPut assigns values to the elements of the tensor selected by index. It panics if error.
+func (ts *Tensor) MustIdxPut(index interface{}, value *Tensor) {
+	if err := ts.IdxPut(index, value); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func toIndexSpec(index interface{}) ([]TensorIndexer, error) {
+	switch idx := index.(type) {
+	case []TensorIndexer:
+		return idx, nil
 	default:
-		log.Fatalf("Invalid 'index' type (%v) - Expected type 'TensorIndexer' or '[]TensorIndexer'\n.", indexVal.Kind().String())
+		if reflect.ValueOf(index).Kind() != reflect.Ptr {
+			err := fmt.Errorf("Invalid 'index' type (%v) - Expected type 'TensorIndexer' or '[]TensorIndexer'\n.", reflect.ValueOf(index).Kind().String())
+			return nil, err
+		}
+		return []TensorIndexer{idx}, nil
 	}
+}
 
-	return ts.mustIndexer(indexes)
+func dropIndices(indices []*Tensor) {
+	for _, x := range indices {
+		if x != nil {
+			x.MustDrop()
+		}
+	}
 }
 
 // Tensor Methods:
 // ===============
 func (ts *Tensor) indexer(indexSpec []TensorIndexer) (retVal *Tensor, err error) {
+	currTensor, indices, flipDims, err := ts.basicIndexer(indexSpec)
+	if err != nil {
+		return retVal, err
+	}
+	defer dropIndices(indices)
 
-	// Make sure number of non-newaxis is not exceed number of dimensions
-	var numNewAxis int = 0
-	for _, ti := range indexSpec {
-		if reflect.TypeOf(ti).String() == "*ts.InsertNewAxis" {
-			numNewAxis += 1
+	if len(flipDims) > 0 {
+		currTensor, err = currTensor.Flip(flipDims, true)
+		if err != nil {
+			return retVal, err
 		}
 	}
 
-	tsShape, err := ts.Size()
-	if err != nil {
-		return retVal, err
-	}
-	tsLen := len(tsShape)
-	if len(indexSpec) > tsLen+numNewAxis {
-		err = fmt.Errorf("Too many indices for tensor of dimension %v\n", tsLen)
-		return retVal, err
+	if len(indices) == 0 {
+		return currTensor, nil
 	}
 
-	// Make sure tensor conforms the format
-	for _, spec := range indexSpec {
-		// If `spec` is `*IndexSelect` type and
-		if reflect.TypeOf(spec).String() == "*ts.IndexSelect" {
-			if reflect.ValueOf(spec).Kind() == reflect.Ptr {
-				inputTensor := reflect.Indirect(reflect.ValueOf(spec)).FieldByName("Index").Interface().(*Tensor)
+	// A single 1-D integer index tensor is the last element of `indices`.
+	last := indices[len(indices)-1]
+	single := true
+	for _, x := range indices[:len(indices)-1] {
+		single = single && x == nil
+	}
+	if single && last.DType() != gotch.Bool && last.Dim() == 1 {
+		return currTensor.IndexSelect(int64(len(indices)-1), last, true)
+	}
 
-				// 1. Either its input tensor has dimension > 1, throw error.
-				inputTensorShape, err := inputTensor.Size()
-				if err != nil {
-					err = fmt.Errorf("Indexer Func Error: %v\n", err)
-					return retVal, err
-				}
-				if len(inputTensorShape) != 1 {
-					err = fmt.Errorf("Multi-dimenstional tensor is not supported for indexing.")
-					return retVal, err
-				}
+	return currTensor.Index(indices, true)
+}
 
-				// 2. Or its input tensor has an unsupported dtype
-				if inputTensor.DType() != gotch.Int64 &&
-					inputTensor.DType() != gotch.Int16 &&
-					inputTensor.DType() != gotch.Int8 &&
-					inputTensor.DType() != gotch.Int {
+// basicIndexer applies select, narrow, slice, new axis and ellipsis indexing
+// from left to right. It returns a view of the tensor, tensor indices to be
+// applied on the view with `index` or `index_put_` (nil is `None`) and
+// dimensions of the view to be flipped due to negative step slices.
+func (ts *Tensor) basicIndexer(indexSpec []TensorIndexer) (view *Tensor, indices []*Tensor, flipDims []int64, err error) {
+	// Make sure number of indexed dimensions does not exceed number of dimensions.
+	var (
+		numIndexed  int64 = 0
+		numEllipsis int   = 0
+	)
+	for _, spec := range indexSpec {
+		switch spec := spec.(type) {
+		case *InsertNewAxis:
+		case *Ellipsis:
+			numEllipsis += 1
+		case *Select, *Narrow, *Slice:
+			numIndexed += 1
+		case *IndexSelect:
+			// 1. Its input tensor has an unsupported dtype
+			dtype := spec.Index.DType()
+			if dtype != gotch.Int64 &&
+				dtype != gotch.Int16 &&
+				dtype != gotch.Int8 &&
+				dtype != gotch.Int &&
+				dtype != gotch.Bool {
+
+				err = fmt.Errorf("The dtype of tensor used (%v) as indices must be one of: 'int64', 'int16', 'int8', 'int', 'bool'. \n", dtype)
+				return nil, nil, nil, err
+			}
 
-					err = fmt.Errorf("The dtype of tensor used (%v) as indices must be one of: 'int64', 'int16', 'int8', 'int'. \n", inputTensor.DType())
-					return retVal, err
-				}
+			// 2. A boolean mask indexes as many dimensions as it has.
+			if dtype == gotch.Bool {
+				numIndexed += int64(spec.Index.Dim())
+			} else {
+				numIndexed += 1
 			}
+		default:
+			err = fmt.Errorf("Invalid indexer type (%T)\n", spec)
+			return nil, nil, nil, err
 		}
 	}
 
+	if numEllipsis > 1 {
+		err = fmt.Errorf("An index can only have a single ellipsis\n")
+		return nil, nil, nil, err
+	}
+
+	tsLen := int64(ts.Dim())
+	if numIndexed > tsLen {
+		err = fmt.Errorf("Too many indices for tensor of dimension %v\n", tsLen)
+		return nil, nil, nil, err
+	}
+
+	device, err := ts.Device()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	// Now, apply indexing from left to right.
 	var (
 		currTensor *Tensor = ts.MustShallowClone()
 		currIdx    int64   = 0
 		nextTensor *Tensor
 		nextIdx    int64
+		indexedDim int64 = 0 // number of dimensions covered by `indices`
 	)
+	defer func() {
+		if err != nil {
+			dropIndices(indices)
+		}
+	}()
 
-	// `spec` is a function type implements `TensorIndexer`
 	for _, spec := range indexSpec {
-		switch reflect.TypeOf(spec).String() {
-		case "*ts.InsertNewAxis":
+		switch spec := spec.(type) {
+		case *InsertNewAxis:
 			nextTensor, err = currTensor.Unsqueeze(currIdx, true)
 			if err != nil {
-				return retVal, err
+				return nil, nil, nil, err
 			}
 			nextIdx = currIdx + 1
-		case "*ts.Select": // 1 field: `Index`
-			index := reflect.Indirect(reflect.ValueOf(spec)).FieldByName("Index").Interface().(int64)
-			nextTensor, err = currTensor.Select(currIdx, index, true) // TODO: double-check is `*index` or `index`
+		case *Ellipsis:
+			nextTensor = currTensor
+			nextIdx = currIdx + tsLen - numIndexed
+		case *Select:
+			nextTensor, err = currTensor.Select(currIdx, spec.Index, true)
 			if err != nil {
-				return retVal, err
+				return nil, nil, nil, err
 			}
 			nextIdx = currIdx // not advanced because select() squeezes dimension
-		case "*ts.Narrow": // 2 fields: `(Start, End int64)`
-			// TODO: implement for `Unbounded`, `Included`, `Excluded` ranges
-			// NOTE: for now, just implement (Included(start), Excluded(end))` case
-			start := reflect.Indirect(reflect.ValueOf(spec)).FieldByName("Start").Interface().(int64)
-			end := reflect.Indirect(reflect.ValueOf(spec)).FieldByName("End").Interface().(int64)
-			nextTensor, err = currTensor.Narrow(currIdx, start, end-start, true)
+		case *Narrow:
+			nextTensor, err = currTensor.Narrow(currIdx, spec.Start, spec.End-spec.Start, true)
 			if err != nil {
-				return retVal, err
+				return nil, nil, nil, err
 			}
 			nextIdx = currIdx + 1
-		case "*ts.IndexSelect": // 1 field `(Index *Tensor)`
-			indexTensor := reflect.Indirect(reflect.ValueOf(spec)).FieldByName("Index").Interface().(*Tensor)
-			device, err := currTensor.Device()
+		case *Slice:
+			var shape []int64
+			shape, err = currTensor.Size()
 			if err != nil {
-				return retVal, err
+				return nil, nil, nil, err
+			}
+			start, count, step := spec.indices(shape[currIdx])
+			switch {
+			case count == 0:
+				nextTensor, err = currTensor.Narrow(currIdx, 0, 0, true)
+			case step > 0:
+				end := start + (count-1)*step + 1
+				nextTensor, err = currTensor.Slice(currIdx, []int64{start}, []int64{end}, step, true)
+			default:
+				// Take the same elements in original order then flip them.
+				first := start + (count-1)*step
+				nextTensor, err = currTensor.Slice(currIdx, []int64{first}, []int64{start + 1}, -step, true)
+				flipDims = append(flipDims, currIdx)
 			}
-			indexTensor, err = indexTensor.To(device, true)
 			if err != nil {
-				return retVal, err
+				return nil, nil, nil, err
 			}
-			nextTensor, err = currTensor.IndexSelect(currIdx, indexTensor, true)
+			nextIdx = currIdx + 1
+		case *IndexSelect:
+			var index *Tensor
+			index, err = spec.Index.To(device, false)
 			if err != nil {
-				return retVal, err
+				return nil, nil, nil, err
+			}
+			if dtype := index.DType(); dtype != gotch.Bool && dtype != gotch.Int64 {
+				index, err = index.Totype(gotch.Int64, true)
+				if err != nil {
+					return nil, nil, nil, err
+				}
 			}
+			for ; indexedDim < currIdx; indexedDim++ {
+				indices = append(indices, nil)
+			}
+			indices = append(indices, index)
+
+			nextTensor = currTensor
 			nextIdx = currIdx + 1
+			if index.DType() == gotch.Bool {
+				nextIdx = currIdx + int64(index.Dim())
+			}
+			indexedDim = nextIdx
 		} // end of switch
 
 		currTensor = nextTensor
 		currIdx = nextIdx
 	}
 
-	retVal = currTensor
-	return retVal, nil
+	return currTensor, indices, flipDims, nil
 }
 
 func (ts *Tensor) mustIndexer(indexSpec []TensorIndexer) (retVal *Tensor) {
@@ -297,3 +297,191 @@ func TestIndex3D(t *testing.T) {
 		t.Errorf("Got tensor values: %v\n", got3)
 	}
 }
+
+func TestAdvancedIndex(t *testing.T) {
+	// [[[ 0  1  2  3]
+	//   [ 4  5  6  7]
+	//   [ 8  9 10 11]]
+	//  [[12 13 14 15]
+	//   [16 17 18 19]
+	//   [20 21 22 23]]]
+	tensor := ts.MustArange(ts.IntScalar(2*3*4), gotch.Int64, gotch.CPU).MustView([]int64{2, 3, 4}, true)
+	mask2D := ts.MustOfSlice([]bool{false, true, false, false, false, true}).MustView([]int64{2, 3}, true)
+	mask1D := ts.MustOfSlice([]bool{true, false, false, true})
+
+	tests := []struct {
+		name      string
+		index     []ts.TensorIndexer
+		want      []int64
+		wantShape []int64
+	}{
+		{
+			name:      "x[..., 1]",
+			index:     []ts.TensorIndexer{ts.NewEllipsis(), ts.NewSelect(1)},
+			want:      []int64{1, 5, 9, 13, 17, 21},
+			wantShape: []int64{2, 3},
+		},
+		{
+			name:      "x[1, ...]",
+			index:     []ts.TensorIndexer{ts.NewSelect(1), ts.NewEllipsis()},
+			want:      []int64{12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+			wantShape: []int64{3, 4},
+		},
+		{
+			name:      "x[None, ..., 0]",
+			index:     []ts.TensorIndexer{ts.NewInsertNewAxis(), ts.NewEllipsis(), ts.NewSelect(0)},
+			want:      []int64{0, 4, 8, 12, 16, 20},
+			wantShape: []int64{1, 2, 3},
+		},
+		{
+			name:      "x[:, ::-1]",
+			index:     []ts.TensorIndexer{ts.NewSliceAll(1), ts.NewSliceAll(-1)},
+			want:      []int64{8, 9, 10, 11, 4, 5, 6, 7, 0, 1, 2, 3, 20, 21, 22, 23, 16, 17, 18, 19, 12, 13, 14, 15},
+			wantShape: []int64{2, 3, 4},
+		},
+		{
+			name:      "x[..., ::2]",
+			index:     []ts.TensorIndexer{ts.NewEllipsis(), ts.NewSliceAll(2)},
+			want:      []int64{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22},
+			wantShape: []int64{2, 3, 2},
+		},
+		{
+			name:      "x[1, 2:0:-1, -1::-2]",
+			index:     []ts.TensorIndexer{ts.NewSelect(1), ts.NewSlice(2, 0, -1), ts.NewSliceFrom(-1, -2)},
+			want:      []int64{23, 21, 19, 17},
+			wantShape: []int64{2, 2},
+		},
+		{
+			name:      "x[:, -2:, :-2]",
+			index:     []ts.TensorIndexer{ts.NewSliceAll(1), ts.NewSliceFrom(-2, 1), ts.NewSliceTo(-2, 1)},
+			want:      []int64{4, 5, 8, 9, 16, 17, 20, 21},
+			wantShape: []int64{2, 2, 2},
+		},
+		{
+			name:      "x[:, :, [2, 0]]",
+			index:     []ts.TensorIndexer{ts.NewSliceAll(1), ts.NewSliceAll(1), ts.NewSliceIndex([]int64{2, 0})},
+			want:      []int64{2, 0, 6, 4, 10, 8, 14, 12, 18, 16, 22, 20},
+			wantShape: []int64{2, 3, 2},
+		},
+		{
+			name:      "x[[0, 1], [1, 2]]",
+			index:     []ts.TensorIndexer{ts.NewSliceIndex([]int64{0, 1}), ts.NewSliceIndex([]int64{1, 2})},
+			want:      []int64{4, 5, 6, 7, 20, 21, 22, 23},
+			wantShape: []int64{2, 4},
+		},
+		{
+			name:      "x[:, [0, 2], [1, 3]]",
+			index:     []ts.TensorIndexer{ts.NewSliceAll(1), ts.NewSliceIndex([]int64{0, 2}), ts.NewSliceIndex([]int64{1, 3})},
+			want:      []int64{1, 11, 13, 23},
+			wantShape: []int64{2, 2},
+		},
+		{
+			name: "x[[[0], [1]], :, [0, 3]]",
+			index: []ts.TensorIndexer{
+				ts.NewIndexSelect(ts.MustOfSlice([]int64{0, 1}).MustView([]int64{2, 1}, true)),
+				ts.NewSliceAll(1),
+				ts.NewSliceIndex([]int64{0, 3}),
+			},
+			want:      []int64{0, 4, 8, 3, 7, 11, 12, 16, 20, 15, 19, 23},
+			wantShape: []int64{2, 2, 3},
+		},
+		{
+			// Unlike NumPy, integer is not an advanced index in PyTorch.
+			name:      "x[0, :, [1, 2]]",
+			index:     []ts.TensorIndexer{ts.NewSelect(0), ts.NewSliceAll(1), ts.NewSliceIndex([]int64{1, 2})},
+			want:      []int64{1, 2, 5, 6, 9, 10},
+			wantShape: []int64{3, 2},
+		},
+		{
+			name:      "x[x > 20]",
+			index:     []ts.TensorIndexer{ts.NewIndexSelect(tensor.MustGt(ts.IntScalar(20), false))},
+			want:      []int64{21, 22, 23},
+			wantShape: []int64{3},
+		},
+		{
+			name:      "x[mask2D]",
+			index:     []ts.TensorIndexer{ts.NewIndexSelect(mask2D)},
+			want:      []int64{4, 5, 6, 7, 20, 21, 22, 23},
+			wantShape: []int64{2, 4},
+		},
+		{
+			name:      "x[1, :, mask1D]",
+			index:     []ts.TensorIndexer{ts.NewSelect(1), ts.NewSliceAll(1), ts.NewIndexSelect(mask1D)},
+			want:      []int64{12, 15, 16, 19, 20, 23},
+			wantShape: []int64{3, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		result := tensor.Idx(tt.index)
+		got := result.Vals()
+		gotShape := result.MustSize()
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("%s: want tensor values %v, got %v\n", tt.name, tt.want, got)
+		}
+		if !reflect.DeepEqual(tt.wantShape, gotShape) {
+			t.Errorf("%s: want tensor shape %v, got %v\n", tt.name, tt.wantShape, gotShape)
+		}
+	}
+}
+
+func TestIdxPut(t *testing.T) {
+	mask := ts.MustArange(ts.IntScalar(3*4), gotch.Int64, gotch.CPU).MustView([]int64{3, 4}, true).MustGt(ts.IntScalar(8), true)
+
+	tests := []struct {
+		name  string
+		index []ts.TensorIndexer
+		value *ts.Tensor
+		want  []int64
+	}{
+		{
+			name:  "x[1] = 5",
+			index: []ts.TensorIndexer{ts.NewSelect(1)},
+			value: ts.MustOfSlice([]int64{5}),
+			want:  []int64{0, 0, 0, 0, 5, 5, 5, 5, 0, 0, 0, 0},
+		},
+		{
+			name:  "x[..., 1:3] = [[1], [2], [3]]",
+			index: []ts.TensorIndexer{ts.NewEllipsis(), ts.NewSlice(1, 3, 1)},
+			value: ts.MustOfSlice([]float64{1, 2, 3}).MustView([]int64{3, 1}, true),
+			want:  []int64{0, 1, 1, 0, 0, 2, 2, 0, 0, 3, 3, 0},
+		},
+		{
+			name:  "x[:, ::-1] = [0, 1, 2, 3]",
+			index: []ts.TensorIndexer{ts.NewSliceAll(1), ts.NewSliceAll(-1)},
+			value: ts.MustOfSlice([]int64{0, 1, 2, 3}),
+			want:  []int64{3, 2, 1, 0, 3, 2, 1, 0, 3, 2, 1, 0},
+		},
+		{
+			name:  "x[[0, 2], [1, 3]] = [7, 8]",
+			index: []ts.TensorIndexer{ts.NewSliceIndex([]int64{0, 2}), ts.NewSliceIndex([]int64{1, 3})},
+			value: ts.MustOfSlice([]int64{7, 8}),
+			want:  []int64{0, 7, 0, 0, 0, 0, 0, 0, 0, 0, 0, 8},
+		},
+		{
+			name:  "x[mask] = 1",
+			index: []ts.TensorIndexer{ts.NewIndexSelect(mask)},
+			value: ts.MustOfSlice([]int64{1}),
+			want:  []int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		x := ts.MustZeros([]int64{3, 4}, gotch.Int64, gotch.CPU)
+		if err := x.IdxPut(tt.index, tt.value); err != nil {
+			t.Errorf("%s: unexpected error: %v\n", tt.name, err)
+			continue
+		}
+		got := x.Vals()
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("%s: want tensor values %v, got %v\n", tt.name, tt.want, got)
+		}
+	}
+
+	// Negative step slice can't be combined with tensor indices.
+	x := ts.MustZeros([]int64{3, 4}, gotch.Int64, gotch.CPU)
+	idx := []ts.TensorIndexer{ts.NewSliceIndex([]int64{0, 2}), ts.NewSliceAll(-1)}
+	if err := x.IdxPut(idx, ts.MustOfSlice([]int64{1})); err == nil {
+		t.Errorf("Expected error for negative step slice with tensor indices\n")
+	}
+}
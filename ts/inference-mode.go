@@ -0,0 +1,53 @@
+package ts
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+
+	lib "github.com/sugarme/gotch/libtch"
+)
+
+// InferenceMode runs a closure in libtorch inference mode, which is similar to
+// `NoGrad()` but faster: besides disabling gradient tracking, it skips version
+// counting and view tracking of tensors.
+//
+// Tensors created inside are inference tensors (see `Tensor.MustIsInference()`).
+// They can't be modified in-place outside inference mode nor used in ops recorded
+// by autograd, so InferenceMode should only be used for pure inference, e.g.
+// serving a model.
+//
+// It is goroutine-safe like `NoGrad()`: the calling goroutine is locked to its
+// OS thread while fn runs and goroutines spawned by fn don't inherit the mode.
+func InferenceMode(fn func()) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	guard := lib.AtInferenceModeEnter()
+	if err := TorchErr(); err != nil {
+		return fmt.Errorf("InferenceMode() failed: %w", err)
+	}
+	defer func() {
+		lib.AtInferenceModeExit(guard)
+		if err := TorchErr(); err != nil {
+			log.Printf("WARNING: exiting inference mode failed: %v\n", err)
+		}
+	}()
+
+	fn()
+
+	return nil
+}
+
+// MustInferenceMode runs a closure in libtorch inference mode. It panics if error.
+func MustInferenceMode(fn func()) {
+	if err := InferenceMode(fn); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// IsInferenceModeEnabled returns whether inference mode is enabled on the
+// current OS thread.
+func IsInferenceModeEnabled() bool {
+	return lib.AtInferenceModeIsEnabled()
+}
@@ -7,4 +7,6 @@ import (
 func init() {
 	// debug.SetMemoryLimit()
 	// debug.SetGCPercent(100) // ratio freshly allocated data to live data remaining after previous collection.
+
+	initMemLimit()
 }
@@ -0,0 +1,449 @@
+package ts
+
+// Leak tracker: records where tensors are created and reports live tensors
+// grouped by call site.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	maxStackDepth = 32 // frames captured per allocation
+	maxStackKept  = 16 // frames kept after trimming
+	tsPackage     = "github.com/sugarme/gotch/ts."
+)
+
+var (
+	leakTracking int32                           // 1 if leak tracker is enabled
+	allocRecords = make(map[string]*allocRecord) // live tracked tensors by name. Guarded by `lock`.
+)
+
+// allocRecord is information of a tensor recorded when it's created.
+type allocRecord struct {
+	stack []uintptr // trimmed stack, innermost first
+	bytes int64
+	shape []int64
+	dtype string
+}
+
+// SetLeakTracker enables or disables leak tracking mode.
+//
+// In tracking mode, a trimmed Go stack is recorded when a tensor is created so
+// that tensors still alive can be reported with `LiveTensorReport()` and
+// `WriteTensorProfile()`. Only tensors created while tracking is enabled are
+// reported. Disabling clears all records.
+//
+// NOTE. tracking mode slows down tensor creation and should only be used for debugging.
+func SetLeakTracker(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&leakTracking, 1)
+		return
+	}
+
+	atomic.StoreInt32(&leakTracking, 0)
+	lock.Lock()
+	allocRecords = make(map[string]*allocRecord)
+	lock.Unlock()
+}
+
+// LeakTrackerEnabled returns whether leak tracking mode is enabled.
+func LeakTrackerEnabled() bool {
+	return atomic.LoadInt32(&leakTracking) == 1
+}
+
+// recordAlloc records call stack, shape and dtype of a new tensor.
+func recordAlloc(x *Tensor) {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(3, pcs[:]) // skip runtime.Callers, recordAlloc and newTensor
+	stack := trimStack(pcs[:n])
+
+	rec := &allocRecord{
+		stack: stack,
+		bytes: x.allocBytes,
+	}
+	if x.MustDefined() {
+		rec.shape, _ = x.Size()
+		rec.dtype = x.DType().String()
+	}
+	if len(stack) > 0 {
+		x.calledFrom = frameString(stack[0])
+	}
+
+	lock.Lock()
+	allocRecords[x.name] = rec
+	lock.Unlock()
+}
+
+// trimStack removes frames inside package ts (except tests) from the top and
+// runtime/testing frames from the bottom of a stack.
+func trimStack(pcs []uintptr) []uintptr {
+	for len(pcs) > 0 && isInternalFrame(pcs[0]) {
+		pcs = pcs[1:]
+	}
+	for len(pcs) > 0 {
+		name := funcName(pcs[len(pcs)-1])
+		if name != "runtime.goexit" && name != "runtime.main" && name != "testing.tRunner" {
+			break
+		}
+		pcs = pcs[:len(pcs)-1]
+	}
+	if len(pcs) > maxStackKept {
+		pcs = pcs[:maxStackKept]
+	}
+
+	stack := make([]uintptr, len(pcs))
+	copy(stack, pcs)
+
+	return stack
+}
+
+func isInternalFrame(pc uintptr) bool {
+	f := runtime.FuncForPC(pc - 1)
+	if f == nil {
+		return false
+	}
+	if !strings.HasPrefix(f.Name(), tsPackage) {
+		return false
+	}
+	file, _ := f.FileLine(pc - 1)
+
+	return !strings.HasSuffix(file, "_test.go")
+}
+
+func funcName(pc uintptr) string {
+	if f := runtime.FuncForPC(pc - 1); f != nil {
+		return f.Name()
+	}
+
+	return "unknown"
+}
+
+// frameString formats a stack frame as "function (dir/file.go:line)".
+func frameString(pc uintptr) string {
+	f := runtime.FuncForPC(pc - 1)
+	if f == nil {
+		return "unknown"
+	}
+	file, line := f.FileLine(pc - 1)
+	file = filepath.Join(filepath.Base(filepath.Dir(file)), filepath.Base(file))
+
+	return fmt.Sprintf("%s (%s:%d)", f.Name(), file, line)
+}
+
+// TensorAllocSite is statistics of live tensors created at the same call site.
+type TensorAllocSite struct {
+	CallSite string   // first frame outside package ts
+	Stack    []string // trimmed stack of the first recorded tensor, innermost first
+	Count    int      // number of live tensors
+	Bytes    int64    // total C memory of live tensors
+	Shapes   []string // distinct shapes
+	DTypes   []string // distinct dtypes
+}
+
+// TensorReport is a list of call sites sorted by memory in descending order.
+type TensorReport []TensorAllocSite
+
+// LiveTensorReport groups live tensors created in leak tracking mode by their
+// creation call site. See `SetLeakTracker()`.
+func LiveTensorReport() TensorReport {
+	lock.Lock()
+	records := make([]*allocRecord, 0, len(allocRecords))
+	for _, rec := range allocRecords {
+		records = append(records, rec)
+	}
+	lock.Unlock()
+
+	type siteInfo struct {
+		site   *TensorAllocSite
+		shapes map[string]struct{}
+		dtypes map[string]struct{}
+	}
+	sites := make(map[string]*siteInfo)
+	var order []string
+	for _, rec := range records {
+		callSite := "unknown"
+		if len(rec.stack) > 0 {
+			callSite = frameString(rec.stack[0])
+		}
+		info, ok := sites[callSite]
+		if !ok {
+			stack := make([]string, len(rec.stack))
+			for i, pc := range rec.stack {
+				stack[i] = frameString(pc)
+			}
+			info = &siteInfo{
+				site:   &TensorAllocSite{CallSite: callSite, Stack: stack},
+				shapes: make(map[string]struct{}),
+				dtypes: make(map[string]struct{}),
+			}
+			sites[callSite] = info
+			order = append(order, callSite)
+		}
+		info.site.Count++
+		info.site.Bytes += rec.bytes
+		if rec.dtype != "" {
+			info.shapes[fmt.Sprintf("%v", rec.shape)] = struct{}{}
+			info.dtypes[rec.dtype] = struct{}{}
+		}
+	}
+
+	report := make(TensorReport, 0, len(order))
+	for _, callSite := range order {
+		info := sites[callSite]
+		info.site.Shapes = sortedKeys(info.shapes)
+		info.site.DTypes = sortedKeys(info.dtypes)
+		report = append(report, *info.site)
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].Bytes != report[j].Bytes {
+			return report[i].Bytes > report[j].Bytes
+		}
+		return report[i].Count > report[j].Count
+	})
+
+	return report
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// String formats report as a table.
+func (r TensorReport) String() string {
+	var (
+		buf   bytes.Buffer
+		count int
+		total int64
+	)
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\tBYTES\tDTYPES\tSHAPES\tCALL SITE")
+	for _, s := range r {
+		shapes := s.Shapes
+		if len(shapes) > 3 {
+			shapes = append(shapes[:3:3], "...")
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", s.Count, s.Bytes, strings.Join(s.DTypes, ","), strings.Join(shapes, ","), s.CallSite)
+		count += s.Count
+		total += s.Bytes
+	}
+	fmt.Fprintf(w, "%d\t%d\t\t\tTOTAL\n", count, total)
+	w.Flush()
+
+	return buf.String()
+}
+
+// WriteTensorProfile writes live tensors created in leak tracking mode as a
+// gzipped pprof profile with sample types "inuse_objects" and "inuse_space"
+// so that it can be inspected by `go tool pprof`. See `SetLeakTracker()`.
+func WriteTensorProfile(w io.Writer) error {
+	lock.Lock()
+	records := make([]*allocRecord, 0, len(allocRecords))
+	for _, rec := range allocRecords {
+		records = append(records, rec)
+	}
+	lock.Unlock()
+
+	data := encodeProfile(records)
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(data); err != nil {
+		err = fmt.Errorf("WriteTensorProfile() failed: %w", err)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		err = fmt.Errorf("WriteTensorProfile() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// encodeProfile encodes records in pprof protobuf format.
+// Ref. https://github.com/google/pprof/blob/main/proto/profile.proto
+func encodeProfile(records []*allocRecord) []byte {
+	var (
+		strs      = []string{""}
+		strIdx    = map[string]int64{"": 0}
+		locIDs    = make(map[uintptr]uint64)
+		funcIDs   = make(map[string]uint64)
+		locations protoBuffer
+		functions protoBuffer
+		samples   protoBuffer
+	)
+	str := func(s string) int64 {
+		if i, ok := strIdx[s]; ok {
+			return i
+		}
+		strIdx[s] = int64(len(strs))
+		strs = append(strs, s)
+		return strIdx[s]
+	}
+	valueType := func(typ, unit string) []byte {
+		var b protoBuffer
+		b.int64Field(1, str(typ))
+		b.int64Field(2, str(unit))
+		return b.data
+	}
+	location := func(pc uintptr) uint64 {
+		if id, ok := locIDs[pc]; ok {
+			return id
+		}
+		id := uint64(len(locIDs) + 1)
+		locIDs[pc] = id
+
+		var loc protoBuffer
+		loc.uint64Field(1, id)
+		loc.uint64Field(2, 1) // mapping
+		loc.uint64Field(3, uint64(pc))
+		// Inlined frames, innermost first.
+		frames := runtime.CallersFrames([]uintptr{pc})
+		for {
+			frame, more := frames.Next()
+			fid, ok := funcIDs[frame.Function]
+			if !ok {
+				fid = uint64(len(funcIDs) + 1)
+				funcIDs[frame.Function] = fid
+				var fn protoBuffer
+				fn.uint64Field(1, fid)
+				fn.int64Field(2, str(frame.Function))
+				fn.int64Field(3, str(frame.Function))
+				fn.int64Field(4, str(frame.File))
+				functions.bytesField(5, fn.data)
+			}
+			var line protoBuffer
+			line.uint64Field(1, fid)
+			line.int64Field(2, int64(frame.Line))
+			loc.bytesField(4, line.data)
+			if !more {
+				break
+			}
+		}
+		locations.bytesField(4, loc.data)
+
+		return id
+	}
+
+	for _, rec := range records {
+		ids := make([]uint64, len(rec.stack))
+		for i, pc := range rec.stack {
+			ids[i] = location(pc)
+		}
+		var s protoBuffer
+		s.packedUint64s(1, ids)
+		s.packedInt64s(2, []int64{1, rec.bytes})
+		if rec.dtype != "" {
+			for _, l := range [][2]string{{"dtype", rec.dtype}, {"shape", fmt.Sprintf("%v", rec.shape)}} {
+				var label protoBuffer
+				label.int64Field(1, str(l[0]))
+				label.int64Field(2, str(l[1]))
+				s.bytesField(3, label.data)
+			}
+		}
+		samples.bytesField(2, s.data)
+	}
+
+	var mapping protoBuffer
+	mapping.uint64Field(1, 1)
+	mapping.uint64Field(3, ^uint64(0)) // memory limit
+	mapping.boolField(7, true)         // has functions
+	mapping.boolField(8, true)         // has filenames
+	mapping.boolField(9, true)         // has line numbers
+	mapping.boolField(10, true)        // has inline frames
+
+	var p protoBuffer
+	p.bytesField(1, valueType("inuse_objects", "count"))
+	p.bytesField(1, valueType("inuse_space", "bytes"))
+	p.append(samples.data)
+	p.bytesField(3, mapping.data)
+	p.append(locations.data)
+	p.append(functions.data)
+	periodType := valueType("space", "bytes")
+	defaultType := str("inuse_space")
+	// String table must be written after all strings are collected.
+	for _, s := range strs {
+		p.bytesField(6, []byte(s))
+	}
+	p.int64Field(9, time.Now().UnixNano())
+	p.bytesField(11, periodType)
+	p.int64Field(12, 1)
+	p.int64Field(14, defaultType)
+
+	return p.data
+}
+
+// protoBuffer is a minimal protocol buffer encoder.
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) append(data []byte) {
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		b.data = append(b.data, byte(v)|0x80)
+		v >>= 7
+	}
+	b.data = append(b.data, byte(v))
+}
+
+func (b *protoBuffer) key(tag, wireType int) {
+	b.varint(uint64(tag)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) uint64Field(tag int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.key(tag, 0)
+	b.varint(v)
+}
+
+func (b *protoBuffer) int64Field(tag int, v int64) {
+	b.uint64Field(tag, uint64(v))
+}
+
+func (b *protoBuffer) boolField(tag int, v bool) {
+	if v {
+		b.uint64Field(tag, 1)
+	}
+}
+
+func (b *protoBuffer) bytesField(tag int, data []byte) {
+	b.key(tag, 2)
+	b.varint(uint64(len(data)))
+	b.append(data)
+}
+
+func (b *protoBuffer) packedUint64s(tag int, vs []uint64) {
+	var packed protoBuffer
+	for _, v := range vs {
+		packed.varint(v)
+	}
+	b.bytesField(tag, packed.data)
+}
+
+func (b *protoBuffer) packedInt64s(tag int, vs []int64) {
+	var packed protoBuffer
+	for _, v := range vs {
+		packed.varint(uint64(v))
+	}
+	b.bytesField(tag, packed.data)
+}
@@ -0,0 +1,189 @@
+package ts
+
+// Tracking of C memory occupied by tensors.
+
+import (
+	"log"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/sugarme/gotch"
+	lib "github.com/sugarme/gotch/libtch"
+)
+
+// MemPressurePolicy specifies what to do when C memory occupied by live tensors
+// crosses the high-water mark set by `SetMemLimit()`.
+type MemPressurePolicy int
+
+const (
+	// MemPressureGC triggers Go garbage collection in background so that
+	// finalizers release unreachable tensors.
+	MemPressureGC MemPressurePolicy = iota
+	// MemPressureBlock triggers Go garbage collection and blocks goroutines
+	// creating new tensors until memory drops below the high-water mark or
+	// `MemBlockTimeout` elapsed (back-pressure).
+	MemPressureBlock
+)
+
+// MemBlockTimeout is maximum time a goroutine is blocked by `MemPressureBlock` policy.
+var MemBlockTimeout = 2 * time.Second
+
+var (
+	liveBytes   int64 // C memory occupied by live tensors (bytes)
+	peakBytes   int64 // highest value of liveBytes
+	liveTensors int64 // number of live tensors
+	memLimit    int64 // high-water mark (bytes). 0 means no limit
+	memTrigger  int64 // liveBytes level to trigger next collection
+	memPolicy   int64 // MemPressurePolicy
+	memGCs      int64 // number of collections triggered by memory pressure
+	memGCActive int32 // 1 if a background collection is running
+)
+
+// MemoryStats is statistics of C memory occupied by tensors.
+//
+// NOTE. memory is calculated as number of elements times element size of
+// tensors when they're created. It's only accounted while a high-water mark
+// (`SetMemLimit()`), the leak tracker (`SetLeakTracker()`) or debug mode is
+// enabled. Views sharing storage with other tensors and memory allocated
+// internally by libtorch are not counted.
+type MemoryStats struct {
+	LiveBytes   int64 // bytes occupied by live tensors
+	PeakBytes   int64 // highest LiveBytes so far
+	LiveTensors int64 // number of live tensors
+	Limit       int64 // high-water mark set by `SetMemLimit()`. 0 means no limit
+	NumGC       int64 // number of garbage collections triggered by memory pressure
+}
+
+// MemStats returns current statistics of C memory occupied by tensors.
+func MemStats() MemoryStats {
+	return MemoryStats{
+		LiveBytes:   atomic.LoadInt64(&liveBytes),
+		PeakBytes:   atomic.LoadInt64(&peakBytes),
+		LiveTensors: atomic.LoadInt64(&liveTensors),
+		Limit:       atomic.LoadInt64(&memLimit),
+		NumGC:       atomic.LoadInt64(&memGCs),
+	}
+}
+
+// SetMemLimit sets high-water mark of C memory occupied by live tensors (bytes)
+// and what to do when it's crossed. Zero or negative limit disables the mark.
+//
+// Go garbage collector doesn't see memory allocated by libtorch, so it may
+// not run while unreachable tensors hold a lot of C memory. Crossing the mark
+// runs collection so that tensor finalizers free this memory.
+//
+// Default values can be set with environment variables "GOTCH_MEM_LIMIT"
+// (e.g. "8GB") and "GOTCH_MEM_POLICY" ("gc" or "block").
+func SetMemLimit(limit int64, policy MemPressurePolicy) {
+	if limit < 0 {
+		limit = 0
+	}
+	atomic.StoreInt64(&memPolicy, int64(policy))
+	atomic.StoreInt64(&memLimit, limit)
+	atomic.StoreInt64(&memTrigger, limit)
+}
+
+// ResetPeakMem resets peak memory to current live memory.
+func ResetPeakMem() {
+	atomic.StoreInt64(&peakBytes, atomic.LoadInt64(&liveBytes))
+}
+
+func initMemLimit() {
+	policy := MemPressureGC
+	if gotch.MemPolicy == "block" {
+		policy = MemPressureBlock
+	}
+	SetMemLimit(gotch.MemLimit, policy)
+}
+
+// allocBytes returns C memory (bytes) to account for a new tensor. It's zero if
+// memory accounting is disabled or the tensor is a view of another tensor storage.
+func allocBytes(x *Tensor) int64 {
+	if !gotch.Debug && atomic.LoadInt64(&memLimit) == 0 && atomic.LoadInt32(&leakTracking) == 0 {
+		return 0
+	}
+
+	isView := lib.AtIsView(x.ctensor)
+	if err := TorchErr(); err == nil && isView {
+		return 0
+	}
+
+	return x.nbytes()
+}
+
+// allocMem accounts memory of a new tensor and applies memory pressure policy
+// if high-water mark is crossed.
+func allocMem(nbytes int64) {
+	atomic.AddInt64(&liveTensors, 1)
+	live := atomic.AddInt64(&liveBytes, nbytes)
+	for {
+		peak := atomic.LoadInt64(&peakBytes)
+		if live <= peak || atomic.CompareAndSwapInt64(&peakBytes, peak, live) {
+			break
+		}
+	}
+
+	limit := atomic.LoadInt64(&memLimit)
+	if limit == 0 || live <= atomic.LoadInt64(&memTrigger) {
+		return
+	}
+
+	switch MemPressurePolicy(atomic.LoadInt64(&memPolicy)) {
+	case MemPressureBlock:
+		waitMem(limit)
+	default:
+		if atomic.CompareAndSwapInt32(&memGCActive, 0, 1) {
+			go func() {
+				collect(limit)
+				atomic.StoreInt32(&memGCActive, 0)
+			}()
+		}
+	}
+}
+
+// freeMem accounts memory of a released tensor.
+func freeMem(nbytes int64) {
+	atomic.AddInt64(&liveTensors, -1)
+	live := atomic.AddInt64(&liveBytes, -nbytes)
+
+	// Memory dropped below the mark, trigger next collection at the mark again.
+	if limit := atomic.LoadInt64(&memLimit); live <= limit && atomic.LoadInt64(&memTrigger) > limit {
+		atomic.StoreInt64(&memTrigger, limit)
+	}
+}
+
+// collect runs garbage collection and sets level to trigger next collection.
+// If memory is still above the mark after collection (i.e. tensors are reachable),
+// next collection is triggered when memory grows by another quarter of the mark
+// so that collections don't run back to back.
+func collect(limit int64) {
+	atomic.AddInt64(&memGCs, 1)
+	runtime.GC()
+	// Finalizers run in a separate goroutine after collection.
+	time.Sleep(10 * time.Millisecond)
+
+	trigger := limit
+	if live := atomic.LoadInt64(&liveBytes); live > limit {
+		trigger = live + limit/4
+	}
+	atomic.StoreInt64(&memTrigger, trigger)
+
+	if gotch.Debug {
+		log.Printf("INFO: tensor memory over limit (%d bytes). Garbage collected - live memory: %d bytes.\n", limit, atomic.LoadInt64(&liveBytes))
+	}
+}
+
+// waitMem runs garbage collection and blocks until memory drops below limit
+// or MemBlockTimeout elapsed.
+func waitMem(limit int64) {
+	deadline := time.Now().Add(MemBlockTimeout)
+	collect(limit)
+	for atomic.LoadInt64(&liveBytes) > limit && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		runtime.GC()
+	}
+	if live := atomic.LoadInt64(&liveBytes); live <= limit {
+		atomic.StoreInt64(&memTrigger, limit)
+	}
+}
@@ -3,9 +3,11 @@ package ts
 import (
 	"archive/zip"
 	"bufio"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -118,6 +120,8 @@ func (h *NpyHeader) ToString() (string, error) {
 		descr = "i1"
 	case gotch.Uint8:
 		descr = "u1"
+	case gotch.Bool:
+		descr = "b1"
 	default:
 		err := fmt.Errorf("Unsupported kind: %v\n", h.descr)
 		return "", err
@@ -205,12 +209,12 @@ func ParseNpyHeader(header string) (*NpyHeader, error) {
 		return nil, err
 	}
 
-	descrStr := trimMatches([]rune{'=', '<'}, d)
+	descrStr := trimMatches([]rune{'=', '<', '|'}, d)
 
 	var descr gotch.DType
 	switch descrStr {
 	case "f2":
-		descr = gotch.Float // use Go float32 as there's no float16
+		descr = gotch.Half
 	case "f4":
 		descr = gotch.Float
 	case "f8":
@@ -225,8 +229,10 @@ func ParseNpyHeader(header string) (*NpyHeader, error) {
 		descr = gotch.Int8
 	case "u1":
 		descr = gotch.Uint8
+	case "b1":
+		descr = gotch.Bool
 	default:
-		err := fmt.Errorf("unrecognized descr: %v\n", descr)
+		err := fmt.Errorf("unrecognized descr: %v\n", descrStr)
 		return nil, err
 	}
 
@@ -294,10 +300,6 @@ func ReadNpy(filepath string) (*Tensor, error) {
 	if err != nil {
 		return nil, err
 	}
-	if header.fortranOrder {
-		err := fmt.Errorf("fortran order not supported.\n")
-		return nil, err
-	}
 
 	// Read all the rest
 	var data []byte
@@ -306,12 +308,41 @@ func ReadNpy(filepath string) (*Tensor, error) {
 		return nil, err
 	}
 
+	return ofNpyData(header, data)
+}
+
+// ofNpyData creates tensor from npy header and data.
+func ofNpyData(header *NpyHeader, data []byte) (*Tensor, error) {
+	shape := header.shape
 	// NOTE(TT.). case tensor 1 element with shape = []
-	if len(data) > 0 && len(header.shape) == 0 {
-		header.shape = []int64{1}
+	if len(data) > 0 && len(shape) == 0 {
+		shape = []int64{1}
 	}
 
-	return OfDataSize(data, header.shape, header.descr)
+	if !header.fortranOrder || len(shape) < 2 {
+		return OfDataSize(data, shape, header.descr)
+	}
+
+	// Fortran (column-major) data of shape (d0, d1, ..., dn) is C (row-major) data
+	// of shape (dn, ..., d1, d0). Hence, creating tensor with reversed shape then permuting
+	// dimensions back.
+	n := len(shape)
+	reversedShape := make([]int64, n)
+	dims := make([]int64, n)
+	for i := 0; i < n; i++ {
+		reversedShape[i] = shape[n-1-i]
+		dims[i] = int64(n - 1 - i)
+	}
+	x, err := OfDataSize(data, reversedShape, header.descr)
+	if err != nil {
+		return nil, err
+	}
+	x, err = x.Permute(dims, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return x.Contiguous(true)
 }
 
 // ReadNpz reads a compressed numpy file (.npz) and returns named tensors
@@ -342,23 +373,13 @@ func ReadNpz(filePath string) ([]NamedTensor, error) {
 			return nil, err
 		}
 
-		if header.fortranOrder {
-			err := fmt.Errorf("fortran order not supported.\n")
-			return nil, err
-		}
-
 		var data []byte
 		data, err = ioutil.ReadAll(rc)
 		if err != nil {
 			return nil, err
 		}
 
-		// NOTE(TT.). case tensor 1 element with shape = []
-		if len(data) > 0 && len(header.shape) == 0 {
-			header.shape = []int64{1}
-		}
-
-		tensor, err := OfDataSize(data, header.shape, header.descr)
+		tensor, err := ofNpyData(header, data)
 		if err != nil {
 			return nil, err
 		}
@@ -372,3 +393,195 @@ func ReadNpz(filePath string) ([]NamedTensor, error) {
 
 	return namedTensors, nil
 }
+
+// isFortranContiguous returns whether tensor data is contiguous in column-major
+// (Fortran) order but not in row-major (C) order.
+func (ts *Tensor) isFortranContiguous() (bool, error) {
+	shape, err := ts.Size()
+	if err != nil {
+		return false, err
+	}
+	if len(shape) < 2 {
+		return false, nil
+	}
+
+	isContiguous, err := ts.IsContiguous()
+	if err != nil {
+		return false, err
+	}
+	if isContiguous {
+		return false, nil
+	}
+
+	stride, err := ts.Stride()
+	if err != nil {
+		return false, err
+	}
+
+	var expected int64 = 1
+	for i, dim := range shape {
+		if dim != 1 && stride[i] != expected {
+			return false, nil
+		}
+		expected *= dim
+	}
+
+	return true, nil
+}
+
+// writeNpy writes tensor in npy format to the given writer.
+//
+// Tensor that is column-major contiguous (i.e. transposed) is written in Fortran order
+// as numpy does. Otherwise, it is written in C order.
+func (ts *Tensor) writeNpy(w io.Writer) error {
+	shape, err := ts.Size()
+	if err != nil {
+		return err
+	}
+
+	fortranOrder, err := ts.isFortranContiguous()
+	if err != nil {
+		return err
+	}
+
+	header := NewNpyHeader(ts.DType(), fortranOrder, shape)
+	headerStr, err := header.ToString()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if fortranOrder {
+		n := len(shape)
+		dims := make([]int64, n)
+		for i := 0; i < n; i++ {
+			dims[i] = int64(n - 1 - i)
+		}
+		x, err := ts.Permute(dims, false)
+		if err != nil {
+			return err
+		}
+		data, err = x.Bytes()
+		x.MustDrop()
+		if err != nil {
+			return err
+		}
+	} else {
+		data, err = ts.Bytes()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Header is padded with spaces and terminated with '\n' so that data starts
+	// at an offset divisible by 64 (numpy format v1.0 or v2.0 for long header).
+	const alignment = 64
+	version := []byte{1, 0}
+	headerLenLength := 2
+	preambleLen := len(NpyMagicString) + 2 + headerLenLength
+	padding := alignment - (preambleLen+len(headerStr)+1)%alignment
+	if len(headerStr)+padding+1 > 65535 {
+		version = []byte{2, 0}
+		headerLenLength = 4
+		preambleLen = len(NpyMagicString) + 2 + headerLenLength
+		padding = alignment - (preambleLen+len(headerStr)+1)%alignment
+	}
+	if padding == alignment {
+		padding = 0
+	}
+	headerStr += strings.Repeat(" ", padding) + "\n"
+
+	headerLen := make([]byte, headerLenLength)
+	if headerLenLength == 2 {
+		binary.LittleEndian.PutUint16(headerLen, uint16(len(headerStr)))
+	} else {
+		binary.LittleEndian.PutUint32(headerLen, uint32(len(headerStr)))
+	}
+
+	var buf []byte
+	buf = append(buf, []byte(NpyMagicString)...)
+	buf = append(buf, version...)
+	buf = append(buf, headerLen...)
+	buf = append(buf, []byte(headerStr)...)
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteNpy writes tensor to a numpy .npy file.
+func (ts *Tensor) WriteNpy(filepath string) error {
+	f, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err := ts.writeNpy(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// MustWriteNpy writes tensor to a numpy .npy file. It panics if error occurred.
+func (ts *Tensor) MustWriteNpy(filepath string) {
+	if err := ts.WriteNpy(filepath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// WriteNpz writes named tensors to a numpy .npz file.
+//
+// Each tensor is stored as "<name>.npy" record. If compressed is true, records are
+// compressed with zip deflate (i.e. `numpy.savez_compressed`) otherwise they are
+// stored uncompressed (i.e. `numpy.savez`).
+func WriteNpz(namedTensors []NamedTensor, filepath string, compressed bool) error {
+	f, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+
+	method := zip.Store
+	if compressed {
+		method = zip.Deflate
+	}
+
+	zw := zip.NewWriter(f)
+	for _, nt := range namedTensors {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   nt.Name + NpySuffix,
+			Method: method,
+		})
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if err := nt.Tensor.writeNpy(w); err != nil {
+			f.Close()
+			err = fmt.Errorf("WriteNpz() failed for tensor %q: %w", nt.Name, err)
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// MustWriteNpz writes named tensors to a numpy .npz file. It panics if error occurred.
+func MustWriteNpz(namedTensors []NamedTensor, filepath string, compressed bool) {
+	if err := WriteNpz(namedTensors, filepath, compressed); err != nil {
+		log.Fatal(err)
+	}
+}
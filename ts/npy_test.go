@@ -1,6 +1,7 @@
 package ts_test
 
 import (
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -60,3 +61,89 @@ func testToString(t *testing.T, want string, h *ts.NpyHeader) {
 		t.Errorf("got: %+v\n", got)
 	}
 }
+
+func npyTestTensors() []ts.NamedTensor {
+	dtypes := []gotch.DType{
+		gotch.Bool,
+		gotch.Uint8,
+		gotch.Int8,
+		gotch.Int16,
+		gotch.Int,
+		gotch.Int64,
+		gotch.Half,
+		gotch.Float,
+		gotch.Double,
+	}
+
+	var namedTensors []ts.NamedTensor
+	for _, dtype := range dtypes {
+		x := ts.MustArange(ts.IntScalar(6), gotch.Float, gotch.CPU).MustView([]int64{2, 3}, true).MustTotype(dtype, true)
+		namedTensors = append(namedTensors, ts.NamedTensor{Name: dtype.String(), Tensor: x})
+	}
+	// Fortran order (column-major contiguous)
+	x := ts.MustArange(ts.IntScalar(24), gotch.Float, gotch.CPU).MustView([]int64{2, 3, 4}, true).MustPermute([]int64{2, 1, 0}, true)
+	namedTensors = append(namedTensors, ts.NamedTensor{Name: "fortran", Tensor: x})
+	// Scalar
+	namedTensors = append(namedTensors, ts.NamedTensor{Name: "scalar", Tensor: ts.MustOfSlice([]float64{1.5}).MustView([]int64{}, true)})
+
+	return namedTensors
+}
+
+func testNpyEqual(t *testing.T, name string, want, got *ts.Tensor) {
+	if got.DType() != want.DType() {
+		t.Errorf("%q: want dtype %v, got %v", name, want.DType(), got.DType())
+	}
+	if want.Numel() == 1 {
+		if !reflect.DeepEqual(want.Float64Values(), got.Float64Values()) {
+			t.Errorf("%q: want %v, got %v", name, want.Float64Values(), got.Float64Values())
+		}
+		return
+	}
+	if !reflect.DeepEqual(want.MustSize(), got.MustSize()) {
+		t.Errorf("%q: want shape %v, got %v", name, want.MustSize(), got.MustSize())
+	}
+	if !reflect.DeepEqual(want.Float64Values(), got.Float64Values()) {
+		t.Errorf("%q: want %v, got %v", name, want.Float64Values(), got.Float64Values())
+	}
+}
+
+func TestWriteNpy(t *testing.T) {
+	dir := t.TempDir()
+	for _, nt := range npyTestTensors() {
+		file := filepath.Join(dir, nt.Name+ts.NpySuffix)
+		if err := nt.Tensor.WriteNpy(file); err != nil {
+			t.Fatalf("%q: %v", nt.Name, err)
+		}
+
+		got, err := ts.ReadNpy(file)
+		if err != nil {
+			t.Fatalf("%q: %v", nt.Name, err)
+		}
+		testNpyEqual(t, nt.Name, nt.Tensor, got)
+	}
+}
+
+func TestWriteNpz(t *testing.T) {
+	namedTensors := npyTestTensors()
+	for _, compressed := range []bool{false, true} {
+		file := filepath.Join(t.TempDir(), "data.npz")
+		if err := ts.WriteNpz(namedTensors, file, compressed); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := ts.ReadNpz(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded) != len(namedTensors) {
+			t.Fatalf("want %v tensors, got %v", len(namedTensors), len(loaded))
+		}
+		for i, want := range namedTensors {
+			got := loaded[i]
+			if got.Name != want.Name {
+				t.Errorf("want name %q, got %q", want.Name, got.Name)
+			}
+			testNpyEqual(t, want.Name, want.Tensor, got.Tensor)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ts
 
 import (
+	"fmt"
 	"log"
 
 	lib "github.com/sugarme/gotch/libtch"
@@ -168,3 +169,47 @@ func (co *COptimizer) Drop() {
 		log.Fatal(err)
 	}
 }
+
+// GetState returns optimizer state of a given parameter. States is a slice of 3
+// tensors whose meaning depends on optimizer (unused or undefined states are nil):
+//
+//   - Adam, AdamW: exp_avg, exp_avg_sq, max_exp_avg_sq
+//   - RMSProp: square_avg, momentum_buffer, grad_avg
+//   - SGD: momentum_buffer
+//
+// Returned step is -1 if parameter has no state yet (i.e. optimizer has not stepped).
+func (co *COptimizer) GetState(param *Tensor) (states []*Tensor, step int64, err error) {
+	ctensors := make([]lib.Ctensor, 3)
+	step = lib.AtoGetState(co.coptimizer, param.ctensor, &ctensors[0])
+	if err = TorchErr(); err != nil {
+		return nil, -1, err
+	}
+
+	states = make([]*Tensor, len(ctensors))
+	for i, ctensor := range ctensors {
+		if ctensor != nil {
+			states[i] = newTensor(ctensor)
+		}
+	}
+
+	return states, step, nil
+}
+
+// SetState sets optimizer state of a given parameter. See `GetState` for
+// layout of states. Nil states are left undefined. State tensors are copied.
+func (co *COptimizer) SetState(param *Tensor, states []*Tensor, step int64) error {
+	if len(states) > 3 {
+		err := fmt.Errorf("SetState() failed: expected at most 3 state tensors, got %v", len(states))
+		return err
+	}
+
+	ctensors := make([]lib.Ctensor, 3)
+	for i, t := range states {
+		if t != nil {
+			ctensors[i] = t.ctensor
+		}
+	}
+	lib.AtoSetState(co.coptimizer, param.ctensor, &ctensors[0], step)
+
+	return TorchErr()
+}
@@ -4,6 +4,7 @@ package ts
 import "C"
 
 import (
+	"fmt"
 	"log"
 	"unsafe"
 
@@ -183,6 +184,87 @@ func (ts *Tensor) MustNLLLoss(target *Tensor, del bool) (retVal *Tensor) {
 	return retVal
 }
 
+// NOTE. `Index` and `IndexPut_` are missing from `tensor-generated.go` as their
+// indices are a list of optional tensors. A nil element in `indices` is `None`,
+// i.e. the corresponding dimension is not indexed.
+
+// optionalCtensors converts optional tensors to C tensors. Nil tensor is
+// converted to C null pointer.
+func optionalCtensors(tensors []*Tensor) []lib.Ctensor {
+	var ctensors []lib.Ctensor
+	for _, t := range tensors {
+		if t == nil {
+			ctensors = append(ctensors, nil)
+			continue
+		}
+		ctensors = append(ctensors, t.ctensor)
+	}
+
+	return ctensors
+}
+
+// void atg_index(tensor *, tensor self, tensor *indices_data, int indices_len);
+func (ts *Tensor) Index(indices []*Tensor, del bool) (retVal *Tensor, err error) {
+	if del {
+		defer ts.MustDrop()
+	}
+	if len(indices) == 0 {
+		err = fmt.Errorf("Index() failed: empty indices")
+		return retVal, err
+	}
+
+	ptr := (*lib.Ctensor)(unsafe.Pointer(C.malloc(0)))
+	cindices := optionalCtensors(indices)
+	lib.AtgIndex(ptr, ts.ctensor, cindices, len(cindices))
+	if err = TorchErr(); err != nil {
+		err = fmt.Errorf("Index() failed: %w", err)
+		return retVal, err
+	}
+
+	retVal = newTensor(*ptr, "Index")
+
+	return retVal, nil
+}
+
+func (ts *Tensor) MustIndex(indices []*Tensor, del bool) (retVal *Tensor) {
+	retVal, err := ts.Index(indices, del)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return retVal
+}
+
+// void atg_index_put_(tensor *, tensor self, tensor *indices_data, int indices_len, tensor values, int accumulate);
+func (ts *Tensor) IndexPut_(indices []*Tensor, values *Tensor, accumulate bool) error {
+	if len(indices) == 0 {
+		err := fmt.Errorf("IndexPut_() failed: empty indices")
+		return err
+	}
+
+	ptr := (*lib.Ctensor)(unsafe.Pointer(C.malloc(0)))
+	cindices := optionalCtensors(indices)
+	caccumulate := int32(0)
+	if accumulate {
+		caccumulate = int32(1)
+	}
+	lib.AtgIndexPut_(ptr, ts.ctensor, cindices, len(cindices), values.ctensor, caccumulate)
+	if err := TorchErr(); err != nil {
+		err = fmt.Errorf("IndexPut_() failed: %w", err)
+		return err
+	}
+	ts.ctensor = *ptr
+
+	return nil
+}
+
+func (ts *Tensor) MustIndexPut_(indices []*Tensor, values *Tensor, accumulate bool) {
+	err := ts.IndexPut_(indices, values, accumulate)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 // NOTE: the following 9 APIs are missing from `tensor-generated.go` with
 // pattern of **return tensor pointer**: `tensor *atg_FUNCTION_NAME()`.
 // The returning tensor pointer actually is the FIRST element of a vector
@@ -0,0 +1,413 @@
+package ts
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"syscall"
+
+	"github.com/sugarme/gotch"
+)
+
+// This file implements reading and writing of safetensors format.
+// Ref. https://github.com/huggingface/safetensors
+//
+// File layout:
+// - 8 bytes: N, little-endian unsigned 64-bits integer - size of header.
+// - N bytes: JSON UTF-8 string header. E.g.
+// {"weight": {"dtype": "F32", "shape": [2, 2], "data_offsets": [0, 16]}, "__metadata__": {"format": "pt"}}
+// - rest of file: byte buffer of tensor data. Offsets are relative to the start of this buffer.
+
+const (
+	SafetensorsSuffix string = ".safetensors"
+
+	safetensorsMetadataKey        = "__metadata__"
+	safetensorsMaxHeaderSize      = 100_000_000 // 100MB
+	safetensorsHeaderAlignment    = 8
+	safetensorsHeaderSizeNumBytes = 8
+)
+
+var safetensorsDTypes map[string]gotch.DType = map[string]gotch.DType{
+	"BOOL": gotch.Bool,
+	"U8":   gotch.Uint8,
+	"I8":   gotch.Int8,
+	"I16":  gotch.Int16,
+	"I32":  gotch.Int,
+	"I64":  gotch.Int64,
+	"F16":  gotch.Half,
+	"BF16": gotch.BFloat16,
+	"F32":  gotch.Float,
+	"F64":  gotch.Double,
+	"C64":  gotch.ComplexFloat,
+	"C128": gotch.ComplexDouble,
+}
+
+var dtype2Safetensors map[gotch.DType]string = map[gotch.DType]string{
+	gotch.Bool:          "BOOL",
+	gotch.Uint8:         "U8",
+	gotch.Int8:          "I8",
+	gotch.Int16:         "I16",
+	gotch.Int:           "I32",
+	gotch.Int64:         "I64",
+	gotch.Half:          "F16",
+	gotch.BFloat16:      "BF16",
+	gotch.Float:         "F32",
+	gotch.Double:        "F64",
+	gotch.ComplexFloat:  "C64",
+	gotch.ComplexDouble: "C128",
+}
+
+// safetensorsEntry is a tensor entry in safetensors JSON header.
+type safetensorsEntry struct {
+	DType       string   `json:"dtype"`
+	Shape       []int64  `json:"shape"`
+	DataOffsets [2]int64 `json:"data_offsets"`
+}
+
+// Safetensors is a safetensors file opened for reading.
+//
+// Tensor data is memory-mapped and only copied to a tensor when it is requested
+// by name with `Safetensors.Tensor()`.
+type Safetensors struct {
+	Metadata map[string]string // header `__metadata__`. Can be empty.
+
+	entries map[string]safetensorsEntry
+	names   []string // tensor names in order of their data offsets
+	data    []byte   // memory-mapped tensor data
+	mmap    []byte   // memory-mapped whole file
+}
+
+// OpenSafetensors opens and memory-maps a safetensors file and parses its header.
+//
+// NOTE. Safetensors.Close() should be called to unmap file when done.
+func OpenSafetensors(path string) (*Safetensors, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("OpenSafetensors() failed: %w", err)
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		err = fmt.Errorf("OpenSafetensors() failed: %w", err)
+		return nil, err
+	}
+	size := fi.Size()
+	if size < safetensorsHeaderSizeNumBytes {
+		err = fmt.Errorf("OpenSafetensors() failed: file too small (%d bytes)", size)
+		return nil, err
+	}
+
+	mmap, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		err = fmt.Errorf("OpenSafetensors() failed: mmap: %w", err)
+		return nil, err
+	}
+
+	st, err := parseSafetensors(mmap)
+	if err != nil {
+		syscall.Munmap(mmap)
+		err = fmt.Errorf("OpenSafetensors() failed: %w", err)
+		return nil, err
+	}
+	st.mmap = mmap
+
+	return st, nil
+}
+
+// parseSafetensors parses safetensors header from the whole file buffer.
+func parseSafetensors(buf []byte) (*Safetensors, error) {
+	n := binary.LittleEndian.Uint64(buf[:safetensorsHeaderSizeNumBytes])
+	if n > safetensorsMaxHeaderSize {
+		err := fmt.Errorf("header too large (%d bytes)", n)
+		return nil, err
+	}
+	headerEnd := safetensorsHeaderSizeNumBytes + int(n)
+	if headerEnd > len(buf) {
+		err := fmt.Errorf("invalid header length %d, file size %d bytes", n, len(buf))
+		return nil, err
+	}
+
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(buf[safetensorsHeaderSizeNumBytes:headerEnd], &header); err != nil {
+		err = fmt.Errorf("invalid header: %w", err)
+		return nil, err
+	}
+
+	st := &Safetensors{
+		Metadata: make(map[string]string),
+		entries:  make(map[string]safetensorsEntry, len(header)),
+		data:     buf[headerEnd:],
+	}
+
+	for name, raw := range header {
+		if name == safetensorsMetadataKey {
+			if err := json.Unmarshal(raw, &st.Metadata); err != nil {
+				err = fmt.Errorf("invalid %s: %w", safetensorsMetadataKey, err)
+				return nil, err
+			}
+			continue
+		}
+
+		var e safetensorsEntry
+		if err := json.Unmarshal(raw, &e); err != nil {
+			err = fmt.Errorf("invalid entry for tensor %q: %w", name, err)
+			return nil, err
+		}
+
+		dtype, ok := safetensorsDTypes[e.DType]
+		if !ok {
+			err := fmt.Errorf("unsupported dtype %q for tensor %q", e.DType, name)
+			return nil, err
+		}
+
+		begin, end := e.DataOffsets[0], e.DataOffsets[1]
+		if begin < 0 || end < begin || end > int64(len(st.data)) {
+			err := fmt.Errorf("invalid data offsets %v for tensor %q", e.DataOffsets, name)
+			return nil, err
+		}
+		nbytes := int64(ElementCount(e.Shape)) * int64(dtype.Size())
+		if end-begin != nbytes {
+			err := fmt.Errorf("tensor %q: data length (%d bytes) and shape %v mismatched for dtype %v", name, end-begin, e.Shape, dtype)
+			return nil, err
+		}
+
+		st.entries[name] = e
+		st.names = append(st.names, name)
+	}
+
+	sort.Slice(st.names, func(i, j int) bool {
+		return st.entries[st.names[i]].DataOffsets[0] < st.entries[st.names[j]].DataOffsets[0]
+	})
+
+	return st, nil
+}
+
+// Close unmaps the safetensors file.
+func (st *Safetensors) Close() error {
+	if st.mmap == nil {
+		return nil
+	}
+
+	err := syscall.Munmap(st.mmap)
+	st.mmap = nil
+	st.data = nil
+
+	return err
+}
+
+// Names returns names of all tensors in the file in storage order.
+func (st *Safetensors) Names() []string {
+	names := make([]string, len(st.names))
+	copy(names, st.names)
+
+	return names
+}
+
+// Has returns whether a tensor with the given name is in the file.
+func (st *Safetensors) Has(name string) bool {
+	_, ok := st.entries[name]
+	return ok
+}
+
+// Shape returns shape of a named tensor without loading it.
+func (st *Safetensors) Shape(name string) ([]int64, error) {
+	e, ok := st.entries[name]
+	if !ok {
+		err := fmt.Errorf("Safetensors.Shape() failed: tensor %q not found", name)
+		return nil, err
+	}
+
+	shape := make([]int64, len(e.Shape))
+	copy(shape, e.Shape)
+
+	return shape, nil
+}
+
+// DType returns dtype of a named tensor without loading it.
+func (st *Safetensors) DType(name string) (gotch.DType, error) {
+	e, ok := st.entries[name]
+	if !ok {
+		err := fmt.Errorf("Safetensors.DType() failed: tensor %q not found", name)
+		return gotch.Invalid, err
+	}
+
+	return safetensorsDTypes[e.DType], nil
+}
+
+// Tensor copies a named tensor from the memory-mapped data to a new CPU tensor.
+func (st *Safetensors) Tensor(name string) (*Tensor, error) {
+	if st.data == nil {
+		err := fmt.Errorf("Safetensors.Tensor() failed: file has been closed")
+		return nil, err
+	}
+
+	e, ok := st.entries[name]
+	if !ok {
+		err := fmt.Errorf("Safetensors.Tensor() failed: tensor %q not found", name)
+		return nil, err
+	}
+
+	x, err := OfDataSize(st.data[e.DataOffsets[0]:e.DataOffsets[1]], e.Shape, safetensorsDTypes[e.DType], WithName(name))
+	if err != nil {
+		err = fmt.Errorf("Safetensors.Tensor() failed: tensor %q: %w", name, err)
+		return nil, err
+	}
+
+	return x, nil
+}
+
+// LoadSafetensors loads all tensors from a safetensors file.
+func LoadSafetensors(path string) ([]NamedTensor, error) {
+	namedTensors, _, err := LoadSafetensorsWithMetadata(path)
+	return namedTensors, err
+}
+
+// LoadSafetensorsWithMetadata loads all tensors and the header `__metadata__`
+// from a safetensors file.
+func LoadSafetensorsWithMetadata(path string) ([]NamedTensor, map[string]string, error) {
+	st, err := OpenSafetensors(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer st.Close()
+
+	var namedTensors []NamedTensor
+	for _, name := range st.names {
+		x, err := st.Tensor(name)
+		if err != nil {
+			for _, nt := range namedTensors {
+				nt.Tensor.MustDrop()
+			}
+			return nil, nil, err
+		}
+		namedTensors = append(namedTensors, NamedTensor{name, x})
+	}
+
+	return namedTensors, st.Metadata, nil
+}
+
+// MustLoadSafetensors loads all tensors from a safetensors file. It will panic if error.
+func MustLoadSafetensors(path string) []NamedTensor {
+	namedTensors, err := LoadSafetensors(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return namedTensors
+}
+
+// SaveSafetensors saves named tensors to a safetensors file.
+//
+// Optional metadata is saved to header `__metadata__`.
+//
+// NOTE. All gotch dtypes are supported except `gotch.ComplexHalf` and quantized
+// dtypes, which have no safetensors equivalent.
+func SaveSafetensors(namedTensors []NamedTensor, path string, metadataOpt ...map[string]string) error {
+	sorted := make([]NamedTensor, len(namedTensors))
+	copy(sorted, namedTensors)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	header := make(map[string]interface{}, len(sorted)+1)
+	if len(metadataOpt) > 0 && len(metadataOpt[0]) > 0 {
+		header[safetensorsMetadataKey] = metadataOpt[0]
+	}
+
+	datas := make([][]byte, len(sorted))
+	var offset int64 = 0
+	for i, nt := range sorted {
+		if nt.Name == safetensorsMetadataKey {
+			err := fmt.Errorf("SaveSafetensors() failed: invalid tensor name %q", nt.Name)
+			return err
+		}
+		if _, ok := header[nt.Name]; ok {
+			err := fmt.Errorf("SaveSafetensors() failed: duplicated tensor name %q", nt.Name)
+			return err
+		}
+
+		dtype := nt.Tensor.DType()
+		dtypeStr, ok := dtype2Safetensors[dtype]
+		if !ok {
+			dtypeName := dtype.String()
+			if dtype == gotch.ComplexHalf {
+				dtypeName = "ComplexHalf" // no name in gotch.DType.String()
+			}
+			err := fmt.Errorf("SaveSafetensors() failed: unsupported dtype %s for tensor %q", dtypeName, nt.Name)
+			return err
+		}
+		shape, err := nt.Tensor.Size()
+		if err != nil {
+			err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+			return err
+		}
+		data, err := nt.Tensor.Bytes()
+		if err != nil {
+			err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+			return err
+		}
+
+		datas[i] = data
+		header[nt.Name] = safetensorsEntry{
+			DType:       dtypeStr,
+			Shape:       shape,
+			DataOffsets: [2]int64{offset, offset + int64(len(data))},
+		}
+		offset += int64(len(data))
+	}
+
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+		return err
+	}
+	// Pad header with spaces so that data buffer is aligned.
+	for len(headerBytes)%safetensorsHeaderAlignment != 0 {
+		headerBytes = append(headerBytes, ' ')
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	sizeBuf := make([]byte, safetensorsHeaderSizeNumBytes)
+	binary.LittleEndian.PutUint64(sizeBuf, uint64(len(headerBytes)))
+	if _, err := w.Write(sizeBuf); err != nil {
+		err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+		return err
+	}
+	if _, err := w.Write(headerBytes); err != nil {
+		err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+		return err
+	}
+	for _, data := range datas {
+		if _, err := w.Write(data); err != nil {
+			err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		err = fmt.Errorf("SaveSafetensors() failed: %w", err)
+		return err
+	}
+
+	return nil
+}
+
+// MustSaveSafetensors saves named tensors to a safetensors file. It will panic if error.
+func MustSaveSafetensors(namedTensors []NamedTensor, path string, metadataOpt ...map[string]string) {
+	if err := SaveSafetensors(namedTensors, path, metadataOpt...); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,135 @@
+package ts_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestSafetensors_Read(t *testing.T) {
+	// Header and data created with Python `safetensors.torch.save_file()`
+	header := `{"a":{"dtype":"F32","shape":[2],"data_offsets":[0,8]},"b":{"dtype":"I64","shape":[1,1],"data_offsets":[8,16]},"__metadata__":{"format":"pt"}}`
+	var buf []byte
+	sizeBuf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(sizeBuf, uint64(len(header)))
+	buf = append(buf, sizeBuf...)
+	buf = append(buf, []byte(header)...)
+	buf = append(buf, 0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0x40) // float32: 1.0, 2.0
+	buf = append(buf, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00) // int64: 7
+
+	file := filepath.Join(t.TempDir(), "model.safetensors")
+	if err := os.WriteFile(file, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	namedTensors, metadata, err := ts.LoadSafetensorsWithMetadata(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantMetadata := map[string]string{"format": "pt"}
+	if !reflect.DeepEqual(wantMetadata, metadata) {
+		t.Errorf("want metadata: %v, got: %v", wantMetadata, metadata)
+	}
+
+	if len(namedTensors) != 2 {
+		t.Fatalf("want 2 tensors, got %v", len(namedTensors))
+	}
+
+	a := namedTensors[0]
+	if a.Name != "a" || !reflect.DeepEqual(a.Tensor.Vals(), []float32{1, 2}) {
+		t.Errorf("want a: [1 2], got %q: %v", a.Name, a.Tensor.Vals())
+	}
+	b := namedTensors[1]
+	if b.Name != "b" || !reflect.DeepEqual(b.Tensor.MustSize(), []int64{1, 1}) || !reflect.DeepEqual(b.Tensor.Vals(), []int64{7}) {
+		t.Errorf("want b: [[7]], got %q: %v", b.Name, b.Tensor.Vals())
+	}
+}
+
+func TestSafetensors_SaveLoad(t *testing.T) {
+	dtypes := []gotch.DType{
+		gotch.Bool,
+		gotch.Uint8,
+		gotch.Int8,
+		gotch.Int16,
+		gotch.Int,
+		gotch.Int64,
+		gotch.Half,
+		gotch.BFloat16,
+		gotch.Float,
+		gotch.Double,
+	}
+
+	var namedTensors []ts.NamedTensor
+	for _, dtype := range dtypes {
+		x := ts.MustArange(ts.IntScalar(6), gotch.Float, gotch.CPU).MustView([]int64{2, 3}, true).MustTotype(dtype, true)
+		namedTensors = append(namedTensors, ts.NamedTensor{Name: dtype.String(), Tensor: x})
+	}
+	// non-contiguous tensor
+	x := ts.MustArange(ts.IntScalar(6), gotch.Float, gotch.CPU).MustView([]int64{2, 3}, true).MustT(true)
+	namedTensors = append(namedTensors, ts.NamedTensor{Name: "transposed", Tensor: x})
+
+	file := filepath.Join(t.TempDir(), "model.safetensors")
+	metadata := map[string]string{"format": "pt"}
+	if err := ts.SaveSafetensors(namedTensors, file, metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, gotMetadata, err := ts.LoadSafetensorsWithMetadata(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(metadata, gotMetadata) {
+		t.Errorf("want metadata: %v, got: %v", metadata, gotMetadata)
+	}
+
+	got := make(map[string]*ts.Tensor)
+	for _, nt := range loaded {
+		got[nt.Name] = nt.Tensor
+	}
+
+	for _, want := range namedTensors {
+		x, ok := got[want.Name]
+		if !ok {
+			t.Errorf("missing tensor %q", want.Name)
+			continue
+		}
+		if x.DType() != want.Tensor.DType() {
+			t.Errorf("%q: want dtype %v, got %v", want.Name, want.Tensor.DType(), x.DType())
+		}
+		if !reflect.DeepEqual(x.MustSize(), want.Tensor.MustSize()) {
+			t.Errorf("%q: want shape %v, got %v", want.Name, want.Tensor.MustSize(), x.MustSize())
+		}
+		if !reflect.DeepEqual(x.Float64Values(), want.Tensor.Float64Values()) {
+			t.Errorf("%q: want %v, got %v", want.Name, want.Tensor.Float64Values(), x.Float64Values())
+		}
+	}
+}
+
+func TestSafetensors_ComplexDTypes(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "complex.safetensors")
+
+	x := ts.MustOnes([]int64{2, 3}, gotch.ComplexDouble, gotch.CPU)
+	if err := ts.SaveSafetensors([]ts.NamedTensor{{Name: "x", Tensor: x}}, file); err != nil {
+		t.Fatal(err)
+	}
+	loaded := ts.MustLoadSafetensors(file)
+	if got := loaded[0].Tensor.DType(); got != gotch.ComplexDouble {
+		t.Errorf("want dtype %v, got %v", gotch.ComplexDouble, got)
+	}
+	if !reflect.DeepEqual(loaded[0].Tensor.MustBytes(), x.MustBytes()) {
+		t.Errorf("ComplexDouble tensor data mismatched")
+	}
+
+	half := ts.MustOnes([]int64{2}, gotch.ComplexHalf, gotch.CPU)
+	err := ts.SaveSafetensors([]ts.NamedTensor{{Name: "half", Tensor: half}}, file)
+	if err == nil || !strings.Contains(err.Error(), "ComplexHalf") {
+		t.Errorf("Expected unsupported dtype ComplexHalf error, got %v", err)
+	}
+}
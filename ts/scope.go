@@ -0,0 +1,215 @@
+package ts
+
+// Scoped tensor lifetimes.
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Scope tracks tensors created by a goroutine so that they can be freed
+// all together when the scope is closed instead of waiting for Go garbage
+// collector to run tensor finalizers.
+//
+// Scopes are goroutine-aware: a scope only tracks tensors created by the goroutine
+// that opened it. Tensors created in other goroutines (including ones spawned inside
+// the scope) are not tracked unless they're added with `Scope.Add()`.
+// Scopes can be nested. Kept tensors of an inner scope are moved to the enclosing scope.
+//
+// VarStore variables and optimizer states are not freed by scopes. However, modules
+// may hold tensors derived from their variables, so they should be built outside
+// scopes (or inside `WithoutScope()`).
+//
+// Example:
+//
+//	for i := 0; i < epochs; i++ {
+//		ts.WithScope(func(s *ts.Scope) {
+//			logits := model.ForwardT(x, true)
+//			loss := logits.CrossEntropyForLogits(y)
+//			opt.BackwardStep(loss)
+//			lossVal = loss.Float64Values()[0]
+//		}) // all tensors created in the loop body are freed here.
+//	}
+type Scope struct {
+	parent    *Scope
+	gid       uint64
+	untracked bool // scope created by `WithoutScope()` that doesn't track tensors
+
+	mu      sync.Mutex
+	tensors []*Tensor
+	kept    map[*Tensor]struct{}
+	closed  bool
+}
+
+var (
+	scopeLock    sync.Mutex
+	scopes       = make(map[uint64]*Scope) // innermost scope of each goroutine
+	activeScopes int64                     // number of open scopes. Used to skip scope lookup when zero.
+)
+
+// NewScope opens a new scope for the current goroutine. Tensors created by
+// the goroutine will be tracked by the scope until `Scope.Close()` is called.
+//
+// NOTE. `WithScope()` should be preferred as it closes the scope even if
+// panic occurred.
+func NewScope() *Scope {
+	return openScope(false)
+}
+
+func openScope(untracked bool) *Scope {
+	gid := goid()
+	s := &Scope{
+		gid:       gid,
+		untracked: untracked,
+		kept:      make(map[*Tensor]struct{}),
+	}
+
+	scopeLock.Lock()
+	s.parent = scopes[gid]
+	scopes[gid] = s
+	scopeLock.Unlock()
+	atomic.AddInt64(&activeScopes, 1)
+
+	return s
+}
+
+// WithScope runs fn in a new scope. All tensors created by the current goroutine
+// inside fn are freed when fn returns, except ones marked with `Scope.Keep()`.
+func WithScope(fn func(s *Scope)) {
+	s := NewScope()
+	defer s.Close()
+
+	fn(s)
+}
+
+// WithScopeTensor runs fn in a new scope and returns the tensor returned by fn.
+// The returned tensor is kept, all other tensors created inside fn are freed.
+func WithScopeTensor(fn func(s *Scope) *Tensor) *Tensor {
+	s := NewScope()
+	defer s.Close()
+
+	x := fn(s)
+	s.Keep(x)
+
+	return x
+}
+
+// WithoutScope runs fn without tracking tensors created by the current goroutine
+// by any open scope. It can be used to create long-lived tensors (e.g. model
+// variables or optimizer states) inside a scope.
+func WithoutScope(fn func()) {
+	s := openScope(true)
+	defer s.Close()
+
+	fn()
+}
+
+// Keep marks tensors to survive closing of the scope. If the scope is nested,
+// kept tensors are tracked by the enclosing scope.
+func (s *Scope) Keep(xs ...*Tensor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, x := range xs {
+		if x != nil {
+			s.kept[x] = struct{}{}
+		}
+	}
+}
+
+// Add adds tensors to be freed when the scope is closed. It can be used to
+// track tensors created outside the scope, e.g. by other goroutines.
+func (s *Scope) Add(xs ...*Tensor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, x := range xs {
+		if x != nil {
+			s.tensors = append(s.tensors, x)
+		}
+	}
+}
+
+// Len returns number of tensors tracked by the scope.
+func (s *Scope) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.tensors)
+}
+
+// Close frees all tensors tracked by the scope except kept ones and restores
+// the enclosing scope of the goroutine. Closing a closed scope is a no-op.
+func (s *Scope) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	tensors, kept := s.tensors, s.kept
+	s.tensors, s.kept = nil, nil
+	s.mu.Unlock()
+
+	scopeLock.Lock()
+	if scopes[s.gid] == s {
+		if s.parent != nil {
+			scopes[s.gid] = s.parent
+		} else {
+			delete(scopes, s.gid)
+		}
+	}
+	scopeLock.Unlock()
+	atomic.AddInt64(&activeScopes, -1)
+
+	var survivors []*Tensor
+	for _, x := range tensors {
+		if _, ok := kept[x]; ok {
+			survivors = append(survivors, x)
+			continue
+		}
+		x.MustDrop()
+	}
+
+	// Kept tensors escape to enclosing scope.
+	if p := s.parent; p != nil && !p.untracked && len(survivors) > 0 {
+		p.Add(survivors...)
+	}
+}
+
+// track adds a newly created tensor to the innermost scope of the current goroutine if any.
+func track(x *Tensor) {
+	if atomic.LoadInt64(&activeScopes) == 0 {
+		return
+	}
+
+	scopeLock.Lock()
+	s := scopes[goid()]
+	scopeLock.Unlock()
+	if s == nil || s.untracked {
+		return
+	}
+
+	s.mu.Lock()
+	if !s.closed {
+		s.tensors = append(s.tensors, x)
+	}
+	s.mu.Unlock()
+}
+
+// goid returns id of the current goroutine parsed from its stack trace header,
+// i.e. "goroutine 18 [running]:".
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+
+	return id
+}
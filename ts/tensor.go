@@ -54,6 +54,7 @@ type Tensor struct {
 	name       string
 	ctensor    lib.Ctensor
 	calledFrom string
+	allocBytes int64 // C memory (bytes) accounted when created. See `MemStats()`.
 }
 
 func newTensor(ctensor lib.Ctensor, nameOpt ...string) *Tensor {
@@ -64,14 +65,14 @@ func newTensor(ctensor lib.Ctensor, nameOpt ...string) *Tensor {
 	x := new(Tensor)
 	x.ctensor = ctensor
 	x.d = new(bigStruct)
+	x.allocBytes = allocBytes(x)
 
 	lock.Lock()
 	atomic.AddInt64(&TensorCount, 1)
 	if gotch.Debug {
-		nbytes := x.nbytes()
-		atomic.AddInt64(&AllocatedMem, nbytes)
+		atomic.AddInt64(&AllocatedMem, x.allocBytes)
 
-		log.Printf("INFO: Added tensor %q - Allocated memory: %d bytes.\n", x.name, nbytes)
+		log.Printf("INFO: Added tensor %q - Allocated memory: %d bytes.\n", x.name, x.allocBytes)
 	}
 	name := newName(nameOpt...)
 	if _, ok := ExistingTensors[name]; ok {
@@ -83,9 +84,18 @@ func newTensor(ctensor lib.Ctensor, nameOpt ...string) *Tensor {
 	x.name = name
 
 	x.calledFrom = "newTensor()"
+	if atomic.LoadInt32(&leakTracking) == 1 {
+		recordAlloc(x) // See `SetLeakTracker()`
+	}
 
 	runtime.SetFinalizer(x, freeCTensor)
 
+	// Free at exit of current goroutine scope if any. See `WithScope()`.
+	track(x)
+
+	// May trigger garbage collection or block if memory limit is crossed. See `SetMemLimit()`.
+	allocMem(x.allocBytes)
+
 	return x
 }
 
@@ -107,6 +117,9 @@ func CheckCMemLeak() string {
 	msg += fmt.Sprintf("============================= C MEMORY CHECK RESULT ==================================\n")
 	msg += fmt.Sprintf("C memory allocated not been released: %v bytes\n", memUsed)
 	msg += fmt.Sprintf("Tensors not been released: %q\n", tensors)
+	if LeakTrackerEnabled() {
+		msg += fmt.Sprintf("Live tensors by call site:\n%v", LiveTensorReport())
+	}
 	msg += fmt.Sprintf("======================================================================================\n")
 
 	return msg
@@ -136,39 +149,35 @@ func freeCTensor(ts *Tensor) error {
 	}
 
 	lock.Lock()
-	defer lock.Unlock()
-
 	if _, ok := ExistingTensors[ts.name]; !ok {
+		lock.Unlock()
 		log.Printf("WARNING: Probably double free tensor %q. Called from %q. Just skipping...\n", ts.name, ts.calledFrom)
 
 		return nil
 	}
 
 	if gotch.Debug {
-		shape, err := ts.Size()
-		if err != nil {
-			err = fmt.Errorf("ERROR: failed to release tensor %q: %w\n", ts.name, err)
-		}
-		log.Printf(err.Error())
-
-		numel := uint(FlattenDim(shape))
-		dtype := ts.DType()
-		nbytes := int64(numel * dtype.Size())
-		atomic.AddInt64(&AllocatedMem, -nbytes)
+		atomic.AddInt64(&AllocatedMem, -ts.allocBytes)
 
-		log.Printf("INFO: Released tensor %q - C memory(%d bytes).\n", ts.name, nbytes)
-	}
-
-	lib.AtFree(ts.ctensor)
-	if err := TorchErr(); err != nil {
-		err := fmt.Errorf("ERROR: failed to release tensor %q - %w", ts.name, err)
-		return err
+		log.Printf("INFO: Released tensor %q - C memory(%d bytes).\n", ts.name, ts.allocBytes)
 	}
 
 	delete(ExistingTensors, ts.name)
+	delete(allocRecords, ts.name)
+	freeMem(ts.allocBytes)
 
 	// IMPORTANT. make it nil so won't double free.
+	ctensor := ts.ctensor
 	ts.ctensor = nil
+	lock.Unlock()
+
+	// NOTE. Release C memory outside of the lock as libtorch may call back
+	// into Go here (e.g. the deleter of `FromBlob()`), which can create or drop tensors.
+	lib.AtFree(ctensor)
+	if err := TorchErr(); err != nil {
+		err := fmt.Errorf("ERROR: failed to release tensor %q - %w", ts.name, err)
+		return err
+	}
 
 	// Clear SetFinalizer on ts so no double free tensor.
 	// Ref. https://pkg.go.dev/runtime#SetFinalizer
@@ -718,6 +727,51 @@ func (ts *Tensor) MustDataPtr() unsafe.Pointer {
 	return p
 }
 
+// Bytes copies tensor data to a byte slice in Go memory.
+//
+// NOTE. data is copied from a contiguous CPU copy of the tensor, hence
+// bytes are always in row-major (C) order. See `AsBytes()` for a zero-copy
+// view of a contiguous CPU tensor.
+func (ts *Tensor) Bytes() ([]byte, error) {
+	cpuTs, err := ts.Detach(false)
+	if err != nil {
+		return nil, err
+	}
+	cpuTs, err = cpuTs.To(gotch.CPU, true)
+	if err != nil {
+		return nil, err
+	}
+	contTs, err := cpuTs.Contiguous(true)
+	if err != nil {
+		return nil, err
+	}
+	defer contTs.MustDrop()
+
+	nbytes := int(contTs.nbytes())
+	data := make([]byte, nbytes)
+	if nbytes == 0 {
+		return data, nil
+	}
+
+	ptr, err := contTs.DataPtr()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, unsafe.Slice((*byte)(ptr), nbytes))
+
+	return data, nil
+}
+
+// MustBytes copies tensor data to a byte slice in Go memory. It panics if error.
+func (ts *Tensor) MustBytes() []byte {
+	data, err := ts.Bytes()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return data
+}
+
 // Defined returns true is the tensor is defined.
 func (ts *Tensor) Defined() (bool, error) {
 	state := lib.AtDefined(ts.ctensor)
@@ -1205,9 +1259,17 @@ func (ts *Tensor) MustDrop() {
 	}
 }
 
-// GradSetEnabled sets globally whether GradMode gradient accumulation is enable or not.
+// GradSetEnabled sets whether GradMode gradient accumulation is enable or not.
 // It returns PREVIOUS state of Grad before setting.
+//
+// NOTE. Grad mode is a thread-local state of libtorch while goroutines can migrate
+// between OS threads, so the setting may leak to other goroutines or get lost.
+// Use `NoGrad()`, `EnableGrad()`, `InferenceMode()` or lock the goroutine to its
+// OS thread with `runtime.LockOSThread()` before calling it.
 func GradSetEnabled(b bool) (bool, error) {
+	// Setting and reading error must be done on the same OS thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
 	var cbool, cretVal int
 	switch b {
@@ -1244,7 +1306,7 @@ func GradSetEnabled(b bool) (bool, error) {
 	return state, nil
 }
 
-// MustGradSetEnabled sets globally whether GradMode gradient accumuation is enable or not.
+// MustGradSetEnabled sets whether GradMode gradient accumuation is enable or not.
 // It returns PREVIOUS state of Grad before setting. It will be panic if error
 func MustGradSetEnabled(b bool) bool {
 	state, err := GradSetEnabled(b)
@@ -1255,28 +1317,48 @@ func MustGradSetEnabled(b bool) bool {
 	return state
 }
 
-// NoGrad runs a closure without keeping track of gradients.
-func NoGrad(fn func()) {
-	// Switch off Grad
-	MustGradSetEnabled(false)
+// IsGradEnabled returns whether GradMode gradient accumulation is enabled
+// on the current OS thread.
+func IsGradEnabled() bool {
+	return lib.AtGradIsEnabled()
+}
 
-	fn()
+// withGradMode runs fn with grad mode set to b. The goroutine is locked to its
+// OS thread while fn runs so that the thread-local grad mode of libtorch applies
+// to ops run by fn and only to them. Previous grad mode is restored when fn returns.
+//
+// NOTE. Goroutines spawned by fn don't inherit the grad mode.
+func withGradMode(b bool, fn func()) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	// Switch on Grad
-	MustGradSetEnabled(true)
-}
+	prev := MustGradSetEnabled(b)
+	defer MustGradSetEnabled(prev)
 
-func NoGrad1(fn func() interface{}) interface{} {
-	newTs := NewTensor()
-	newTs.Drop()
+	fn()
+}
 
-	// Switch off Grad
-	prev := MustGradSetEnabled(false)
+// NoGrad runs a closure without keeping track of gradients.
+//
+// It is goroutine-safe: grad mode is only disabled for ops run by the calling
+// goroutine inside fn. Goroutines spawned by fn don't inherit it.
+func NoGrad(fn func()) {
+	withGradMode(false, fn)
+}
 
-	retVal := fn()
+// EnableGrad runs a closure with gradient tracking enabled, e.g. inside `NoGrad()`.
+// It is goroutine-safe like `NoGrad()`.
+func EnableGrad(fn func()) {
+	withGradMode(true, fn)
+}
 
-	// Switch on Grad
-	_ = MustGradSetEnabled(prev)
+// NoGrad1 runs a closure returning a value without keeping track of gradients.
+// It is goroutine-safe like `NoGrad()`.
+func NoGrad1(fn func() interface{}) interface{} {
+	var retVal interface{}
+	withGradMode(false, func() {
+		retVal = fn()
+	})
 
 	return retVal
 }
@@ -1288,9 +1370,12 @@ func NoGrad1(fn func() interface{}) interface{} {
 // That way it is similar to a with torch.no_grad(): block in python.
 // Ref. https://discuss.pytorch.org/t/how-does-nogradguard-works-in-cpp/34960/2
 //
-// TODO: should we implement Go `mutex` here???
+// The calling goroutine is locked to its OS thread until `Drop()` is called, so
+// the guard must be created and dropped by the same goroutine. `NoGrad()` should
+// be preferred.
 type NoGradGuard struct {
-	enabled bool
+	enabled bool // grad mode before the guard was created
+	dropped bool
 }
 
 // Init NoGradGuard and disables gradient tracking
@@ -1301,18 +1386,24 @@ func NewNoGradGuard() *NoGradGuard {
 // Disables gradient tracking, this will be enabled back when the
 // returned value gets deallocated.
 func noGradGuardInit() *NoGradGuard {
+	runtime.LockOSThread()
 	return &NoGradGuard{enabled: MustGradSetEnabled(false)}
 }
 
-// Drop drops the NoGradGuard state.
+// Drop restores grad mode before the guard was created and unlocks the calling
+// goroutine from its OS thread. Dropping a dropped guard is a no-op.
 func (ngg *NoGradGuard) Drop() {
-	ngg.enabled = true
+	if ngg.dropped {
+		return
+	}
+	ngg.dropped = true
 	_ = MustGradSetEnabled(ngg.enabled)
+	runtime.UnlockOSThread()
 }
 
+// Enable disables gradient tracking again if it was enabled inside the guard.
 func (ngg *NoGradGuard) Enable() {
-	ngg.enabled = false
-	_ = MustGradSetEnabled(ngg.enabled)
+	_ = MustGradSetEnabled(false)
 }
 
 const (
@@ -1554,3 +1645,27 @@ func CudaSynchronize(cudaDeviceIndexOpt ...int) error {
 
 	return TorchErr()
 }
+
+// GetRNGState returns state of the default CPU random number generator as
+// a uint8 tensor.
+func GetRNGState() (*Tensor, error) {
+	ctensor := lib.AtGetRngState()
+	if err := TorchErr(); err != nil {
+		err = fmt.Errorf("ts.GetRNGState() failed: %w\n", err)
+		return nil, err
+	}
+
+	return newTensor(ctensor), nil
+}
+
+// SetRNGState sets state of the default CPU random number generator from a
+// tensor returned by `GetRNGState`.
+func SetRNGState(state *Tensor) error {
+	lib.AtSetRngState(state.ctensor)
+	if err := TorchErr(); err != nil {
+		err = fmt.Errorf("ts.SetRNGState() failed: %w\n", err)
+		return err
+	}
+
+	return nil
+}
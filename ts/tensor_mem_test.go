@@ -1,10 +1,16 @@
 package ts
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
+	"reflect"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -81,3 +87,246 @@ func TestMem1(t *testing.T) {
 
 	fmt.Printf(CheckCMemLeak())
 }
+
+func existingTensorCount() int {
+	lock.Lock()
+	defer lock.Unlock()
+
+	return len(ExistingTensors)
+}
+
+func TestMemScope(t *testing.T) {
+	var rtm runtime.MemStats
+	runtime.ReadMemStats(&rtm)
+	printMemStats("Start", rtm)
+
+	before := existingTensorCount()
+	for i := 0; i < n; i++ {
+		WithScope(func(s *Scope) {
+			x := MustOfSlice(newData())
+			y := x.MustMulScalar(FloatScalar(2.0), false).MustAddScalar(FloatScalar(1.0), true)
+			log.Printf("created tensors : %q, %q\n", x.Name(), y.Name())
+		})
+	}
+
+	if got := existingTensorCount(); got != before {
+		t.Errorf("Want %v tensors after scopes closed, got %v\n", before, got)
+	}
+
+	runtime.ReadMemStats(&rtm)
+	printMemStats("After completing loop", rtm)
+
+	fmt.Printf(CheckCMemLeak())
+}
+
+func TestScope_Keep(t *testing.T) {
+	before := existingTensorCount()
+
+	var kept, freed, outer *Tensor
+	WithScope(func(s *Scope) {
+		outer = WithScopeTensor(func(s *Scope) *Tensor {
+			kept = MustOnes([]int64{2}, gotch.Float, gotch.CPU)
+			freed = MustZeros([]int64{2}, gotch.Float, gotch.CPU)
+			s.Keep(kept)
+			return MustOnes([]int64{3}, gotch.Float, gotch.CPU)
+		})
+
+		// Kept and returned tensors escape the inner scope only.
+		if !kept.MustDefined() || !outer.MustDefined() {
+			t.Errorf("Want kept tensors alive after inner scope closed\n")
+		}
+		if freed.ctensor != nil {
+			t.Errorf("Want not kept tensor freed after inner scope closed\n")
+		}
+		if got := s.Len(); got != 2 {
+			t.Errorf("Want 2 tensors moved to outer scope, got %v\n", got)
+		}
+	})
+
+	if kept.ctensor != nil || outer.ctensor != nil {
+		t.Errorf("Want tensors freed after outer scope closed\n")
+	}
+	if got := existingTensorCount(); got != before {
+		t.Errorf("Want %v tensors after scopes closed, got %v\n", before, got)
+	}
+}
+
+func TestScope_Goroutines(t *testing.T) {
+	var (
+		wg      sync.WaitGroup
+		other   *Tensor
+		created = make(chan struct{})
+	)
+
+	WithScope(func(s *Scope) {
+		// Tensors created by other goroutines are not tracked by this scope.
+		go func() {
+			other = MustOnes([]int64{2}, gotch.Float, gotch.CPU)
+			close(created)
+		}()
+		<-created
+
+		// Each goroutine has its own scopes.
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var x *Tensor
+				WithScope(func(s *Scope) {
+					x = MustOnes([]int64{2}, gotch.Float, gotch.CPU)
+				})
+				if x.ctensor != nil {
+					t.Errorf("Want tensor freed by goroutine scope\n")
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := s.Len(); got != 0 {
+			t.Errorf("Want no tensors tracked by scope, got %v\n", got)
+		}
+	})
+
+	if other.ctensor == nil {
+		t.Errorf("Want tensor created by other goroutine not freed by scope\n")
+	}
+	other.MustDrop()
+}
+
+func TestScope_WithoutScope(t *testing.T) {
+	var x, y *Tensor
+	WithScope(func(s *Scope) {
+		WithoutScope(func() {
+			x = MustOnes([]int64{2}, gotch.Float, gotch.CPU)
+		})
+		y = MustOnes([]int64{2}, gotch.Float, gotch.CPU)
+	})
+
+	if x.ctensor == nil {
+		t.Errorf("Want tensor created without scope not freed\n")
+	}
+	if y.ctensor != nil {
+		t.Errorf("Want tensor created in scope freed\n")
+	}
+	x.MustDrop()
+}
+
+func TestMemStats(t *testing.T) {
+	// Memory is only accounted while a limit is set.
+	SetMemLimit(1<<40, MemPressureGC)
+	defer SetMemLimit(0, MemPressureGC)
+
+	CleanUp(100) // free unreachable tensors of other tests first
+	before := MemStats()
+
+	x := MustZeros([]int64{10, 100}, gotch.Float, gotch.CPU)
+	stats := MemStats()
+	if got, want := stats.LiveBytes-before.LiveBytes, int64(10*100*4); got != want {
+		t.Errorf("Want %v live bytes added, got %v\n", want, got)
+	}
+	if got := stats.LiveTensors - before.LiveTensors; got != 1 {
+		t.Errorf("Want 1 live tensor added, got %v\n", got)
+	}
+	if stats.PeakBytes < stats.LiveBytes {
+		t.Errorf("Want peak bytes >= live bytes, got %v < %v\n", stats.PeakBytes, stats.LiveBytes)
+	}
+
+	// Views share storage, hence aren't counted.
+	v := x.MustView([]int64{1000}, false)
+	if got := MemStats().LiveBytes; got != stats.LiveBytes {
+		t.Errorf("Want no live bytes added by a view, got %v\n", got-stats.LiveBytes)
+	}
+	v.MustDrop()
+
+	x.MustDrop()
+	if got := MemStats().LiveBytes; got != before.LiveBytes {
+		t.Errorf("Want %v live bytes after drop, got %v\n", before.LiveBytes, got)
+	}
+}
+
+func TestSetMemLimit(t *testing.T) {
+	defer SetMemLimit(0, MemPressureGC)
+
+	for _, policy := range []MemPressurePolicy{MemPressureGC, MemPressureBlock} {
+		before := MemStats()
+		SetMemLimit(before.LiveBytes+10*(1<<20), policy) // 10MB above current
+
+		// Unreachable tensors of 4MB each are freed by triggered garbage collections.
+		for i := 0; i < 20; i++ {
+			MustOnes([]int64{1 << 20}, gotch.Float, gotch.CPU)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		stats := MemStats()
+		if stats.NumGC == before.NumGC {
+			t.Errorf("policy %v: want garbage collection triggered\n", policy)
+		}
+		if policy == MemPressureBlock && stats.PeakBytes > stats.Limit+(4<<20) {
+			t.Errorf("policy %v: want peak memory %v not over limit %v\n", policy, stats.PeakBytes, stats.Limit)
+		}
+		ResetPeakMem()
+	}
+}
+
+func allocForLeakReport(n int) []*Tensor {
+	var tensors []*Tensor
+	for i := 0; i < n; i++ {
+		tensors = append(tensors, MustZeros([]int64{2, 3}, gotch.Float, gotch.CPU))
+	}
+
+	return tensors
+}
+
+func TestLiveTensorReport(t *testing.T) {
+	SetLeakTracker(true)
+	defer SetLeakTracker(false)
+
+	tensors := allocForLeakReport(3)
+
+	var site *TensorAllocSite
+	report := LiveTensorReport()
+	for i, s := range report {
+		if strings.Contains(s.CallSite, "allocForLeakReport") {
+			site = &report[i]
+		}
+	}
+	if site == nil {
+		t.Fatalf("Want call site allocForLeakReport in report, got:\n%v", report)
+	}
+	if site.Count != 3 || site.Bytes != 3*2*3*4 {
+		t.Errorf("Want 3 tensors of %v bytes, got %v tensors of %v bytes\n", 3*2*3*4, site.Count, site.Bytes)
+	}
+	if !reflect.DeepEqual(site.Shapes, []string{"[2 3]"}) || !reflect.DeepEqual(site.DTypes, []string{"Float"}) {
+		t.Errorf("Want shape [2 3] and dtype Float, got %v and %v\n", site.Shapes, site.DTypes)
+	}
+	if len(site.Stack) < 2 || !strings.Contains(site.Stack[1], "TestLiveTensorReport") {
+		t.Errorf("Want trimmed stack starting at call site, got %v\n", site.Stack)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTensorProfile(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"inuse_space", "allocForLeakReport"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("Want %q in profile\n", want)
+		}
+	}
+
+	for _, x := range tensors {
+		x.MustDrop()
+	}
+	for _, s := range LiveTensorReport() {
+		if strings.Contains(s.CallSite, "allocForLeakReport") {
+			t.Errorf("Want no live tensors from allocForLeakReport after drop, got %v\n", s.Count)
+		}
+	}
+}
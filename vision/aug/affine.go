@@ -1,7 +1,6 @@
 package aug
 
 import (
-	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
@@ -33,6 +32,7 @@ import (
 // Please use the ``interpolation`` parameter instead.
 // .. _filters: https://pillow.readthedocs.io/en/latest/handbook/concepts.html#filters
 type RandomAffine struct {
+	randomizer
 	degree            []int64 // degree range
 	translate         []float64
 	scale             []float64 // scale range
@@ -42,50 +42,31 @@ type RandomAffine struct {
 }
 
 func (ra *RandomAffine) getParams(imageSize []int64) (float64, []int64, float64, []float64) {
-	angleTs := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-	angleTs.MustUniform_(float64(ra.degree[0]), float64(ra.degree[1]))
-	angle := angleTs.Float64Values()[0]
-	angleTs.MustDrop()
+	angle := ra.uniform(float64(ra.degree[0]), float64(ra.degree[1]))
 
 	var translations []int64 = []int64{0, 0}
 	if ra.translate != nil {
 		maxDX := ra.translate[0] * float64(imageSize[0])
 		maxDY := ra.translate[1] * float64(imageSize[1])
-		dx := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-		dx.MustUniform_(-maxDX, maxDX)
-		tx := dx.Float64Values()[0]
-		dx.MustDrop()
-
-		dy := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-		dy.MustUniform_(-maxDY, maxDY)
-		ty := dy.Float64Values()[0]
-		dy.MustDrop()
+		tx := ra.uniform(-maxDX, maxDX)
+		ty := ra.uniform(-maxDY, maxDY)
 
 		translations = []int64{int64(tx), int64(ty)} // should we use math.Round here???
 	}
 
 	scale := 1.0
 	if ra.scale != nil {
-		scaleTs := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-		scaleTs.MustUniform_(ra.scale[0], ra.scale[1])
-		scale = scaleTs.Float64Values()[0]
-		scaleTs.MustDrop()
+		scale = ra.uniform(ra.scale[0], ra.scale[1])
 	}
 
 	var (
 		shearX, shearY float64 = 0.0, 0.0
 	)
 	if ra.shear != nil {
-		shearXTs := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-		shearXTs.MustUniform_(ra.shear[0], ra.shear[1])
-		shearX = shearXTs.Float64Values()[0]
-		shearXTs.MustDrop()
+		shearX = ra.uniform(ra.shear[0], ra.shear[1])
 
 		if len(ra.shear) == 4 {
-			shearYTs := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-			shearYTs.MustUniform_(ra.shear[2], ra.shear[3])
-			shearY = shearYTs.Float64Values()[0]
-			shearYTs.MustDrop()
+			shearY = ra.uniform(ra.shear[2], ra.shear[3])
 		}
 	}
 
@@ -4,11 +4,11 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
 type GaussianBlur struct {
+	randomizer
 	kernelSize []int64   // >= 0 && ks%2 != 0
 	sigma      []float64 // [0.1, 2.0] range(min, max)
 }
@@ -76,10 +76,7 @@ func (b *GaussianBlur) Forward(x *ts.Tensor) *ts.Tensor {
 	assertImageTensor(x)
 	fx := Byte2FloatImage(x)
 
-	sigmaTs := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-	sigmaTs.MustUniform_(b.sigma[0], b.sigma[1])
-	sigmaVal := sigmaTs.Float64Values()[0]
-	sigmaTs.MustDrop()
+	sigmaVal := b.uniform(b.sigma[0], b.sigma[1])
 
 	out := gaussianBlur(fx, b.kernelSize, []float64{sigmaVal, sigmaVal})
 	bx := Float2ByteImage(out)
@@ -10,6 +10,7 @@ import (
 // Ref. https://github.com/pytorch/vision/blob/f1d734213af65dc06e777877d315973ba8386080/torchvision/transforms/functional_tensor.py
 
 type ColorJitter struct {
+	randomizer
 	brightness []float64
 	contrast   []float64
 	saturation []float64
@@ -188,7 +189,7 @@ func (c *ColorJitter) Forward(x *ts.Tensor) *ts.Tensor {
 	if c.brightness == nil {
 		bOut = x.MustShallowClone()
 	} else {
-		bfactor := randVal(c.brightness[0], c.brightness[1])
+		bfactor := c.uniform(c.brightness[0], c.brightness[1])
 		bOut = adjustBrightness(x, bfactor)
 	}
 	// 2. Contrast
@@ -197,7 +198,7 @@ func (c *ColorJitter) Forward(x *ts.Tensor) *ts.Tensor {
 		cOut = bOut.MustShallowClone()
 		bOut.MustDrop()
 	} else {
-		cfactor := randVal(c.contrast[0], c.contrast[1])
+		cfactor := c.uniform(c.contrast[0], c.contrast[1])
 		cOut = adjustContrast(bOut, cfactor)
 		bOut.MustDrop()
 	}
@@ -207,7 +208,7 @@ func (c *ColorJitter) Forward(x *ts.Tensor) *ts.Tensor {
 		sOut = cOut.MustShallowClone()
 		cOut.MustDrop()
 	} else {
-		sfactor := randVal(c.saturation[0], c.saturation[1])
+		sfactor := c.uniform(c.saturation[0], c.saturation[1])
 		sOut = adjustSaturation(cOut, sfactor)
 		cOut.MustDrop()
 	}
@@ -217,7 +218,7 @@ func (c *ColorJitter) Forward(x *ts.Tensor) *ts.Tensor {
 		hOut = sOut.MustShallowClone()
 		sOut.MustDrop()
 	} else {
-		hfactor := randVal(c.hue[0], c.hue[1])
+		hfactor := c.uniform(c.hue[0], c.hue[1])
 		hOut = adjustHue(sOut, hfactor)
 		sOut.MustDrop()
 	}
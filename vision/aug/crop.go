@@ -6,11 +6,11 @@ import (
 
 	// "math"
 
-	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
 type RandomCrop struct {
+	randomizer
 	size            []int64
 	padding         []int64
 	paddingIfNeeded bool
@@ -39,13 +39,8 @@ func (c *RandomCrop) params(x *ts.Tensor) (int64, int64, int64, int64) {
 		return 0, 0, h, w
 	}
 
-	iTs := ts.MustRandint(h-th+1, []int64{1}, gotch.Int64, gotch.CPU)
-	i := iTs.Int64Values()[0]
-	iTs.MustDrop()
-
-	jTs := ts.MustRandint(w-tw+1, []int64{1}, gotch.Int64, gotch.CPU)
-	j := jTs.Int64Values()[0]
-	jTs.MustDrop()
+	i := c.int63n(h - th + 1)
+	j := c.int63n(w - tw + 1)
 
 	return i, j, th, tw
 }
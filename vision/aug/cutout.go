@@ -5,7 +5,6 @@ import (
 	"log"
 	"math"
 
-	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
@@ -22,6 +21,7 @@ import (
 // R, G, B channels respectively.
 // If a str of 'random', erasing each pixel with random values.
 type RandomCutout struct {
+	randomizer
 	pvalue float64
 	scale  []float64
 	ratio  []float64
@@ -106,17 +106,10 @@ func (rc *RandomCutout) cutoutParams(x *ts.Tensor) (int64, int64, int64, int64,
 	logRatio := ts.MustOfSlice(rc.ratio).MustLog(true).Float64Values()
 
 	for i := 0; i < 10; i++ {
-		scaleTs := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-		scaleTs.MustUniform_(rc.scale[0], rc.scale[1])
-		scaleVal := scaleTs.Float64Values()[0]
-		scaleTs.MustDrop()
+		scaleVal := rc.uniform(rc.scale[0], rc.scale[1])
 		eraseArea := area * scaleVal
 
-		ratioTs := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-		ratioTs.MustUniform_(logRatio[0], logRatio[1])
-		asTs := ratioTs.MustExp(true)
-		asVal := asTs.Float64Values()[0] // aspect ratio
-		asTs.MustDrop()
+		asVal := math.Exp(rc.uniform(logRatio[0], logRatio[1])) // aspect ratio
 
 		// h = int(round(math.sqrt(erase_area * aspect_ratio)))
 		// w = int(round(math.sqrt(erase_area / aspect_ratio)))
@@ -130,13 +123,9 @@ func (rc *RandomCutout) cutoutParams(x *ts.Tensor) (int64, int64, int64, int64,
 		v := ts.MustOfSlice(rc.rgbVal).MustUnsqueeze(1, true).MustUnsqueeze(1, true)
 
 		// i = torch.randint(0, img_h - h + 1, size=(1, )).item()
-		iTs := ts.MustRandint(imgH-h+1, []int64{1}, gotch.Int64, gotch.CPU)
-		i := iTs.Int64Values()[0]
-		iTs.MustDrop()
+		i := rc.int63n(imgH - h + 1)
 		// j = torch.randint(0, img_w - w + 1, size=(1, )).item()
-		jTs := ts.MustRandint(imgW-w+1, []int64{1}, gotch.Int64, gotch.CPU)
-		j := jTs.Int64Values()[0]
-		jTs.MustDrop()
+		j := rc.int63n(imgW - w + 1)
 		return i, j, h, w, v
 	}
 
@@ -148,9 +137,7 @@ func (rc *RandomCutout) cutoutParams(x *ts.Tensor) (int64, int64, int64, int64,
 func (rc *RandomCutout) Forward(img *ts.Tensor) *ts.Tensor {
 	fx := Byte2FloatImage(img)
 
-	randTs := ts.MustRandn([]int64{1}, gotch.Float, gotch.CPU)
-	randVal := randTs.Float64Values()[0]
-	randTs.MustDrop()
+	randVal := rc.randP()
 
 	var out *ts.Tensor
 	switch randVal < rc.pvalue {
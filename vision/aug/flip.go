@@ -1,7 +1,6 @@
 package aug
 
 import (
-	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
@@ -12,6 +11,7 @@ import (
 // Args:
 // p (float): probability of the image being flipped. Default value is 0.5
 type RandomHorizontalFlip struct {
+	randomizer
 	pvalue float64
 }
 
@@ -24,9 +24,7 @@ func newRandomHorizontalFlip(pvalue float64) *RandomHorizontalFlip {
 func (hf *RandomHorizontalFlip) Forward(x *ts.Tensor) *ts.Tensor {
 	fx := Byte2FloatImage(x)
 
-	randTs := ts.MustRandn([]int64{1}, gotch.Float, gotch.CPU)
-	randVal := randTs.Float64Values()[0]
-	randTs.MustDrop()
+	randVal := hf.randP()
 	var out *ts.Tensor
 	switch {
 	case randVal < hf.pvalue:
@@ -56,6 +54,7 @@ func WithRandomHFlip(pvalue float64) Option {
 // Args:
 // p (float): probability of the image being flipped. Default value is 0.5
 type RandomVerticalFlip struct {
+	randomizer
 	pvalue float64
 }
 
@@ -68,9 +67,7 @@ func newRandomVerticalFlip(pvalue float64) *RandomVerticalFlip {
 func (vf *RandomVerticalFlip) Forward(x *ts.Tensor) *ts.Tensor {
 	fx := Byte2FloatImage(x)
 
-	randTs := ts.MustRandn([]int64{1}, gotch.Float, gotch.CPU)
-	randVal := randTs.Float64Values()[0]
-	randTs.MustDrop()
+	randVal := vf.randP()
 
 	var out *ts.Tensor
 	switch {
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"math/rand"
-	"time"
 
 	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
@@ -217,15 +215,6 @@ func adjustBrightness(x *ts.Tensor, brightness float64) *ts.Tensor {
 	return out
 }
 
-// randVal generates a value from uniform values from 0 to x
-func randVal(from, to float64) float64 {
-	v := ts.MustEmpty([]int64{1}, gotch.Float, gotch.CPU)
-	v.MustUniform_(from, to)
-	randVal := v.Float64Values()[0]
-	v.MustDrop()
-	return randVal
-}
-
 func getMinMax(x float64) (float64, float64) {
 	from := 0.0
 	if 1-x > 0 {
@@ -1094,15 +1083,6 @@ func genAffineGrid(theta *ts.Tensor, w, h, ow, oh int64) *ts.Tensor {
 	return outputGrid
 }
 
-// randPvalue generates a random propability value [0, 1]
-func randPvalue() float64 {
-	rand.Seed(time.Now().UnixNano())
-	var min, max float64 = 0.0, 1.0
-
-	r := min + rand.Float64()*(max-min)
-	return r
-}
-
 func getImageChanNum(x *ts.Tensor) int64 {
 	dim := x.MustSize()
 	switch {
@@ -3,7 +3,6 @@ package aug
 import (
 	// "fmt"
 
-	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
@@ -21,6 +20,7 @@ import (
 // fill (sequence or number): Pixel fill value for the area outside the transformed
 // image. Default is ``0``. If given a number, the value is used for all bands respectively.
 type RandomPerspective struct {
+	randomizer
 	distortionScale   float64 // range [0, 1]
 	pvalue            float64 //  range [0, 1]
 	interpolationMode string
@@ -107,13 +107,9 @@ func (rp *RandomPerspective) getParams(w, h int64) ([][]int64, [][]int64) {
 	// int(torch.randint(0, int(distortion_scale * half_height) + 1, size=(1, )).item())
 	// ]
 	tlVal1 := int64(rp.distortionScale*float64(halfW)) + 1
-	tlTs1 := ts.MustRandint(tlVal1, []int64{1}, gotch.Int64, gotch.CPU)
-	tl1 := tlTs1.Int64Values()[0]
-	tlTs1.MustDrop()
+	tl1 := rp.int63n(tlVal1)
 	tlVal2 := int64(rp.distortionScale*float64(halfH)) + 1
-	tlTs2 := ts.MustRandint(tlVal2, []int64{1}, gotch.Int64, gotch.CPU)
-	tl2 := tlTs2.Int64Values()[0]
-	tlTs2.MustDrop()
+	tl2 := rp.int63n(tlVal2)
 	topLeft = []int64{tl1, tl2}
 
 	// topright = [
@@ -121,13 +117,9 @@ func (rp *RandomPerspective) getParams(w, h int64) ([][]int64, [][]int64) {
 	// int(torch.randint(0, int(distortion_scale * half_height) + 1, size=(1, )).item())
 	// ]
 	trVal1 := w - int64(rp.distortionScale*float64(halfW)) - 1
-	trTs1 := ts.MustRandintLow(trVal1, w, []int64{1}, gotch.Int64, gotch.CPU)
-	tr1 := trTs1.Int64Values()[0]
-	trTs1.MustDrop()
+	tr1 := rp.randint(trVal1, w)
 	trVal2 := int64(rp.distortionScale*float64(halfH)) + 1
-	trTs2 := ts.MustRandint(trVal2, []int64{1}, gotch.Int64, gotch.CPU)
-	tr2 := trTs2.Int64Values()[0]
-	trTs2.MustDrop()
+	tr2 := rp.int63n(trVal2)
 	topRight = []int64{tr1, tr2}
 
 	// botright = [
@@ -135,13 +127,9 @@ func (rp *RandomPerspective) getParams(w, h int64) ([][]int64, [][]int64) {
 	// int(torch.randint(height - int(distortion_scale * half_height) - 1, height, size=(1, )).item())
 	// ]
 	brVal1 := w - int64(rp.distortionScale*float64(halfW)) - 1
-	brTs1 := ts.MustRandintLow(brVal1, w, []int64{1}, gotch.Int64, gotch.CPU)
-	br1 := brTs1.Int64Values()[0]
-	brTs1.MustDrop()
+	br1 := rp.randint(brVal1, w)
 	brVal2 := h - int64(rp.distortionScale*float64(halfH)) - 1
-	brTs2 := ts.MustRandintLow(brVal2, h, []int64{1}, gotch.Int64, gotch.CPU)
-	br2 := brTs2.Int64Values()[0]
-	brTs2.MustDrop()
+	br2 := rp.randint(brVal2, h)
 	bottomRight = []int64{br1, br2}
 
 	// botleft = [
@@ -149,13 +137,9 @@ func (rp *RandomPerspective) getParams(w, h int64) ([][]int64, [][]int64) {
 	// int(torch.randint(height - int(distortion_scale * half_height) - 1, height, size=(1, )).item())
 	// ]
 	blVal1 := int64(rp.distortionScale*float64(halfW)) + 1
-	blTs1 := ts.MustRandint(blVal1, []int64{1}, gotch.Int64, gotch.CPU)
-	bl1 := blTs1.Int64Values()[0]
-	blTs1.MustDrop()
+	bl1 := rp.int63n(blVal1)
 	blVal2 := h - int64(rp.distortionScale*float64(halfH)) - 1
-	blTs2 := ts.MustRandintLow(blVal2, h, []int64{1}, gotch.Int64, gotch.CPU)
-	bl2 := blTs2.Int64Values()[0]
-	blTs2.MustDrop()
+	bl2 := rp.randint(blVal2, h)
 	bottomLeft = []int64{bl1, bl2}
 
 	startPoints := [][]int64{
@@ -12,6 +12,7 @@ import (
 // - p (float): probability of the image being color inverted. Default value is 0.5
 // Ref. https://en.wikipedia.org/wiki/Posterization
 type RandomPosterize struct {
+	randomizer
 	pvalue float64
 	bits   uint8
 }
@@ -56,7 +57,7 @@ func newRandomPosterize(opts ...PosterizeOption) *RandomPosterize {
 
 // NOTE. Input image must be uint8 dtype otherwise panic!
 func (rp *RandomPosterize) Forward(x *ts.Tensor) *ts.Tensor {
-	r := randPvalue()
+	r := rp.randP()
 	var out *ts.Tensor
 	switch {
 	case r < rp.pvalue:
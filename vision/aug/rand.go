@@ -0,0 +1,93 @@
+package aug
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/sugarme/gotch"
+)
+
+// randomizer holds random number generator of a random transform.
+//
+// If no generator is set with `SetRand()`, one is created at first use from
+// gotch generator, which is seeded by `gotch.ManualSeed()`. See `gotch.NewRand()`.
+type randomizer struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// SetRand sets random number generator of the transform so that
+// augmentations are reproducible.
+//
+// NOTE. The generator should not be shared with other goroutines
+// without synchronization.
+func (r *randomizer) SetRand(rng *rand.Rand) {
+	r.mu.Lock()
+	r.rng = rng
+	r.mu.Unlock()
+}
+
+// float64 returns a random value in [0.0, 1.0).
+func (r *randomizer) float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rng == nil {
+		r.rng = newRand()
+	}
+
+	return r.rng.Float64()
+}
+
+// int63n returns a random value in [0, n).
+func (r *randomizer) int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rng == nil {
+		r.rng = newRand()
+	}
+
+	return r.rng.Int63n(n)
+}
+
+// uniform returns a random value in [from, to).
+func (r *randomizer) uniform(from, to float64) float64 {
+	return from + r.float64()*(to-from)
+}
+
+// randint returns a random value in [low, high).
+func (r *randomizer) randint(low, high int64) int64 {
+	return low + r.int63n(high-low)
+}
+
+// randP returns a random probability value in [0.0, 1.0).
+func (r *randomizer) randP() float64 {
+	return r.float64()
+}
+
+// randSetter is implemented by random transforms.
+type randSetter interface {
+	SetRand(rng *rand.Rand)
+}
+
+// newRand creates a new generator derived from gotch generator.
+func newRand() *rand.Rand {
+	return gotch.NewRand()
+}
+
+// WithRand sets random number generator of all random transforms composed by
+// `Compose()` so that two runs with the same seed produce identical augmentations.
+//
+// Example:
+//
+//	t, err := aug.Compose(
+//		aug.WithRandomHFlip(0.5),
+//		aug.WithRandomCrop([]int64{28, 28}, []int64{4, 4}, false, "constant"),
+//		aug.WithRand(rand.New(rand.NewSource(42))),
+//	)
+func WithRand(rng *rand.Rand) Option {
+	return func(o *Options) {
+		o.rand = rng
+	}
+}
@@ -90,6 +90,7 @@ func (rs *DownSample) Forward(x *ts.Tensor) *ts.Tensor {
 }
 
 type ZoomIn struct {
+	randomizer
 	v float64 // v should be [0, 0.5]
 }
 
@@ -129,7 +130,7 @@ func (rs *ZoomIn) Forward(x *ts.Tensor) *ts.Tensor {
 
 	var out *ts.Tensor
 	var err error
-	r := randPvalue()
+	r := rs.randP()
 	switch {
 	case r < rs.v:
 		cropW := int64(rs.v) * w
@@ -4,14 +4,15 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"math/rand"
-	"time"
 
 	"github.com/sugarme/gotch"
 	"github.com/sugarme/gotch/ts"
 )
 
 // RandomRotate randomly rotates a tensor image within a specifed angle range (degree).
+//
+// Angle is drawn from a generator derived from gotch generator, which is seeded
+// by `gotch.ManualSeed()`.
 func RandomRotate(img *ts.Tensor, min, max float64) (*ts.Tensor, error) {
 	if min > max {
 		tmp := min
@@ -24,8 +25,7 @@ func RandomRotate(img *ts.Tensor, min, max float64) (*ts.Tensor, error) {
 	}
 	// device := img.MustDevice()
 	dtype := gotch.Double
-	rand.Seed(time.Now().UnixNano())
-	angle := min + rand.Float64()*(max-min)
+	angle := min + newRand().Float64()*(max-min)
 
 	theta := float64(angle) * (math.Pi / 180)
 	input := img.MustUnsqueeze(0, false).MustTotype(dtype, true)
@@ -89,19 +89,24 @@ func WithRotate(angle float64) Option {
 
 // RandomRotateModule
 type RandRotateModule struct {
+	randomizer
 	minAngle float64
 	maxAngle float64
 }
 
 func newRandRotate(min, max float64) *RandRotateModule {
-	return &RandRotateModule{min, max}
+	return &RandRotateModule{minAngle: min, maxAngle: max}
 }
 
 // Forward implements ts.Module for RandRotateModule
 func (rr *RandRotateModule) Forward(x *ts.Tensor) *ts.Tensor {
 	fx := Byte2FloatImage(x)
 
-	out, err := RandomRotate(fx, rr.minAngle, rr.maxAngle)
+	min, max := rr.minAngle, rr.maxAngle
+	if min > max {
+		min, max = max, min
+	}
+	out, err := Rotate(fx, rr.uniform(min, max))
 	if err != nil {
 		log.Fatal(err)
 	}
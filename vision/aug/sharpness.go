@@ -12,6 +12,7 @@ import (
 // original image while 2 increases the sharpness by a factor of 2.
 // p (float): probability of the image being color inverted. Default value is 0.5
 type RandomAdjustSharpness struct {
+	randomizer
 	sharpnessFactor float64
 	pvalue          float64
 }
@@ -55,7 +56,7 @@ func newRandomAdjustSharpness(opts ...SharpnessOption) *RandomAdjustSharpness {
 
 // NOTE. input img dtype shoule be `uint8` (Byte)
 func (ras *RandomAdjustSharpness) Forward(x *ts.Tensor) *ts.Tensor {
-	r := randPvalue()
+	r := ras.randP()
 	var out *ts.Tensor
 	switch {
 	case r < ras.pvalue:
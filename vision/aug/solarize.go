@@ -13,6 +13,7 @@ import (
 // - p (float): probability of the image being color inverted. Default value is 0.5
 // Ref. https://en.wikipedia.org/wiki/Solarization_(photography)
 type RandomSolarize struct {
+	randomizer
 	threshold float64
 	pvalue    float64
 }
@@ -59,7 +60,7 @@ func newRandomSolarize(opts ...SolarizeOption) *RandomSolarize {
 func (rs *RandomSolarize) Forward(x *ts.Tensor) *ts.Tensor {
 	fx := Byte2FloatImage(x)
 
-	r := randPvalue()
+	r := rs.randP()
 	var out *ts.Tensor
 	switch {
 	case r < rs.pvalue:
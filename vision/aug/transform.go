@@ -2,7 +2,6 @@ package aug
 
 import (
 	"math/rand"
-	"time"
 
 	"github.com/sugarme/gotch/nn"
 	"github.com/sugarme/gotch/ts"
@@ -48,6 +47,9 @@ type Options struct {
 	zoomIn                *ZoomIn
 	zoomOut               *ZoomOut
 	normalize             *Normalize
+
+	rand   *rand.Rand // generator set by `WithRand()`
+	oneOfs []oneOf    // transforms to be picked by `OneOf()`
 }
 
 func defaultOption() *Options {
@@ -75,6 +77,8 @@ func defaultOption() *Options {
 		zoomIn:                nil,
 		zoomOut:               nil,
 		normalize:             nil,
+		rand:                  nil,
+		oneOfs:                nil,
 	}
 }
 
@@ -89,118 +93,154 @@ func Compose(opts ...Option) (Transformer, error) {
 		}
 	}
 
+	rng := augOpts.rand
+	if rng == nil {
+		rng = newRand()
+	}
+
+	// Pick transforms of `OneOf()` options. NOTE. A picked `OneOf()` appends
+	// to `augOpts.oneOfs`, hence length is re-evaluated to pick nested ones.
+	for i := 0; i < len(augOpts.oneOfs); i++ {
+		o := augOpts.oneOfs[i]
+		if rng.Float64() >= o.pvalue {
+			continue
+		}
+		o.tfOpts[rng.Intn(len(o.tfOpts))](augOpts)
+	}
+
 	var augs *nn.Sequential = nn.Seq()
+	var modules []ts.Module
+	add := func(m ts.Module) {
+		augs.Add(m)
+		modules = append(modules, m)
+	}
 
 	if augOpts.rotate != nil {
-		augs.Add(augOpts.rotate)
+		add(augOpts.rotate)
 	}
 
 	if augOpts.randRotate != nil {
-		augs.Add(augOpts.randRotate)
+		add(augOpts.randRotate)
 	}
 
 	if augOpts.resize != nil {
-		augs.Add(augOpts.resize)
+		add(augOpts.resize)
 	}
 
 	if augOpts.colorJitter != nil {
-		augs.Add(augOpts.colorJitter)
+		add(augOpts.colorJitter)
 	}
 
 	if augOpts.gaussianBlur != nil {
-		augs.Add(augOpts.gaussianBlur)
+		add(augOpts.gaussianBlur)
 	}
 
 	if augOpts.randomHFlip != nil {
-		augs.Add(augOpts.randomHFlip)
+		add(augOpts.randomHFlip)
 	}
 
 	if augOpts.randomVFlip != nil {
-		augs.Add(augOpts.randomVFlip)
+		add(augOpts.randomVFlip)
 	}
 
 	if augOpts.randomCrop != nil {
-		augs.Add(augOpts.randomCrop)
+		add(augOpts.randomCrop)
 	}
 
 	if augOpts.centerCrop != nil {
-		augs.Add(augOpts.centerCrop)
+		add(augOpts.centerCrop)
 	}
 
 	if augOpts.randomCutout != nil {
-		augs.Add(augOpts.randomCutout)
+		add(augOpts.randomCutout)
 	}
 
 	if augOpts.randomPerspective != nil {
-		augs.Add(augOpts.randomPerspective)
+		add(augOpts.randomPerspective)
 	}
 
 	if augOpts.randomAffine != nil {
-		augs.Add(augOpts.randomAffine)
+		add(augOpts.randomAffine)
 	}
 
 	if augOpts.randomGrayscale != nil {
-		augs.Add(augOpts.randomGrayscale)
+		add(augOpts.randomGrayscale)
 	}
 
 	if augOpts.randomSolarize != nil {
-		augs.Add(augOpts.randomSolarize)
+		add(augOpts.randomSolarize)
 	}
 
 	if augOpts.randomPosterize != nil {
-		augs.Add(augOpts.randomPosterize)
+		add(augOpts.randomPosterize)
 	}
 
 	if augOpts.randomInvert != nil {
-		augs.Add(augOpts.randomInvert)
+		add(augOpts.randomInvert)
 	}
 
 	if augOpts.randomAutocontrast != nil {
-		augs.Add(augOpts.randomAutocontrast)
+		add(augOpts.randomAutocontrast)
 	}
 
 	if augOpts.randomAdjustSharpness != nil {
-		augs.Add(augOpts.randomAdjustSharpness)
+		add(augOpts.randomAdjustSharpness)
 	}
 
 	if augOpts.randomEqualize != nil {
-		augs.Add(augOpts.randomEqualize)
+		add(augOpts.randomEqualize)
 	}
 
 	if augOpts.normalize != nil {
-		augs.Add(augOpts.normalize)
+		add(augOpts.normalize)
 	}
 
 	if augOpts.downSample != nil {
-		augs.Add(augOpts.downSample)
+		add(augOpts.downSample)
 	}
 
 	if augOpts.zoomIn != nil {
-		augs.Add(augOpts.zoomIn)
+		add(augOpts.zoomIn)
 	}
 
 	if augOpts.zoomOut != nil {
-		augs.Add(augOpts.zoomOut)
+		add(augOpts.zoomOut)
+	}
+
+	if augOpts.rand != nil {
+		// Each transform gets its own generator derived from the given one
+		// so that transforms can run concurrently.
+		for _, m := range modules {
+			if r, ok := m.(randSetter); ok {
+				r.SetRand(rand.New(rand.NewSource(augOpts.rand.Int63())))
+			}
+		}
 	}
 
 	return &Augment{augs}, nil
 }
 
-// OneOf randomly return one transformer from list of transformers
-// with a specific p value.
+type oneOf struct {
+	pvalue float64
+	tfOpts []Option
+}
+
+// OneOf randomly picks one transformer from list of transformers
+// with a specific p value when the transformers are composed.
+//
+// Random number generator set by `WithRand()` is used to pick.
 func OneOf(pvalue float64, tfOpts ...Option) Option {
-	tfsNum := len(tfOpts)
-	if tfsNum < 1 {
-		return nil
+	var opts []Option
+	for _, o := range tfOpts {
+		if o != nil {
+			opts = append(opts, o)
+		}
 	}
-
-	randP := randPvalue()
-	if randP >= pvalue {
+	if len(opts) < 1 {
 		return nil
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	idx := rand.Intn(tfsNum)
-
-	return tfOpts[idx]
+	return func(o *Options) {
+		o.oneOfs = append(o.oneOfs, oneOf{pvalue, opts})
+	}
 }
@@ -0,0 +1,52 @@
+package aug
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/sugarme/gotch"
+	"github.com/sugarme/gotch/ts"
+)
+
+func TestCompose_NestedOneOf(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		tf, err := Compose(
+			OneOf(1.0, OneOf(1.0, WithRandomHFlip(1.0), WithRandomVFlip(1.0))),
+			WithRand(rand.New(rand.NewSource(seed))),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		augs := tf.(*Augment).augments
+		if n := augs.Len(); n != 1 {
+			t.Errorf("seed %d: want 1 transform picked from nested OneOf, got %d", seed, n)
+		}
+	}
+}
+
+func TestCompose_ManualSeed(t *testing.T) {
+	img := ts.MustArange(ts.IntScalar(3*8*8), gotch.Uint8, gotch.CPU).MustView([]int64{3, 8, 8}, true)
+
+	augment := func(seed int64) [][]float64 {
+		gotch.ManualSeed(seed)
+		tf, err := Compose(WithRandomHFlip(0.5), WithRandomVFlip(0.5), WithRandRotate(-45, 45))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var outs [][]float64
+		for i := 0; i < 4; i++ {
+			outs = append(outs, tf.Transform(img).Float64Values(true))
+		}
+
+		return outs
+	}
+
+	got1 := augment(42)
+	got2 := augment(42)
+	if !reflect.DeepEqual(got1, got2) {
+		t.Errorf("want same augmentations after seeding with same seed")
+	}
+}